@@ -36,8 +36,11 @@ func gracefulShutdown(apiServer *http.Server, rulesService *rulesv2.RuleBackEndS
 	log.Println("shutting down gracefully, press Ctrl+C again to force")
 	stop() // Allow Ctrl+C to force shutdown
 
-	// Stop scheduler first
+	// Stop scheduler first, then let queued actions in flight finish
 	_ = rulesService.StopScheduler(context.Background())
+	queueCtx, queueCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	_ = rulesService.StopQueue(queueCtx)
+	queueCancel()
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
@@ -83,6 +86,9 @@ func main() {
 	if err := rulesService.StartScheduler(context.Background()); err != nil {
 		log.Printf("failed to start scheduler: %v", err)
 	}
+	if err := rulesService.StartQueue(context.Background()); err != nil {
+		log.Printf("failed to start action queue: %v", err)
+	}
 
 	// Inject rules service into domain handlers that should fire triggers
 	event.SetRulesService(rulesService)
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database"
+	rules "Automated-Scheduling-Project/internal/rulesV2"
+)
+
+/*
+	Export or import rule bundles, e.g. to copy rules from staging to production.
+
+	go run ./cmd/rules_bundle export [-ids 1,2] [-format json|yaml] [-o rules.yaml]
+	go run ./cmd/rules_bundle import [-mode skip|overwrite] [-dry-run] [-format json|yaml] rules.yaml
+
+	Import validates every rule against the registry first and writes nothing
+	if any rule is invalid. The format defaults to the file extension.
+*/
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rules_bundle export [-ids 1,2] [-format json|yaml] [-o file]")
+	fmt.Fprintln(os.Stderr, "       rules_bundle import [-mode skip|overwrite] [-dry-run] [-format json|yaml] file")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "export":
+		runExport(os.Args[2:])
+	case "import":
+		runImport(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func formatFor(flagValue, path string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return rules.BundleFormatYAML
+	}
+	return rules.BundleFormatJSON
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	idsFlag := fs.String("ids", "", "comma-separated rule ids (default: all rules)")
+	format := fs.String("format", "", "json or yaml (default: from -o extension, else json)")
+	out := fs.String("o", "", "output file (default: stdout)")
+	_ = fs.Parse(args)
+
+	var ids []uint
+	for _, v := range strings.Split(*idsFlag, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			log.Fatalf("invalid id %q", v)
+		}
+		ids = append(ids, uint(id))
+	}
+
+	svc := rules.NewRuleBackEndService(database.New().Gorm())
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	bundle, err := svc.Store.ExportBundle(ctx, ids)
+	if err != nil {
+		log.Fatalf("export: %v", err)
+	}
+	body, err := rules.EncodeBundle(bundle, formatFor(*format, *out))
+	if err != nil {
+		log.Fatalf("encode: %v", err)
+	}
+	if *out == "" {
+		os.Stdout.Write(body)
+		return
+	}
+	if err := os.WriteFile(*out, body, 0o644); err != nil {
+		log.Fatalf("write %s: %v", *out, err)
+	}
+	log.Printf("Exported %d rule(s) to %s", len(bundle.Rules), *out)
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	mode := fs.String("mode", rules.ImportModeSkip, "what to do with rules whose name already exists: skip or overwrite")
+	dryRun := fs.Bool("dry-run", false, "validate and report without writing")
+	format := fs.String("format", "", "json or yaml (default: from file extension)")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	path := fs.Arg(0)
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("read %s: %v", path, err)
+	}
+	bundle, err := rules.DecodeBundle(raw, formatFor(*format, path))
+	if err != nil {
+		log.Fatalf("decode %s: %v", path, err)
+	}
+
+	db := database.New().Gorm()
+	if err := rules.EnsureRulesTable(db); err != nil {
+		log.Fatalf("migrate rules table: %v", err)
+	}
+	svc := rules.NewRuleBackEndService(db)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	ctx = rules.WithAuthor(ctx, "rules_bundle")
+
+	report, err := svc.ImportBundle(ctx, bundle, rules.ImportOptions{Mode: *mode, DryRun: *dryRun})
+	if report != nil {
+		for _, r := range report.Rules {
+			line := fmt.Sprintf("%-8s %s", r.Action, r.Name)
+			if r.ID != "" {
+				line += " (id " + r.ID + ")"
+			}
+			fmt.Println(line)
+			for _, e := range r.Errors {
+				fmt.Printf("         %s: %s\n", e.Parameter, e.Message)
+			}
+		}
+		fmt.Printf("created=%d updated=%d skipped=%d invalid=%d dry-run=%v\n",
+			report.Created, report.Updated, report.Skipped, report.Invalid, report.DryRun)
+	}
+	if errors.Is(err, rules.ErrInvalidBundle) {
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+}
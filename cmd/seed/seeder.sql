@@ -36,6 +36,7 @@ CREATE TABLE users (
     forgot_password_link VARCHAR(255) UNIQUE,
     role VARCHAR(50) DEFAULT 'User',
     employee_number VARCHAR(200) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
     CONSTRAINT fk_employee FOREIGN KEY (employee_number) REFERENCES employee(EmployeeNumber) ON DELETE CASCADE
 );
 
@@ -222,7 +223,7 @@ INSERT INTO roles (role_name, description) VALUES ('HR', 'Human Resources role w
 -- Admin gets all pages
 INSERT INTO role_permissions (role_id, page)
 SELECT r.role_id, p.page FROM roles r CROSS JOIN (VALUES
- ('dashboard'), ('users'), ('roles'), ('compliance dashboard'), ('calendar'), ('event-definitions'), ('events'), ('rules'), ('competencies'), ('main-help')
+ ('dashboard'), ('users'), ('roles'), ('compliance dashboard'), ('calendar'), ('event-definitions'), ('events'), ('rules'), ('rules-edit'), ('rules-admin'), ('competencies'), ('main-help')
 ) AS p(page)
 WHERE r.role_name = 'Admin';
 
@@ -622,7 +623,7 @@ ON CONFLICT (user_id, role_id) DO NOTHING;
 -- Admin gets all pages
 INSERT INTO role_permissions (role_id, page)
 SELECT r.role_id, p.page FROM roles r CROSS JOIN (VALUES
- ('dashboard'), ('users'), ('roles'), ('calendar'), ('event-definitions'), ('events'), ('rules'), ('competencies'), ('main-help')
+ ('dashboard'), ('users'), ('roles'), ('calendar'), ('event-definitions'), ('events'), ('rules'), ('rules-edit'), ('rules-admin'), ('competencies'), ('main-help')
 ) AS p(page)
 WHERE r.role_name = 'Admin' ON CONFLICT DO NOTHING;
 -- User role baseline
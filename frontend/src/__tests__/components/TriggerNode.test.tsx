@@ -43,7 +43,17 @@ vi.mock('../../contexts/RulesMetadataContext', () => {
         name: 'Relative Time',
         parameters: [
             { name: 'entity_type', type: 'string', required: true, options: ['scheduled_event', 'employee_competency', 'employee', 'employment_history'] },
-            { name: 'date_field', type: 'string', required: true, options: ['event_start_date', 'event_end_date', 'expiry_date', 'termination_date', 'start_date'] },
+            {
+                name: 'date_field', type: 'string', required: true,
+                options: ['event_start_date', 'event_end_date', 'expiry_date', 'termination_date', 'start_date'],
+                dependsOn: 'entity_type',
+                optionsBy: {
+                    scheduled_event: ['event_start_date', 'event_end_date'],
+                    employee_competency: ['expiry_date'],
+                    employee: ['termination_date'],
+                    employment_history: ['start_date', 'end_date'],
+                },
+            },
             { name: 'offset_days', type: 'integer', required: true, options: [] },
             { name: 'before', type: 'boolean', required: false, options: [] },
         ],
@@ -126,13 +126,6 @@ const TriggerNode: React.FC<NodeProps<TriggerNodeData>> = ({ id, data }) => {
     const metaParamMap = new Map((meta?.parameters || []).map((p) => [p.name, p as any]));
     const visibleKeys = getVisibleParamKeys(data.triggerType, data.parameters);
 
-    // Map of date_field options per entity_type for relative_time
-    const relativeDateFields: Record<string, string[]> = React.useMemo(() => ({
-        scheduled_event: ['event_start_date', 'event_end_date'],
-        employee_competency: ['expiry_date'],
-        employee: ['termination_date'],
-        employment_history: ['start_date'],
-    }), []);
 
     // Ensure required keys for scheduled_time AFTER render to avoid setState in render.
     React.useEffect(() => {
@@ -174,11 +167,11 @@ const TriggerNode: React.FC<NodeProps<TriggerNodeData>> = ({ id, data }) => {
             },
         };
 
-        // Dynamically narrow date_field options for relative_time based on entity_type
-        if (data.triggerType === 'relative_time' && p.key === 'date_field') {
-            const et = data.parameters.find(x => x.key === 'entity_type')?.value as string || '';
-            if (et && relativeDateFields[et]) {
-                options = relativeDateFields[et];
+        // Narrow options by another parameter's value (e.g. date_field by entity_type)
+        if (def?.dependsOn && def?.optionsBy) {
+            const dep = data.parameters.find(x => x.key === def.dependsOn)?.value as string || '';
+            if (dep && def.optionsBy[dep]) {
+                options = def.optionsBy[dep];
             }
         }
 
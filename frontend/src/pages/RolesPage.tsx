@@ -15,6 +15,8 @@ const ALL_PAGES: { value: AllowedPage; label: string }[] = [
   { value: 'compliance dashboard', label: 'Compliance Dashboard' },
   { value: 'event-definitions', label: 'Event Definitions' },
   { value: 'rules', label: 'Rules' },
+  { value: 'rules-edit', label: 'Rules (edit)' },
+  { value: 'rules-admin', label: 'Rules (admin)' },
   { value: 'competencies', label: 'Competencies' },
 ];
 
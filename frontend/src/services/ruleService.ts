@@ -39,11 +39,13 @@ export type ValidationResult = {
 
 export type ParameterMeta = {
     name: string;
-    type: 'string' | 'number' | 'integer' | 'boolean' | 'date' | 'time' | 'array' | 'object';
+    type: 'string' | 'number' | 'integer' | 'boolean' | 'date' | 'time' | 'timezone' | 'array' | 'object';
     required: boolean;
     description: string;
     example?: any;
     options?: any[]; // NEW: enum-like choices for dropdowns
+    dependsOn?: string; // parameter whose value narrows options
+    optionsBy?: Record<string, any[]>;
 };
 export type TriggerMetadata = {
     type: string;
@@ -64,6 +66,7 @@ export type FactMetadata = {
     operators: string[];
     // Optional list of trigger types that provide this fact
     triggers?: string[];
+    options?: any[]; // fixed set of values the fact can take
 };
 export type OperatorMetadata = {
     name: string;
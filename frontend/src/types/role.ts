@@ -7,6 +7,8 @@ export type AllowedPage =
   | 'event-definitions'
   | 'events'
   | 'rules'
+  | 'rules-edit'
+  | 'rules-admin'
   | 'competencies'
   | 'main-help';
 
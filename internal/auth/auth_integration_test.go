@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"Automated-Scheduling-Project/internal/database/gen_models"
 
@@ -38,6 +39,7 @@ type User struct {
 	Password           string
 	ForgotPasswordLink string
 	Role               string
+	CreatedAt          *time.Time
 }
 
 const (
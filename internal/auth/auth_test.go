@@ -97,7 +97,7 @@ func TestRegisterHandler_Success_Unit(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`INSERT INTO "users" ("username","password","forgot_password_link","role","employee_number") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		`INSERT INTO "users" ("username","password","forgot_password_link","role","employee_number") VALUES ($1,$2,$3,$4,$5) RETURNING "id","created_at"`)).
 		WithArgs(testUsername, sqlmock.AnyArg(), "", "User", testEmployeeNum).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(123))
 	mock.ExpectCommit()
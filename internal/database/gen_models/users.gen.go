@@ -4,16 +4,21 @@
 
 package gen_models
 
+import (
+	"time"
+)
+
 const TableNameUser = "users"
 
 // User mapped from table <users>
 type User struct {
-	ID                 int64  `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
-	Username           string `gorm:"column:username;not null" json:"username"`
-	Password           string `gorm:"column:password;not null" json:"password"`
-	ForgotPasswordLink string `gorm:"column:forgot_password_link" json:"forgot_password_link"`
-	Role               string `gorm:"column:role;default:User" json:"role"`
-	EmployeeNumber     string `gorm:"column:employee_number;not null" json:"employee_number"`
+	ID                 int64     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	Username           string    `gorm:"column:username;not null" json:"username"`
+	Password           string    `gorm:"column:password;not null" json:"password"`
+	ForgotPasswordLink string    `gorm:"column:forgot_password_link" json:"forgot_password_link"`
+	Role               string    `gorm:"column:role;default:User" json:"role"`
+	EmployeeNumber     string    `gorm:"column:employee_number;not null" json:"employee_number"`
+	CreatedAt          time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
 }
 
 // TableName User's table name
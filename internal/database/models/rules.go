@@ -21,7 +21,207 @@ type Rule struct {
 	TriggerType string         `gorm:"size:100;index;not null" json:"triggerType"`
 	Spec        datatypes.JSON `gorm:"type:jsonb;not null" json:"spec"` // full Rulev2 as JSON
 	Enabled     bool           `gorm:"default:true" json:"enabled"`
+	// Priority mirrors Spec.priority so rules can be ordered in SQL.
+	Priority int `gorm:"not null;default:0" json:"priority"`
 
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 }
+
+// RuleRun records a single evaluation of a rule, whether it came from an
+// event dispatch, a scheduled_time cron fire or a relative_time poll.
+// Actions holds the per-action outcome (type, rendered parameters, error).
+type RuleRun struct {
+	ID          uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	RuleID      uint           `gorm:"index" json:"ruleId"`
+	RuleName    string         `gorm:"size:255" json:"ruleName"`
+	TriggerType string         `gorm:"size:100;index" json:"triggerType"`
+	Source      string         `gorm:"size:32" json:"source"` // event|schedule|relative
+	EntityType  string         `gorm:"size:100;index:idx_rule_runs_entity" json:"entityType,omitempty"`
+	EntityID    string         `gorm:"size:255;index:idx_rule_runs_entity" json:"entityId,omitempty"`
+	Matched     bool           `json:"matched"`
+	Status      string         `gorm:"size:32;index" json:"status"` // not_matched|queued|success|partial|failed|error|suppressed
+	Error       string         `gorm:"type:text" json:"error,omitempty"`
+	Actions     datatypes.JSON `gorm:"type:jsonb" json:"actions,omitempty"`
+	DurationMs  int64          `json:"durationMs"`
+	Depth       int            `gorm:"not null;default:0" json:"depth"`    // cascade depth; 0 for external events
+	CausedBy    string         `gorm:"size:255" json:"causedBy,omitempty"` // rule whose action triggered this run
+	Reason      string         `gorm:"size:255" json:"reason,omitempty"`   // why a suppressed run did not fire
+	StartedAt   time.Time      `gorm:"index" json:"startedAt"`
+}
+
+// RuleRevision is an immutable snapshot of a rule's spec, written on every
+// create, update and rollback. Revision numbers start at 1 per rule.
+type RuleRevision struct {
+	ID          uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	RuleID      uint           `gorm:"not null;uniqueIndex:idx_rule_revisions_rule_rev" json:"ruleId"`
+	Revision    int            `gorm:"not null;uniqueIndex:idx_rule_revisions_rule_rev" json:"revision"`
+	Name        string         `gorm:"size:255;not null" json:"name"`
+	TriggerType string         `gorm:"size:100;not null" json:"triggerType"`
+	Spec        datatypes.JSON `gorm:"type:jsonb;not null" json:"spec"`
+	Author      string         `gorm:"size:255" json:"author,omitempty"`
+	Note        string         `gorm:"size:255" json:"note,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// RuleJob is a queued rule action. Parameters are rendered at enqueue time;
+// Data is the JSON snapshot of the evaluation context the action receives.
+//
+// Status moves pending -> running -> succeeded, or back to pending with a
+// later NextAttemptAt on failure until MaxAttempts is reached (dead).
+// Admins may retry a dead job or discard it.
+//
+// A rule's actions are chained: every job after the first starts out
+// waiting on AfterJobID and becomes pending once that job succeeds, or
+// finishes either way when it has ContinueOnError. Otherwise the rest of the
+// chain is discarded.
+type RuleJob struct {
+	ID              uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	RuleID          uint           `gorm:"index" json:"ruleId"`
+	RuleName        string         `gorm:"size:255" json:"ruleName"`
+	TriggerType     string         `gorm:"size:100" json:"triggerType,omitempty"`         // trigger of the rule, for maintenance windows
+	RunID           uint           `gorm:"index" json:"runId,omitempty"`                  // rule_runs row whose action this is, once recorded
+	AfterJobID      uint           `gorm:"index" json:"afterJobId,omitempty"`             // job that runs before this one in the rule's chain
+	ContinueOnError bool           `gorm:"not null;default:false" json:"continueOnError"` // jobs after this one run even if it fails
+	ActionType      string         `gorm:"size:100;not null" json:"actionType"`
+	Parameters      datatypes.JSON `gorm:"type:jsonb" json:"parameters"`
+	Data            datatypes.JSON `gorm:"type:jsonb" json:"data,omitempty"`
+	EvalNow         time.Time      `json:"evalNow"`
+	Causation       datatypes.JSON `gorm:"type:jsonb" json:"causation,omitempty"`                  // rules that led to this job, oldest first
+	Status          string         `gorm:"size:32;not null;index:idx_rule_jobs_due" json:"status"` // waiting|pending|running|succeeded|dead|discarded
+	Attempts        int            `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts     int            `gorm:"not null" json:"maxAttempts"`
+	NextAttemptAt   time.Time      `gorm:"index:idx_rule_jobs_due" json:"nextAttemptAt"`
+	LastError       string         `gorm:"type:text" json:"lastError,omitempty"`
+	CompletedSteps  datatypes.JSON `gorm:"type:jsonb" json:"completedSteps,omitempty"` // parts of the action already done, skipped on retry
+	LockedBy        string         `gorm:"size:100" json:"lockedBy,omitempty"`
+	LockedAt        *time.Time     `json:"lockedAt,omitempty"`
+	FinishedAt      *time.Time     `json:"finishedAt,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// RuleFiring is the relative_time ledger: one row per rule and entity
+// occurrence (entity plus the date the offset is measured from). The unique
+// key lets several poller replicas race for the same reminder while only one
+// of them fires it. A changed date (e.g. a rescheduled event) is a new
+// occurrence and fires again.
+type RuleFiring struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RuleID     string    `gorm:"size:64;not null;uniqueIndex:idx_rule_firings_occurrence" json:"ruleId"`
+	EntityType string    `gorm:"size:100;not null;uniqueIndex:idx_rule_firings_occurrence" json:"entityType"`
+	EntityKey  string    `gorm:"size:255;not null;uniqueIndex:idx_rule_firings_occurrence" json:"entityKey"`
+	TargetDate time.Time `gorm:"not null;uniqueIndex:idx_rule_firings_occurrence" json:"targetDate"`
+	FiredAt    time.Time `gorm:"not null;index" json:"firedAt"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+}
+
+// RuleTriggerState remembers when a time-based rule last fired successfully
+// so the scheduler can reconcile fires missed during downtime on start. For
+// relative_time rules it is the last completed poll.
+type RuleTriggerState struct {
+	RuleID      string    `gorm:"primaryKey;size:64" json:"ruleId"`
+	TriggerType string    `gorm:"primaryKey;size:100" json:"triggerType"`
+	LastFiredAt time.Time `gorm:"not null" json:"lastFiredAt"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// SchedulerLease elects the single API instance that runs time-based
+// triggers. The holder renews ExpiresAt while alive; once it lapses any
+// other instance may take the lease over.
+type SchedulerLease struct {
+	Name       string    `gorm:"primaryKey;size:100" json:"name"`
+	Holder     string    `gorm:"size:255;not null" json:"holder"`
+	AcquiredAt time.Time `gorm:"not null" json:"acquiredAt"`
+	RenewedAt  time.Time `gorm:"not null" json:"renewedAt"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expiresAt"`
+}
+
+// PublicHoliday is a non-working day in a holiday region (e.g. "ZA"). Rules
+// counting in business days skip weekends and the holidays of their region.
+type PublicHoliday struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Region    string    `gorm:"size:50;not null;uniqueIndex:idx_public_holidays_region_date" json:"region"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_public_holidays_region_date" json:"date"`
+	Name      string    `gorm:"size:255" json:"name"`
+	Source    string    `gorm:"size:20" json:"source"` // manual|ics|csv
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// RuleThrottleHit is one firing counted against a rule's limits. The quota
+// counts a rule's hits within its window; the cooldown looks for a recent
+// hit with the same key (e.g. "employee_competency:42").
+type RuleThrottleHit struct {
+	ID      uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RuleID  string    `gorm:"size:64;not null;index:idx_rule_throttle_hits_rule" json:"ruleId"`
+	Key     string    `gorm:"size:255;not null;index:idx_rule_throttle_hits_rule" json:"key"`
+	FiredAt time.Time `gorm:"not null;index" json:"firedAt"`
+}
+
+// RuleThrottleLock is locked while a rule's limits are checked and a hit is
+// recorded, so concurrent firings of one rule are counted one at a time.
+type RuleThrottleLock struct {
+	RuleID string `gorm:"primaryKey;size:64" json:"ruleId"`
+}
+
+// RuleSetting is an engine-wide setting such as the kill switch.
+type RuleSetting struct {
+	Key       string    `gorm:"primaryKey;size:100" json:"key"`
+	Value     string    `gorm:"size:255;not null" json:"value"`
+	UpdatedBy string    `gorm:"size:255" json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// NotificationDigestItem is one notification held back for a digest. Items
+// for the same recipient and channel are sent together as a single message
+// once the earliest of them is due (FlushAfter); each item keeps its own
+// row so what was combined stays visible.
+type NotificationDigestItem struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	RuleID         string     `gorm:"size:64" json:"ruleId,omitempty"`
+	RuleName       string     `gorm:"size:255" json:"ruleName,omitempty"`
+	TriggerType    string     `gorm:"size:100" json:"triggerType,omitempty"`                                    // trigger of the rule, for maintenance windows
+	Recipient      string     `gorm:"size:100;not null;index:idx_notification_digest_pending" json:"recipient"` // employee number
+	Channel        string     `gorm:"size:20;not null;index:idx_notification_digest_pending" json:"channel"`    // email|sms|push
+	Subject        string     `gorm:"size:255" json:"subject"`
+	Message        string     `gorm:"type:text" json:"message"`
+	DigestSubject  string     `gorm:"size:255" json:"digestSubject,omitempty"` // template, see NotificationAction
+	DigestTemplate string     `gorm:"type:text" json:"digestTemplate,omitempty"`
+	FlushAfter     time.Time  `gorm:"not null;index" json:"flushAfter"`
+	SentAt         *time.Time `gorm:"index:idx_notification_digest_pending" json:"sentAt,omitempty"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// RuleAPIKey authenticates an external system calling the rule trigger
+// endpoints. Only the SHA-256 hash of the key is stored. Scopes lists what
+// the key may do, e.g. ["trigger:competency"] or ["trigger:*"].
+type RuleAPIKey struct {
+	ID         uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name       string         `gorm:"size:255;not null" json:"name"`
+	Prefix     string         `gorm:"size:16;not null" json:"prefix"` // start of the key, to recognise it
+	KeyHash    string         `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes     datatypes.JSON `gorm:"type:jsonb;not null" json:"scopes"`
+	CreatedBy  string         `gorm:"size:255" json:"createdBy,omitempty"`
+	LastUsedAt *time.Time     `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time     `gorm:"index" json:"revokedAt,omitempty"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// RuleMaintenance is a maintenance window silencing rules for a bounded time,
+// e.g. during a data migration or bulk import. TriggerTypes lists the
+// silenced trigger types; an empty list silences every rule. Rows stay after
+// the window ends as a record of who silenced what.
+type RuleMaintenance struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	TriggerTypes datatypes.JSON `gorm:"type:jsonb;not null" json:"triggerTypes"`
+	Reason       string         `gorm:"size:500" json:"reason,omitempty"`
+	StartedBy    string         `gorm:"size:255" json:"startedBy,omitempty"`
+	StartedAt    time.Time      `gorm:"not null" json:"startedAt"`
+	EndsAt       time.Time      `gorm:"not null;index" json:"endsAt"`
+	EndedBy      string         `gorm:"size:255" json:"endedBy,omitempty"` // who ended it early
+	EndedAt      *time.Time     `gorm:"index" json:"endedAt,omitempty"`
+}
@@ -3,9 +3,11 @@ package role
 import (
 	"Automated-Scheduling-Project/internal/database/gen_models"
 	"Automated-Scheduling-Project/internal/database/models"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // RequirePage ensures the authenticated user has permission to view the given logical page.
@@ -42,3 +44,22 @@ func RequirePage(page string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// HasPage reports whether the authenticated user (email set by auth
+// middleware) has permission to the given page. Handlers use it for checks
+// that depend on the request body, where RequirePage cannot be applied. An
+// unknown user has no permissions; any other lookup failure is returned.
+func HasPage(c *gin.Context, page string) (bool, error) {
+	email := c.GetString("email")
+	if email == "" {
+		return false, nil
+	}
+	var ext models.ExtendedEmployee
+	if err := DB.Model(&gen_models.Employee{}).Preload("User").Where("Useraccountemail = ?", email).First(&ext).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return UserHasPagePermission(ext.User.ID, page)
+}
@@ -316,3 +316,27 @@ func TestDeleteRoleHandler_NotFound_Unit(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, rec.Code)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestHasPage_LookupError_Unit(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM "employee" WHERE Useraccountemail = $1`)).
+		WillReturnError(errors.New("boom"))
+	c, _ := ctxJSON(t, db, http.MethodPost, "/api/rules", nil)
+	c.Set("email", "a@example.com")
+	ok, err := HasPage(c, "rules-admin")
+	require.Error(t, err, "a failed lookup is not a denial")
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHasPage_UnknownUser_Unit(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM "employee" WHERE Useraccountemail = $1`)).
+		WillReturnError(gorm.ErrRecordNotFound)
+	c, _ := ctxJSON(t, db, http.MethodPost, "/api/rules", nil)
+	c.Set("email", "nobody@example.com")
+	ok, err := HasPage(c, "rules-admin")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
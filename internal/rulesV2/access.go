@@ -0,0 +1,231 @@
+package rulesv2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Permissions guarding /api/rules. They are role page permissions, granted
+// to roles alongside pages such as "users".
+const (
+	PermissionRulesView  = "rules"       // read rules, runs and metadata; dry runs
+	PermissionRulesEdit  = "rules-edit"  // change rules, holidays, jobs, the kill switch and maintenance
+	PermissionRulesAdmin = "rules-admin" // high-risk actions and API keys
+)
+
+// defaultActionPermissions lists the action types only holders of a
+// stronger permission may put in a rule, since they reach outside the
+// system.
+var defaultActionPermissions = map[string]string{
+	"webhook": PermissionRulesAdmin,
+}
+
+// RouteAccess authorises the user-facing /api/rules endpoints. The server
+// supplies auth.AuthMiddleware and the role package's page checks; rulesv2
+// cannot import them itself since role imports rulesv2.
+type RouteAccess struct {
+	// Authenticate rejects anonymous requests and sets "email".
+	Authenticate gin.HandlerFunc
+	// RequirePermission rejects requests without the permission.
+	RequirePermission func(permission string) gin.HandlerFunc
+	// HasPermission checks a permission that depends on the request body.
+	HasPermission func(c *gin.Context, permission string) (bool, error)
+}
+
+// hasPermissionKey holds RouteAccess.HasPermission on the request context.
+const hasPermissionKey = "rules.hasPermission"
+
+// authorizeActions reports whether the caller may save or run rules with
+// these actions, writing 403 when a high-risk action needs a permission they lack.
+// Without a permission check on the context, e.g. on a route registered
+// outside RegisterRulesRoutes, such actions are always refused.
+func authorizeActions(c *gin.Context, service *RuleBackEndService, rules ...Rulev2) bool {
+	has, _ := c.Value(hasPermissionKey).(func(*gin.Context, string) (bool, error))
+	checked := map[string]bool{}
+	for _, r := range rules {
+		for _, a := range r.Actions {
+			perm := service.ActionPermissions[a.Type]
+			if perm == "" || checked[perm] {
+				continue
+			}
+			var ok bool
+			var err error
+			if has != nil {
+				ok, err = has(c, perm)
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Permission check failed"})
+				return false
+			}
+			if !ok {
+				c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("%s actions require the %q permission", a.Type, perm)})
+				return false
+			}
+			checked[perm] = true
+		}
+	}
+	return true
+}
+
+/* ------------------------------- API keys -------------------------------- */
+
+// APIKeyHeader carries the API key on trigger requests.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix starts every generated key so leaked keys are recognisable.
+const apiKeyPrefix = "rk_"
+
+// ScopeAllTriggers lets an API key fire every trigger type.
+const ScopeAllTriggers = "trigger:*"
+
+// Errors returned when creating or checking API keys.
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key request")
+	ErrAPIKeyRejected = errors.New("API key is missing, unknown or revoked")
+	ErrAPIKeyScope    = errors.New("API key is not allowed to do this")
+)
+
+// TriggerScope is the API key scope that allows firing triggerType.
+func TriggerScope(triggerType string) string {
+	return "trigger:" + triggerType
+}
+
+// APIKeyInput is the body accepted by POST /api/rules/api-keys.
+type APIKeyInput struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"` // e.g. ["trigger:competency"] or ["trigger:*"]
+}
+
+// CreateAPIKey stores a new key and returns it; the plain key is only
+// available here.
+func (s *RuleBackEndService) CreateAPIKey(ctx context.Context, in APIKeyInput, by string) (string, *models.RuleAPIKey, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return "", nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if len(in.Scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	for _, sc := range in.Scopes {
+		t, ok := strings.CutPrefix(sc, "trigger:")
+		if !ok || (t != "*" && s.Engine.R.Triggers[t] == nil) {
+			return "", nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKey, sc)
+		}
+	}
+	scopes, err := json.Marshal(in.Scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(buf)
+	row := models.RuleAPIKey{
+		Name:      name,
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		KeyHash:   hashAPIKey(key),
+		Scopes:    datatypes.JSON(scopes),
+		CreatedBy: by,
+	}
+	if err := s.DB.WithContext(ctx).Create(&row).Error; err != nil {
+		return "", nil, err
+	}
+	return key, &row, nil
+}
+
+// ListAPIKeys returns every key, revoked ones included, newest first.
+func (s *RuleBackEndService) ListAPIKeys(ctx context.Context) ([]models.RuleAPIKey, error) {
+	var rows []models.RuleAPIKey
+	err := s.DB.WithContext(ctx).Order("id DESC").Find(&rows).Error
+	return rows, err
+}
+
+// RevokeAPIKey disables a key, returning gorm.ErrRecordNotFound when there
+// is no active key with that id.
+func (s *RuleBackEndService) RevokeAPIKey(ctx context.Context, id uint) error {
+	res := s.DB.WithContext(ctx).Model(&models.RuleAPIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey returns the active key matching key if it holds scope.
+func (s *RuleBackEndService) AuthenticateAPIKey(ctx context.Context, key, scope string) (*models.RuleAPIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrAPIKeyRejected
+	}
+	var row models.RuleAPIKey
+	err := s.DB.WithContext(ctx).Where("key_hash = ? AND revoked_at IS NULL", hashAPIKey(key)).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+	var scopes []string
+	if err := json.Unmarshal(row.Scopes, &scopes); err != nil {
+		return nil, fmt.Errorf("API key %d has unreadable scopes: %w", row.ID, err)
+	}
+	if !slices.Contains(scopes, scope) && !(strings.HasPrefix(scope, "trigger:") && slices.Contains(scopes, ScopeAllTriggers)) {
+		return nil, ErrAPIKeyScope
+	}
+	now := time.Now().UTC()
+	if err := s.DB.WithContext(ctx).Model(&row).Update("last_used_at", now).Error; err != nil {
+		log.Printf("rules: failed to record use of API key %d: %v", row.ID, err)
+	}
+	row.LastUsedAt = &now
+	return &row, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// requireAPIKey authenticates external systems on the trigger endpoints with
+// an API key that may fire triggerType.
+func requireAPIKey(service *RuleBackEndService, triggerType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		key, err := service.AuthenticateAPIKey(ctx, c.GetHeader(APIKeyHeader), TriggerScope(triggerType))
+		switch {
+		case errors.Is(err, ErrAPIKeyRejected):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, ErrAPIKeyScope):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("API key lacks scope %q", TriggerScope(triggerType))})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "API key check failed"})
+			return
+		}
+		c.Set("apiKey", key.Name)
+		c.Next()
+	}
+}
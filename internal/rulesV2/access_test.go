@@ -0,0 +1,175 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// grantedAccess authenticates every request as tester@example.com holding
+// the given permissions.
+func grantedAccess(perms ...string) RouteAccess {
+	has := func(_ *gin.Context, perm string) (bool, error) {
+		for _, p := range perms {
+			if p == perm {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return RouteAccess{
+		Authenticate: func(c *gin.Context) {
+			c.Set("email", "tester@example.com")
+			c.Next()
+		},
+		RequirePermission: func(perm string) gin.HandlerFunc {
+			return func(c *gin.Context) {
+				if ok, _ := has(c, perm); !ok {
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied. Page not permitted."})
+					return
+				}
+				c.Next()
+			}
+		},
+		HasPermission: has,
+	}
+}
+
+func auditRule() Rulev2 {
+	return Rulev2{Name: "audit", Trigger: TriggerSpec{Type: "competency"},
+		Actions: []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"action": "competency_changed"}}}}
+}
+
+func webhookRule() Rulev2 {
+	return Rulev2{Name: "notify HR", Trigger: TriggerSpec{Type: "competency"},
+		Actions: []ActionSpec{{Type: "webhook", Parameters: map[string]any{"url": "https://hr.example.com/hook"}}}}
+}
+
+func TestRoutes_ViewAndEditPermissions(t *testing.T) {
+	viewer, _ := setupRouterWith(t, grantedAccess(PermissionRulesView))
+	rec := doJSON(t, viewer, http.MethodGet, "/api/rules/rules", nil)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = doJSON(t, viewer, http.MethodGet, "/api/rules/metadata", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = doJSON(t, viewer, http.MethodPost, "/api/rules/rules", auditRule())
+	assert.Equal(t, http.StatusForbidden, rec.Code, "viewers cannot create rules")
+	rec = doJSON(t, viewer, http.MethodPut, "/api/rules/kill-switch", map[string]any{"engaged": true})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	nobody, _ := setupRouterWith(t, grantedAccess())
+	rec = doJSON(t, nobody, http.MethodGet, "/api/rules/rules", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "the group needs the rules permission")
+
+	editor, _ := setupRouterWith(t, grantedAccess(PermissionRulesView, PermissionRulesEdit))
+	rec = doJSON(t, editor, http.MethodPost, "/api/rules/rules", auditRule())
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = doJSON(t, editor, http.MethodGet, "/api/rules/api-keys", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "API keys need rules-admin")
+}
+
+func TestRoutes_HighRiskActionsNeedAdmin(t *testing.T) {
+	editor, svc := setupRouterWith(t, grantedAccess(PermissionRulesView, PermissionRulesEdit))
+	rec := doJSON(t, editor, http.MethodPost, "/api/rules/rules", webhookRule())
+	require.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `webhook actions require the \"rules-admin\" permission`)
+
+	// An admin's webhook rule cannot be taken over by an editor either.
+	id, err := svc.CreateRule(context.Background(), webhookRule())
+	require.NoError(t, err)
+	rec = doJSON(t, editor, http.MethodPut, "/api/rules/rules/"+id, webhookRule())
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Nor can an editor execute webhooks by running the rule or retrying its jobs.
+	scheduled := webhookRule()
+	scheduled.Trigger = TriggerSpec{Type: "scheduled_time", Parameters: map[string]any{"frequency": "daily", "time_of_day": "09:00"}}
+	id, err = svc.CreateRule(context.Background(), scheduled)
+	require.NoError(t, err)
+	rec = doJSON(t, editor, http.MethodPost, "/api/rules/rules/"+id+"/run", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	jobID, err := svc.Queue.Enqueue(context.Background(), QueuedAction{RuleID: id, RuleName: "notify HR", ActionType: "webhook", MaxAttempts: 1})
+	require.NoError(t, err)
+	require.NoError(t, svc.Queue.DiscardJob(context.Background(), jobID))
+	rec = doJSON(t, editor, http.MethodPost, fmt.Sprintf("/api/rules/jobs/%d/retry", jobID), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	rec = doJSON(t, editor, http.MethodPost, fmt.Sprintf("/api/rules/jobs/%d/discard", jobID), nil)
+	assert.Equal(t, http.StatusConflict, rec.Code, "discarding needs no action permission")
+
+	admin, _ := setupRouterWith(t, grantedAccess(PermissionRulesView, PermissionRulesEdit, PermissionRulesAdmin))
+	rec = doJSON(t, admin, http.MethodPost, "/api/rules/rules", webhookRule())
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
+
+func TestAuthorizeActions_FailsClosedWithoutPermissionCheck(t *testing.T) {
+	service := &RuleBackEndService{ActionPermissions: defaultActionPermissions}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	assert.True(t, authorizeActions(c, service, auditRule()), "ordinary actions need no extra permission")
+
+	assert.False(t, authorizeActions(c, service, webhookRule()))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRoutes_TriggersNeedScopedAPIKey(t *testing.T) {
+	router, svc := setupRouter(t)
+	fire := func(key string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(map[string]any{"operation": "update", "competency": map[string]any{"CompetencyID": 1}})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/rules/trigger/competency", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(APIKeyHeader, key) // set even when empty, so no default key is added
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, fire("").Code)
+	assert.Equal(t, http.StatusUnauthorized, fire("rk_not-a-key").Code)
+
+	roles, _, err := svc.CreateAPIKey(context.Background(), APIKeyInput{Name: "roles only", Scopes: []string{TriggerScope("roles")}}, "tester@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, fire(roles).Code, "the key may only fire roles")
+
+	// Keys are issued and revoked by admins over the API.
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/api-keys", map[string]any{"name": "HR system", "scopes": []string{"trigger:competency"}})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		Key    string `json:"key"`
+		APIKey struct {
+			ID        uint   `json:"id"`
+			Prefix    string `json:"prefix"`
+			CreatedBy string `json:"createdBy"`
+		} `json:"apiKey"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, created.Key[:len(created.APIKey.Prefix)], created.APIKey.Prefix)
+	assert.Equal(t, "tester@example.com", created.APIKey.CreatedBy)
+	assert.NotContains(t, rec.Body.String(), hashAPIKey(created.Key), "the hash is never returned")
+
+	rec = fire(created.Key)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doJSON(t, router, http.MethodDelete, "/api/rules/api-keys/"+fmt.Sprint(created.APIKey.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, fire(created.Key).Code, "revoked keys are rejected")
+	rec = doJSON(t, router, http.MethodDelete, "/api/rules/api-keys/"+fmt.Sprint(created.APIKey.ID), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/api-keys", map[string]any{"name": "bad", "scopes": []string{"trigger:nope"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRegisterRulesRoutes_RequiresAccess(t *testing.T) {
+	assert.Panics(t, func() { RegisterRulesRoutes(gin.New(), &RuleBackEndService{}, RouteAccess{}) })
+}
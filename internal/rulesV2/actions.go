@@ -2,6 +2,7 @@ package rulesv2
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -14,6 +15,8 @@ import (
 	"Automated-Scheduling-Project/internal/database/gen_models"
 	"Automated-Scheduling-Project/internal/database/models"
 	"Automated-Scheduling-Project/internal/email"
+	"Automated-Scheduling-Project/internal/rulesV2/holidays"
+	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
 	"Automated-Scheduling-Project/internal/sms"
 
 	"gorm.io/gorm"
@@ -53,6 +56,7 @@ func parseStandardDuration(durationStr string) (time.Duration, error) {
 // RelativeDateParser handles parsing and resolving relative date expressions
 type RelativeDateParser struct {
 	baseTime time.Time
+	calendar *holidays.Calendar // business-day arithmetic; nil skips weekends only
 }
 
 // NewRelativeDateParser creates a new parser with the given base time
@@ -60,8 +64,14 @@ func NewRelativeDateParser(baseTime time.Time) *RelativeDateParser {
 	return &RelativeDateParser{baseTime: baseTime}
 }
 
+// WithHolidays makes "business day" expressions also skip the holidays of cal
+func (p *RelativeDateParser) WithHolidays(cal *holidays.Calendar) *RelativeDateParser {
+	p.calendar = cal
+	return p
+}
+
 // ParseRelativeDate parses relative date expressions and returns the actual time
-// Supports formats like: "today", "tomorrow", "in 5 days", "in 2 months", "in 1 year"
+// Supports formats like: "today", "tomorrow", "in 5 days", "in 5 business days", "in 2 months", "in 1 year"
 // Also supports absolute ISO date strings
 func (p *RelativeDateParser) ParseRelativeDate(dateExpr string) (time.Time, error) {
 	dateExpr = strings.TrimSpace(dateExpr)
@@ -122,7 +132,7 @@ func (p *RelativeDateParser) ParseRelativeDate(dateExpr string) (time.Time, erro
 		baseDate = p.baseTime.AddDate(1, 0, 0)
 	default:
 		// Handle "in X unit" format
-		inRegex := regexp.MustCompile(`^in\s+(\d+)\s+(day|days|week|weeks|month|months|year|years)$`)
+		inRegex := regexp.MustCompile(`^in\s+(\d+)\s+(day|days|(?:business|working)\s+days?|week|weeks|month|months|year|years)$`)
 		matches := inRegex.FindStringSubmatch(lowerExpr)
 		if len(matches) == 3 {
 			amount, err := strconv.Atoi(matches[1])
@@ -132,6 +142,8 @@ func (p *RelativeDateParser) ParseRelativeDate(dateExpr string) (time.Time, erro
 
 			unit := matches[2]
 			switch {
+			case strings.HasPrefix(unit, "business"), strings.HasPrefix(unit, "working"):
+				baseDate = p.calendar.AddBusinessDays(p.baseTime, amount)
 			case strings.HasPrefix(unit, "day"):
 				baseDate = p.baseTime.AddDate(0, 0, amount)
 			case strings.HasPrefix(unit, "week"):
@@ -160,7 +172,14 @@ func (p *RelativeDateParser) ParseRelativeDate(dateExpr string) (time.Time, erro
 
 // NotificationAction handles sending notifications
 type NotificationAction struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Limiter *DbLimiter // holds digests back during the kill switch and maintenance; optional
+}
+
+// Describe returns the notification action's metadata.
+func (a *NotificationAction) Describe() meta.ActionMetadata {
+	m, _ := meta.Action("notification")
+	return m
 }
 
 func (a *NotificationAction) Execute(ctx EvalContext, params map[string]any) error {
@@ -198,64 +217,75 @@ func (a *NotificationAction) Execute(ctx EvalContext, params map[string]any) err
 		notificationType = "email" // Default to email notification
 	}
 
-	// Send notification to each recipient
+	// Digest mode: buffer per recipient and send one combined message later
+	if digest, _ := params["digest"].(string); digest != "" {
+		return a.bufferDigest(ctx, params, digest, recipients, notificationType, subject, message)
+	}
+
+	// Send notification to each recipient. A queue retry skips those an
+	// earlier attempt already reached.
 	for _, employeeNumber := range recipients {
 		if employeeNumber == "" {
 			continue // Skip empty recipient entries
 		}
-
-		// Get employee email from database
-		var employee gen_models.Employee
-		if err := a.DB.Where("employeenumber = ?", employeeNumber).First(&employee).Error; err != nil {
-			log.Printf("Failed to find employee %s: %v", employeeNumber, err)
-			return fmt.Errorf("failed to find employee %s: %w", employeeNumber, err)
+		if ctx.steps.Done(employeeNumber) {
+			continue
+		}
+		if err := a.deliver(employeeNumber, notificationType, subject, message); err != nil {
+			return err
 		}
+		ctx.steps.Mark(employeeNumber)
+	}
 
-		switch notificationType {
-		case "email":
-			employeeEmail := employee.Useraccountemail
+	return nil
+}
 
-			err := a.sendEmail(employeeEmail, subject, message)
-			if err != nil {
-				log.Printf("Failed to send email to %s (%s): %v", employeeNumber, employeeEmail, err)
-				return fmt.Errorf("failed to execute NotificationAction for %s: %w", employeeNumber, err)
-			}
-		case "sms":
-			// Get employee phone number from database
-			var employee gen_models.Employee
-			if err := a.DB.Where("employeenumber = ?", employeeNumber).First(&employee).Error; err != nil {
-				log.Printf("Failed to find employee %s: %v", employeeNumber, err)
-				return fmt.Errorf("failed to find employee %s: %w", employeeNumber, err)
-			}
-			var employeeSMS string
-			// Only send sms if the employee has a phone number
-			if employee.PhoneNumber != nil {
-				employeeSMS = *employee.PhoneNumber
-			} else {
-				log.Printf("Employee %s has no phone number, skipping SMS", employeeNumber)
-				continue
-			}
+// deliver sends one notification to an employee over the given channel
+func (a *NotificationAction) deliver(employeeNumber, notificationType, subject, message string) error {
+	// Get employee email from database
+	var employee gen_models.Employee
+	if err := a.DB.Where("employeenumber = ?", employeeNumber).First(&employee).Error; err != nil {
+		log.Printf("Failed to find employee %s: %v", employeeNumber, err)
+		return fmt.Errorf("failed to find employee %s: %w", employeeNumber, err)
+	}
 
-			smsWithSubject := subject + "\n\n" + message
-			err := a.sendSMS(employeeSMS, smsWithSubject)
-			if err != nil {
-				log.Printf("Failed to send SMS to %s (%s): %v", employeeNumber, employeeSMS, err)
-				return fmt.Errorf("failed to send SMS to %s: %w", employeeNumber, err)
-			}
-		case "push":
-			// TODO: Implement push notification logic here
-			log.Printf("PUSH NOTIFICATION SENT: To=%s, Subject=%s, Message=%s", employeeNumber, subject, message)
-		default:
-			return fmt.Errorf("unknown notification type: %s", notificationType)
+	switch notificationType {
+	case "email":
+		employeeEmail := employee.Useraccountemail
+
+		err := a.sendEmail(employeeEmail, subject, message)
+		if err != nil {
+			log.Printf("Failed to send email to %s (%s): %v", employeeNumber, employeeEmail, err)
+			return fmt.Errorf("failed to execute NotificationAction for %s: %w", employeeNumber, err)
+		}
+	case "sms":
+		var employeeSMS string
+		// Only send sms if the employee has a phone number
+		if employee.PhoneNumber != nil {
+			employeeSMS = *employee.PhoneNumber
+		} else {
+			log.Printf("Employee %s has no phone number, skipping SMS", employeeNumber)
+			return nil
 		}
 
-		// Log each successful notification
-		err := a.logSuccessfulNotification(employee.Useraccountemail, subject, message, notificationType)
+		smsWithSubject := subject + "\n\n" + message
+		err := a.sendSMS(employeeSMS, smsWithSubject)
 		if err != nil {
-			log.Printf("Failed to log notification for %s: %v", employeeNumber, err)
+			log.Printf("Failed to send SMS to %s (%s): %v", employeeNumber, employeeSMS, err)
+			return fmt.Errorf("failed to send SMS to %s: %w", employeeNumber, err)
 		}
+	case "push":
+		// TODO: Implement push notification logic here
+		log.Printf("PUSH NOTIFICATION SENT: To=%s, Subject=%s, Message=%s", employeeNumber, subject, message)
+	default:
+		return fmt.Errorf("unknown notification type: %s", notificationType)
 	}
 
+	// Log each successful notification
+	err := a.logSuccessfulNotification(employee.Useraccountemail, subject, message, notificationType)
+	if err != nil {
+		log.Printf("Failed to log notification for %s: %v", employeeNumber, err)
+	}
 	return nil
 }
 
@@ -310,6 +340,17 @@ func (a *NotificationAction) sendSMS(recipient, message string) error {
 
 type CreateEventAction struct {
 	DB *gorm.DB
+
+	// OnCreated, when set, is called for each created schedule so the
+	// scheduled_event trigger fires just like for schedules created in the UI.
+	// ctx carries the causation chain (see WithCausation).
+	OnCreated func(ctx context.Context, schedule *models.CustomEventSchedule) error
+}
+
+// Describe returns the create_event action's metadata.
+func (a *CreateEventAction) Describe() meta.ActionMetadata {
+	m, _ := meta.Action("create_event")
+	return m
 }
 
 func (a *CreateEventAction) Execute(ctx EvalContext, params map[string]any) error {
@@ -322,6 +363,7 @@ func (a *CreateEventAction) Execute(ctx EvalContext, params map[string]any) erro
 	statusName, _ := params["statusName"].(string)
 	maxAttendees, _ := params["maxAttendees"].(int)
 	minAttendees, _ := params["minAttendees"].(int)
+	holidayRegion, _ := params["holidayRegion"].(string)
 
 	// Log extracted string parameters
 	// log.Printf("Extracted parameters: title='%s', startTime='%s', endTime='%s', roomName='%s', color='%s', statusName='%s'",
@@ -388,6 +430,13 @@ func (a *CreateEventAction) Execute(ctx EvalContext, params map[string]any) erro
 
 	// Create relative date parser with current time as base
 	parser := NewRelativeDateParser(time.Now())
+	if holidayRegion != "" {
+		cal, err := holidays.Load(context.Background(), a.DB, holidayRegion)
+		if err != nil {
+			return fmt.Errorf("failed to load holidays for region '%s': %w", holidayRegion, err)
+		}
+		parser.WithHolidays(cal)
+	}
 
 	// Parse start time (supports both relative and absolute dates)
 	startDateTime, err := parser.ParseRelativeDate(startTime)
@@ -456,6 +505,16 @@ func (a *CreateEventAction) Execute(ctx EvalContext, params map[string]any) erro
 	log.Printf("EVENT SCHEDULE CREATED: ID=%d, Title=%s, CustomEventID=%d, Start=%s",
 		schedule.CustomEventScheduleID, title, customEventID, startDateTime.Format("2006-01-02 15:04"))
 
+	if a.OnCreated != nil {
+		// The schedule exists either way; a refused or failed cascade must not
+		// fail (and retry) the action, which would create duplicates.
+		dctx, cancel := context.WithTimeout(WithCausation(context.Background(), ctx.Chain), 10*time.Second)
+		defer cancel()
+		if err := a.OnCreated(dctx, schedule); err != nil {
+			log.Printf("create_event: scheduled_event trigger for schedule %d: %v", schedule.CustomEventScheduleID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -574,6 +633,12 @@ type CompetencyAssignmentAction struct {
 	DB *gorm.DB
 }
 
+// Describe returns the competency_assignment action's metadata.
+func (a *CompetencyAssignmentAction) Describe() meta.ActionMetadata {
+	m, _ := meta.Action("competency_assignment")
+	return m
+}
+
 func (a *CompetencyAssignmentAction) Execute(ctx EvalContext, params map[string]any) error {
 	employeeNumber, _ := params["employeeNumber"].(string)
 	competencyID, _ := params["competencyID"].(int32)
@@ -667,6 +732,12 @@ func (a *JobMatrixUpdateAction) Execute(ctx EvalContext, params map[string]any)
 // WebhookAction sends HTTP webhooks
 type WebhookAction struct{}
 
+// Describe returns the webhook action's metadata.
+func (a *WebhookAction) Describe() meta.ActionMetadata {
+	m, _ := meta.Action("webhook")
+	return m
+}
+
 func (a *WebhookAction) Execute(ctx EvalContext, params map[string]any) error {
 	url, _ := params["url"].(string)
 	method, _ := params["method"].(string)
@@ -723,6 +794,12 @@ type AuditLogAction struct {
 	DB *gorm.DB
 }
 
+// Describe returns the audit_log action's metadata.
+func (a *AuditLogAction) Describe() meta.ActionMetadata {
+	m, _ := meta.Action("audit_log")
+	return m
+}
+
 func (a *AuditLogAction) Execute(ctx EvalContext, params map[string]any) error {
 	action, _ := params["action"].(string)
 	details, _ := params["details"].(string)
@@ -736,6 +813,9 @@ func (a *AuditLogAction) Execute(ctx EvalContext, params map[string]any) error {
 	if employeeNumber == "" {
 		if employee, ok := ctx.Data["employee"].(gen_models.Employee); ok {
 			employeeNumber = employee.Employeenumber
+		} else if v, ok := resolveFromMapOrStruct(ctx.Data["employee"], []string{"Employeenumber"}); ok && v != nil {
+			// queued jobs receive Data decoded from JSON
+			employeeNumber = fmt.Sprint(v)
 		}
 	}
 
@@ -8,6 +8,7 @@ import (
 
 	"Automated-Scheduling-Project/internal/database/gen_models"
 	"Automated-Scheduling-Project/internal/database/models"
+	"Automated-Scheduling-Project/internal/rulesV2/holidays"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
@@ -797,3 +798,26 @@ func TestRelativeDateParser_EventCreationScenarios(t *testing.T) {
 		})
 	}
 }
+
+func TestRelativeDateParser_BusinessDays(t *testing.T) {
+	// Friday 2024-03-29 is Good Friday; Monday 2024-04-01 is Family Day (ZA).
+	baseTime := time.Date(2024, 3, 28, 9, 0, 0, 0, time.UTC) // Thursday
+	cal := holidays.NewCalendar("ZA", []holidays.Holiday{
+		{Date: time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC), Name: "Good Friday"},
+		{Date: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Name: "Family Day"},
+	})
+
+	weekendsOnly := NewRelativeDateParser(baseTime)
+	got, err := weekendsOnly.ParseRelativeDate("in 1 business day")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 29, 9, 0, 0, 0, time.UTC), got)
+
+	parser := NewRelativeDateParser(baseTime).WithHolidays(cal)
+	got, err = parser.ParseRelativeDate("in 1 business day")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 2, 9, 0, 0, 0, time.UTC), got)
+
+	got, err = parser.ParseRelativeDate("In 5 Working Days 14:00")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 8, 14, 0, 0, 0, time.UTC), got)
+}
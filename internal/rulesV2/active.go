@@ -0,0 +1,181 @@
+package rulesv2
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	rsched "Automated-Scheduling-Project/internal/rulesV2/scheduler"
+)
+
+// ActiveWindow is a recurring period in which a rule may fire. A window whose
+// Until is before From spans midnight and belongs to the day it starts on,
+// so {"weekdays": ["fri"], "from": "22:00", "until": "06:00"} covers Friday
+// night into Saturday morning.
+type ActiveWindow struct {
+	Weekdays []string `json:"weekdays,omitempty"` // "mon".."sun"; every day when empty
+	From     string   `json:"from,omitempty"`     // "HH:MM"; midnight when empty
+	Until    string   `json:"until,omitempty"`    // "HH:MM", exclusive; end of day when empty
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// activePeriod is a rule's parsed ActiveFrom/ActiveUntil/ActiveWindows.
+type activePeriod struct {
+	loc     *time.Location
+	from    time.Time // zero when unbounded
+	until   time.Time // exclusive; zero when unbounded
+	windows []dayWindow
+}
+
+// dayWindow is a parsed ActiveWindow in minutes since midnight.
+type dayWindow struct {
+	days        [7]bool
+	from, until int
+}
+
+// parseActivePeriod reads the active period of r, returning nil when the
+// rule is always active.
+func parseActivePeriod(r Rulev2) (*activePeriod, error) {
+	loc, err := rsched.LoadTimezone(r.ActiveTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("activeTimezone: %v", err)
+	}
+	if r.ActiveFrom == "" && r.ActiveUntil == "" && len(r.ActiveWindows) == 0 {
+		return nil, nil
+	}
+	p := &activePeriod{loc: loc}
+	if r.ActiveFrom != "" {
+		if p.from, _, err = parseActiveBound(r.ActiveFrom, loc); err != nil {
+			return nil, fmt.Errorf("activeFrom: %v", err)
+		}
+	}
+	if r.ActiveUntil != "" {
+		var dateOnly bool
+		if p.until, dateOnly, err = parseActiveBound(r.ActiveUntil, loc); err != nil {
+			return nil, fmt.Errorf("activeUntil: %v", err)
+		}
+		if dateOnly {
+			p.until = p.until.AddDate(0, 0, 1)
+		}
+	}
+	if !p.from.IsZero() && !p.until.IsZero() && !p.from.Before(p.until) {
+		return nil, fmt.Errorf("activeUntil must be after activeFrom")
+	}
+	for i, w := range r.ActiveWindows {
+		dw, err := parseActiveWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("activeWindows[%d]: %v", i, err)
+		}
+		p.windows = append(p.windows, dw)
+	}
+	return p, nil
+}
+
+// parseActiveBound parses "YYYY-MM-DD" (midnight in loc) or RFC3339.
+func parseActiveBound(s string, loc *time.Location) (time.Time, bool, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q must be YYYY-MM-DD or RFC3339", s)
+	}
+	return t, false, nil
+}
+
+func parseActiveWindow(w ActiveWindow) (dayWindow, error) {
+	dw := dayWindow{until: 24 * 60}
+	if len(w.Weekdays) == 0 {
+		for i := range dw.days {
+			dw.days[i] = true
+		}
+	}
+	for _, name := range w.Weekdays {
+		d, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return dw, fmt.Errorf("unknown weekday %q", name)
+		}
+		dw.days[d] = true
+	}
+	var err error
+	if w.From != "" {
+		if dw.from, err = parseClock(w.From); err != nil {
+			return dw, fmt.Errorf("from: %v", err)
+		}
+	}
+	if w.Until != "" {
+		if dw.until, err = parseClock(w.Until); err != nil {
+			return dw, fmt.Errorf("until: %v", err)
+		}
+	}
+	if dw.from == dw.until {
+		return dw, fmt.Errorf("from and until must differ")
+	}
+	return dw, nil
+}
+
+// parseClock turns "HH:MM" into minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q must be HH:MM (24h)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// contains reports whether t (already in the rule's timezone) falls in w.
+func (w dayWindow) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.from < w.until {
+		return w.days[t.Weekday()] && m >= w.from && m < w.until
+	}
+	// Overnight: the evening part is on the start day, the morning part
+	// on the day after it.
+	if m >= w.from {
+		return w.days[t.Weekday()]
+	}
+	return m < w.until && w.days[(t.Weekday()+6)%7]
+}
+
+// inactiveReason reports why p excludes now, or "" when the rule is active.
+func (p *activePeriod) inactiveReason(now time.Time) string {
+	if p == nil {
+		return ""
+	}
+	now = now.In(p.loc)
+	if !p.from.IsZero() && now.Before(p.from) {
+		return "not active before " + p.from.Format(time.RFC3339)
+	}
+	if !p.until.IsZero() && !now.Before(p.until) {
+		return "not active since " + p.until.Format(time.RFC3339)
+	}
+	if len(p.windows) == 0 {
+		return ""
+	}
+	for _, w := range p.windows {
+		if w.contains(now) {
+			return ""
+		}
+	}
+	return "outside the rule's active windows"
+}
+
+// inactiveReason reports why r may not fire at now, or "" when it may. A
+// period that no longer parses keeps the rule from firing.
+func (r Rulev2) inactiveReason(now time.Time) string {
+	p, err := parseActivePeriod(r)
+	if err != nil {
+		return "invalid active period: " + err.Error()
+	}
+	return p.inactiveReason(now)
+}
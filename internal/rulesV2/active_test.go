@@ -0,0 +1,142 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivePeriod_DatesInTimezone(t *testing.T) {
+	r := Rulev2{Name: "onboarding season", ActiveFrom: "2026-09-01", ActiveUntil: "2026-09-30", ActiveTimezone: "Africa/Johannesburg"}
+	for _, tc := range []struct {
+		at     string
+		active bool
+	}{
+		{"2026-08-31T21:59:00Z", false}, // 23:59 local on the 31st
+		{"2026-08-31T22:00:00Z", true},  // midnight local on the 1st
+		{"2026-09-30T21:59:00Z", true},  // the last day is included
+		{"2026-09-30T22:00:00Z", false},
+	} {
+		now, err := time.Parse(time.RFC3339, tc.at)
+		require.NoError(t, err)
+		assert.Equal(t, tc.active, r.inactiveReason(now) == "", "%s: %s", tc.at, r.inactiveReason(now))
+	}
+
+	exact := Rulev2{Name: "cutover", ActiveUntil: "2026-09-01T12:00:00Z"}
+	assert.Empty(t, exact.inactiveReason(time.Date(2026, 9, 1, 11, 59, 0, 0, time.UTC)))
+	assert.Equal(t, "not active since 2026-09-01T12:00:00Z", exact.inactiveReason(time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Empty(t, Rulev2{Name: "always"}.inactiveReason(fixedNow()))
+}
+
+func TestActivePeriod_Windows(t *testing.T) {
+	r := Rulev2{Name: "office hours", ActiveWindows: []ActiveWindow{
+		{Weekdays: []string{"mon", "tue", "wed", "thu", "Friday"}, From: "08:00", Until: "18:00"},
+		{Weekdays: []string{"fri"}, From: "22:00", Until: "06:00"},
+	}}
+	// 2026-09-04 is a Friday.
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 9, day, hour, min, 0, 0, time.UTC) }
+	assert.Empty(t, r.inactiveReason(at(4, 8, 0)))
+	assert.Empty(t, r.inactiveReason(at(4, 17, 59)))
+	assert.Equal(t, "outside the rule's active windows", r.inactiveReason(at(4, 18, 0)))
+	assert.Empty(t, r.inactiveReason(at(4, 23, 0)), "Friday night")
+	assert.Empty(t, r.inactiveReason(at(5, 5, 59)), "Saturday morning belongs to Friday's window")
+	assert.NotEmpty(t, r.inactiveReason(at(5, 6, 0)))
+	assert.NotEmpty(t, r.inactiveReason(at(5, 23, 0)), "no Saturday night window")
+	assert.NotEmpty(t, r.inactiveReason(at(6, 3, 0)))
+
+	allDay := Rulev2{Name: "weekends", ActiveWindows: []ActiveWindow{{Weekdays: []string{"sat", "sun"}}}}
+	assert.Empty(t, allDay.inactiveReason(at(6, 23, 59)))
+	assert.NotEmpty(t, allDay.inactiveReason(at(7, 0, 0)))
+}
+
+func TestActivePeriod_Validate(t *testing.T) {
+	reg := NewRegistryWithDefaults()
+	base := Rulev2{Name: "r", Trigger: TriggerSpec{Type: "job_position"}}
+	for _, tc := range []struct {
+		name string
+		edit func(r *Rulev2)
+		ok   bool
+	}{
+		{"none", func(r *Rulev2) {}, true},
+		{"season", func(r *Rulev2) {
+			r.ActiveFrom, r.ActiveUntil, r.ActiveTimezone = "2026-09-01", "2026-11-30T17:00:00+02:00", "UTC+2"
+		}, true},
+		{"bad date", func(r *Rulev2) { r.ActiveFrom = "01/09/2026" }, false},
+		{"backwards", func(r *Rulev2) { r.ActiveFrom, r.ActiveUntil = "2026-09-02", "2026-09-01" }, false},
+		{"empty range", func(r *Rulev2) { r.ActiveFrom, r.ActiveUntil = "2026-09-02T00:00:00Z", "2026-09-01T00:00:00Z" }, false},
+		{"bad timezone", func(r *Rulev2) { r.ActiveTimezone = "Mars/Olympus" }, false},
+		{"bad weekday", func(r *Rulev2) { r.ActiveWindows = []ActiveWindow{{Weekdays: []string{"funday"}}} }, false},
+		{"bad hour", func(r *Rulev2) { r.ActiveWindows = []ActiveWindow{{From: "8am"}} }, false},
+		{"zero length", func(r *Rulev2) { r.ActiveWindows = []ActiveWindow{{From: "09:00", Until: "09:00"}} }, false},
+	} {
+		r := base
+		tc.edit(&r)
+		err := ValidateRule(reg, r)
+		assert.Equal(t, tc.ok, err == nil, "%s: %v", tc.name, err)
+	}
+}
+
+func TestEngine_SuppressesOutsideActivePeriod(t *testing.T) {
+	eng, _, act, rec := newThrottledEngine(t)
+	rule := Rulev2{ID: "4", Name: "new starters", Trigger: TriggerSpec{Type: "relative_time"},
+		Actions:     []ActionSpec{{Type: "notify"}},
+		Limits:      &RuleLimits{MaxFires: 1, Window: "7d"},
+		ActiveFrom:  "2025-01-02",
+		ActiveUntil: "2025-01-31"}
+
+	require.NoError(t, eng.EvaluateOnce(competencyEvent(fixedNow(), 1), rule))
+	assert.Empty(t, act.Calls)
+	require.Len(t, rec.Runs, 1)
+	assert.Equal(t, RunStatusSuppressed, rec.Runs[0].Status)
+	assert.Equal(t, "not active before 2025-01-02T00:00:00Z", rec.Runs[0].Reason)
+
+	// Suppressed runs do not use up the rule's quota.
+	require.NoError(t, eng.EvaluateOnce(competencyEvent(fixedNow().Add(36*time.Hour), 1), rule))
+	assert.Len(t, act.Calls, 1)
+
+	tr := eng.Simulate(competencyEvent(fixedNow(), 1), rule)
+	assert.False(t, tr.WouldFire)
+	assert.Equal(t, "not active before 2025-01-02T00:00:00Z", tr.Inactive)
+}
+
+func TestEngine_RelativeHoldIsLeftToTheScheduler(t *testing.T) {
+	eng, _, act, rec := newThrottledEngine(t)
+	rule := Rulev2{ID: "4", Name: "new starters", Trigger: TriggerSpec{Type: "relative_time"},
+		Actions:    []ActionSpec{{Type: "notify"}},
+		ActiveFrom: "2025-01-02"}
+
+	_, later, err := eng.evaluateFire(competencyEvent(fixedNow(), 1), rule, true)
+	require.NoError(t, err)
+	assert.Equal(t, "not active before 2025-01-02T00:00:00Z", later)
+	assert.Empty(t, act.Calls)
+	assert.Empty(t, rec.Runs, "a hold the poller retries every tick is not recorded")
+
+	_, later, err = eng.evaluateFire(competencyEvent(fixedNow().Add(36*time.Hour), 1), rule, true)
+	require.NoError(t, err)
+	assert.Empty(t, later)
+	assert.Len(t, act.Calls, 1)
+}
+
+func TestRunRuleNow_HonoursActivePeriod(t *testing.T) {
+	_, svc := setupRouter(t)
+	ctx := context.Background()
+	id, err := svc.CreateRule(ctx, Rulev2{Name: "weekday digest", Trigger: TriggerSpec{Type: "scheduled_time",
+		Parameters: map[string]any{"frequency": "daily", "time_of_day": "09:00"}},
+		Actions:       []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"action": "digest"}}},
+		ActiveWindows: []ActiveWindow{{Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}}}})
+	require.NoError(t, err)
+
+	saturday := time.Date(2026, 9, 5, 9, 0, 0, 0, time.UTC)
+	run, err := svc.RunRuleNow(ctx, id, nil, saturday)
+	require.NoError(t, err)
+	assert.False(t, run.Matched, "the scheduler's fires are held back at the weekend")
+
+	run, err = svc.RunRuleNow(ctx, id, nil, saturday.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.True(t, run.Matched, run.Error)
+}
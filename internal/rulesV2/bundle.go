@@ -0,0 +1,305 @@
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// BundleVersion is the bundle format written by ExportBundle. Import rejects
+// bundles from a newer format.
+const BundleVersion = 1
+
+// Bundle is a portable set of rules, e.g. to copy rules from staging to
+// production. Specs are kept verbatim (including the _ui canvas snapshot).
+type Bundle struct {
+	Version    int          `json:"version"`
+	ExportedAt time.Time    `json:"exportedAt"`
+	Rules      []BundleRule `json:"rules"`
+}
+
+// BundleRule is one exported rule. Rules are matched by name on import since
+// ids differ between environments.
+type BundleRule struct {
+	Name    string          `json:"name"`
+	Enabled bool            `json:"enabled"`
+	Spec    json.RawMessage `json:"spec"`
+}
+
+// Bundle encodings.
+const (
+	BundleFormatJSON = "json"
+	BundleFormatYAML = "yaml"
+)
+
+// EncodeBundle renders b as indented JSON or YAML. YAML uses the same field
+// names as JSON, so either form can be edited by hand and imported.
+func EncodeBundle(b *Bundle, format string) ([]byte, error) {
+	js, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(format) {
+	case "", BundleFormatJSON:
+		return js, nil
+	case BundleFormatYAML, "yml":
+		var generic any
+		if err := json.Unmarshal(js, &generic); err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(generic)
+	}
+	return nil, fmt.Errorf("unknown bundle format %q", format)
+}
+
+// DecodeBundle parses a JSON or YAML bundle.
+func DecodeBundle(data []byte, format string) (*Bundle, error) {
+	switch strings.ToLower(format) {
+	case "", BundleFormatJSON:
+	case BundleFormatYAML, "yml":
+		var generic any
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, fmt.Errorf("invalid yaml: %w", err)
+		}
+		js, err := json.Marshal(generic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid yaml: %w", err)
+		}
+		data = js
+	default:
+		return nil, fmt.Errorf("unknown bundle format %q", format)
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if b.Version < 1 || b.Version > BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (supported: 1-%d)", b.Version, BundleVersion)
+	}
+	return &b, nil
+}
+
+// ExportBundle exports the rules with the given ids, or every rule when ids is
+// empty, in id order.
+func (s *DbRuleStore) ExportBundle(ctx context.Context, ids []uint) (*Bundle, error) {
+	q := s.DB.WithContext(ctx).Order("id ASC")
+	if len(ids) > 0 {
+		q = q.Where("id IN ?", ids)
+	}
+	var rows []models.Rule
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 && len(rows) != len(ids) {
+		found := map[uint]bool{}
+		for _, r := range rows {
+			found[r.ID] = true
+		}
+		for _, id := range ids {
+			if !found[id] {
+				return nil, fmt.Errorf("rule %d: %w", id, gorm.ErrRecordNotFound)
+			}
+		}
+	}
+	b := &Bundle{Version: BundleVersion, ExportedAt: time.Now().UTC(), Rules: make([]BundleRule, 0, len(rows))}
+	for _, r := range rows {
+		b.Rules = append(b.Rules, BundleRule{Name: r.Name, Enabled: r.Enabled, Spec: json.RawMessage(r.Spec)})
+	}
+	return b, nil
+}
+
+// Import modes decide what happens when a bundle rule has the same name as an
+// existing rule.
+const (
+	ImportModeSkip      = "skip"
+	ImportModeOverwrite = "overwrite"
+)
+
+// ImportOptions controls ImportBundle. With DryRun nothing is written but the
+// report shows what would happen.
+type ImportOptions struct {
+	Mode   string
+	DryRun bool
+}
+
+// Per-rule import outcomes.
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportInvalid = "invalid"
+)
+
+// ImportResult is the outcome for one bundle rule.
+type ImportResult struct {
+	Name     string            `json:"name"`
+	Action   string            `json:"action"`
+	ID       string            `json:"id,omitempty"`       // existing or new rule id
+	Conflict bool              `json:"conflict,omitempty"` // a rule with this name already exists
+	Errors   []ValidationError `json:"errors,omitempty"`
+}
+
+// ImportReport summarises an import. When any rule is invalid nothing is
+// written, so a bundle is applied all-or-nothing.
+type ImportReport struct {
+	Mode    string         `json:"mode"`
+	DryRun  bool           `json:"dryRun"`
+	Applied bool           `json:"applied"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Skipped int            `json:"skipped"`
+	Invalid int            `json:"invalid"`
+	Rules   []ImportResult `json:"rules"`
+}
+
+// ErrImportMode is returned by ImportBundle for an unknown ImportOptions.Mode.
+var ErrImportMode = errors.New("unknown import mode")
+
+// ErrInvalidBundle is returned by ImportBundle when at least one rule fails
+// validation; the report lists the problems.
+var ErrInvalidBundle = errors.New("bundle contains invalid rules")
+
+// ImportBundle validates every rule in b against the live registry and the
+// parameter metadata, resolves name conflicts according to opts.Mode and,
+// unless it is a dry run, writes the result in a single transaction.
+func (s *RuleBackEndService) ImportBundle(ctx context.Context, b *Bundle, opts ImportOptions) (*ImportReport, error) {
+	mode := strings.ToLower(opts.Mode)
+	if mode == "" {
+		mode = ImportModeSkip
+	}
+	if mode != ImportModeSkip && mode != ImportModeOverwrite {
+		return nil, fmt.Errorf("%w %q (use %s or %s)", ErrImportMode, opts.Mode, ImportModeSkip, ImportModeOverwrite)
+	}
+	report := &ImportReport{Mode: mode, DryRun: opts.DryRun, Rules: make([]ImportResult, 0, len(b.Rules))}
+
+	existing, err := s.rulesByName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]Rulev2, len(b.Rules))
+	seen := map[string]bool{}
+	for i, br := range b.Rules {
+		res := ImportResult{Name: br.Name}
+		spec, errs := s.validateBundleRule(br)
+		specs[i] = spec
+		if seen[br.Name] {
+			errs = append(errs, ValidationError{Parameter: "name", Message: "duplicate name in bundle"})
+		}
+		seen[br.Name] = true
+
+		ids := existing[br.Name]
+		res.Conflict = len(ids) > 0
+		if len(ids) > 1 {
+			errs = append(errs, ValidationError{Parameter: "name", Message: fmt.Sprintf("%d existing rules share this name", len(ids))})
+		}
+
+		switch {
+		case len(errs) > 0:
+			res.Action, res.Errors = ImportInvalid, errs
+			report.Invalid++
+		case res.Conflict && mode == ImportModeSkip:
+			res.Action, res.ID = ImportSkipped, ids[0]
+			report.Skipped++
+		case res.Conflict:
+			res.Action, res.ID = ImportUpdated, ids[0]
+			report.Updated++
+		default:
+			res.Action = ImportCreated
+			report.Created++
+		}
+		report.Rules = append(report.Rules, res)
+	}
+
+	if report.Invalid > 0 {
+		return report, ErrInvalidBundle
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		store := &DbRuleStore{DB: tx}
+		for i := range report.Rules {
+			res := &report.Rules[i]
+			switch res.Action {
+			case ImportCreated:
+				id, err := store.CreateRule(ctx, specs[i])
+				if err != nil {
+					return fmt.Errorf("create %q: %w", res.Name, err)
+				}
+				res.ID = id
+			case ImportUpdated:
+				if err := store.replaceSpec(ctx, res.ID, specs[i], "import"); err != nil {
+					return fmt.Errorf("update %q: %w", res.Name, err)
+				}
+			default:
+				continue
+			}
+			if err := store.EnableRule(ctx, res.ID, b.Rules[i].Enabled); err != nil {
+				return fmt.Errorf("enable %q: %w", res.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Applied = true
+
+	for i, res := range report.Rules {
+		if res.Action != ImportCreated && res.Action != ImportUpdated {
+			continue
+		}
+		spec := specs[i]
+		spec.ID = res.ID
+		if b.Rules[i].Enabled {
+			s.syncSchedule(res.ID, spec)
+		} else if s.Scheduler != nil {
+			s.Scheduler.UnscheduleFixedRule(res.ID)
+		}
+	}
+	return report, nil
+}
+
+// validateBundleRule decodes a bundle spec and runs both the engine's
+// structural checks and the metadata parameter checks.
+func (s *RuleBackEndService) validateBundleRule(br BundleRule) (Rulev2, []ValidationError) {
+	var spec Rulev2
+	if len(br.Spec) == 0 {
+		return spec, []ValidationError{{Parameter: "spec", Message: "missing spec"}}
+	}
+	if err := json.Unmarshal(br.Spec, &spec); err != nil {
+		return spec, []ValidationError{{Parameter: "spec", Message: err.Error()}}
+	}
+	var errs []ValidationError
+	if spec.Name != br.Name {
+		errs = append(errs, ValidationError{Parameter: "name", Message: fmt.Sprintf("bundle name %q does not match spec name %q", br.Name, spec.Name)})
+	}
+	if res := ValidateRuleSpec(s.Engine.R, spec); !res.Valid {
+		errs = append(errs, res.Errors...)
+	}
+	return spec, errs
+}
+
+// rulesByName maps rule name to the ids using it, lowest id first.
+func (s *RuleBackEndService) rulesByName(ctx context.Context) (map[string][]string, error) {
+	var rows []models.Rule
+	if err := s.DB.WithContext(ctx).Select("id", "name").Order("id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := map[string][]string{}
+	for _, r := range rows {
+		out[r.Name] = append(out[r.Name], strconv.FormatUint(uint64(r.ID), 10))
+	}
+	return out, nil
+}
@@ -0,0 +1,140 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bundleTestRule(name, subject string) Rulev2 {
+	return Rulev2{
+		Name:    name,
+		Trigger: TriggerSpec{Type: "competency"},
+		Actions: []ActionSpec{{Type: "notification", Parameters: map[string]any{
+			"type": "email", "recipients": "ops@example.com", "subject": subject, "message": "m",
+		}}},
+		UI: &UISnapshot{Nodes: map[string]UINode{"trigger": {Type: "trigger", Position: UIPosition{X: 10, Y: 20}}}},
+	}
+}
+
+func TestBundle_EncodeDecodeRoundTrip(t *testing.T) {
+	spec, err := json.Marshal(bundleTestRule("A", "s"))
+	require.NoError(t, err)
+	b := &Bundle{Version: BundleVersion, Rules: []BundleRule{{Name: "A", Enabled: true, Spec: spec}}}
+
+	for _, format := range []string{BundleFormatJSON, BundleFormatYAML} {
+		body, err := EncodeBundle(b, format)
+		require.NoError(t, err, format)
+		got, err := DecodeBundle(body, format)
+		require.NoError(t, err, format)
+		require.Len(t, got.Rules, 1)
+		assert.Equal(t, "A", got.Rules[0].Name)
+		assert.JSONEq(t, string(spec), string(got.Rules[0].Spec), format)
+	}
+
+	_, err = DecodeBundle([]byte(`{"version": 99, "rules": []}`), BundleFormatJSON)
+	assert.ErrorContains(t, err, "unsupported bundle version")
+}
+
+func TestImportBundle_ModesAndValidation(t *testing.T) {
+	_, src := setupRouter(t)
+	_, dst := setupRouter(t)
+	ctx := context.Background()
+
+	for _, r := range []Rulev2{bundleTestRule("A", "a1"), bundleTestRule("B", "b1")} {
+		_, err := src.CreateRule(ctx, r)
+		require.NoError(t, err)
+	}
+	bundle, err := src.Store.ExportBundle(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, bundle.Rules, 2)
+
+	// Existing "A" in the target environment with different content.
+	aID, err := dst.CreateRule(ctx, bundleTestRule("A", "old"))
+	require.NoError(t, err)
+
+	// Dry run reports without writing.
+	report, err := dst.ImportBundle(ctx, bundle, ImportOptions{Mode: ImportModeOverwrite, DryRun: true})
+	require.NoError(t, err)
+	assert.False(t, report.Applied)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Created)
+	assert.True(t, report.Rules[0].Conflict)
+	all, _ := dst.Store.ListAllRules(ctx)
+	assert.Len(t, all, 1)
+
+	// Skip leaves the conflicting rule alone.
+	report, err = dst.ImportBundle(ctx, bundle, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, ImportSkipped, report.Rules[0].Action)
+	assert.Equal(t, ImportCreated, report.Rules[1].Action)
+	got, err := dst.Store.GetRuleByID(ctx, aID)
+	require.NoError(t, err)
+	assert.Equal(t, "old", got.Actions[0].Parameters["subject"])
+
+	// Overwrite replaces it (keeping the id) and records a revision.
+	report, err = dst.ImportBundle(ctx, bundle, ImportOptions{Mode: ImportModeOverwrite})
+	require.NoError(t, err)
+	assert.Equal(t, ImportUpdated, report.Rules[0].Action)
+	assert.Equal(t, aID, report.Rules[0].ID)
+	got, err = dst.Store.GetRuleByID(ctx, aID)
+	require.NoError(t, err)
+	assert.Equal(t, "a1", got.Actions[0].Parameters["subject"])
+	require.NotNil(t, got.UI)
+	revs, err := dst.Store.ListRevisions(ctx, aID)
+	require.NoError(t, err)
+	assert.Equal(t, "import", revs[0].Note)
+
+	// One invalid rule rejects the whole bundle.
+	bad := bundleTestRule("C", "c")
+	bad.Actions[0].Type = "nope"
+	badSpec, _ := json.Marshal(bad)
+	bundle.Rules = append(bundle.Rules, BundleRule{Name: "C", Spec: badSpec})
+	report, err = dst.ImportBundle(ctx, bundle, ImportOptions{Mode: ImportModeOverwrite})
+	require.ErrorIs(t, err, ErrInvalidBundle)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, ImportInvalid, report.Rules[2].Action)
+	assert.NotEmpty(t, report.Rules[2].Errors)
+	assert.False(t, report.Applied)
+
+	_, err = dst.ImportBundle(ctx, bundle, ImportOptions{Mode: "merge"})
+	assert.ErrorIs(t, err, ErrImportMode)
+}
+
+func TestBundleHandlers_Unit(t *testing.T) {
+	router, _ := setupRouter(t)
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/rules", bundleTestRule("A", "a1"))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/bundle/export?format=yaml", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Header().Get("Content-Type"), "yaml")
+	assert.Contains(t, rec.Body.String(), "name: A")
+	yamlBundle := rec.Body.Bytes()
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/bundle/export?ids=999", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	// Re-importing the same bundle in overwrite mode updates rule A.
+	req := httptest.NewRequest(http.MethodPost, "/api/rules/bundle/import?mode=overwrite&dryRun=true", bytes.NewReader(yamlBundle))
+	req.Header.Set("Content-Type", "application/yaml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"action":"updated"`)
+
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/bundle/import", map[string]any{
+		"version": 1,
+		"rules":   []map[string]any{{"name": "X", "spec": map[string]any{"name": "X", "trigger": map[string]any{"type": "nope"}}}},
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"action":"invalid"`)
+}
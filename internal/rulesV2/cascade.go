@@ -0,0 +1,185 @@
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxCascadeDepth limits how many rules may chain through their
+// actions (rule A creates an event, which fires rule B, ...) when
+// Engine.MaxCascadeDepth is zero.
+const DefaultMaxCascadeDepth = 3
+
+// envMaxCascadeDepth overrides DefaultMaxCascadeDepth for the rules service.
+const envMaxCascadeDepth = "RULES_MAX_CASCADE_DEPTH"
+
+// ErrCascadeDepth is returned by DispatchEvent when an event caused by rule
+// actions would exceed the engine's maximum cascade depth.
+var ErrCascadeDepth = errors.New("rule cascade depth exceeded")
+
+// Cause is one rule in a causation chain: its actions led to the current
+// evaluation.
+type Cause struct {
+	RuleID   string `json:"ruleId,omitempty"`
+	RuleName string `json:"ruleName"`
+	Trigger  string `json:"trigger"`
+}
+
+type causationKey struct{}
+
+// WithCausation attaches a causation chain to ctx. Actions that fire triggers
+// pass their EvalContext.Chain through this so DispatchEvent can see how deep
+// the cascade already is.
+func WithCausation(ctx context.Context, chain []Cause) context.Context {
+	if len(chain) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, causationKey{}, chain)
+}
+
+// CausationFrom returns the chain set by WithCausation, or nil for events
+// that did not originate from a rule.
+func CausationFrom(ctx context.Context) []Cause {
+	chain, _ := ctx.Value(causationKey{}).([]Cause)
+	return chain
+}
+
+// Depth is the number of rules that led to this evaluation (0 for external events).
+func (ev EvalContext) Depth() int { return len(ev.Chain) }
+
+// causedBy returns a copy of ev whose chain ends with r, used while r's
+// actions run.
+func (ev EvalContext) causedBy(r Rulev2) EvalContext {
+	chain := make([]Cause, len(ev.Chain), len(ev.Chain)+1)
+	copy(chain, ev.Chain)
+	ev.Chain = append(chain, Cause{RuleID: r.ID, RuleName: r.Name, Trigger: r.Trigger.Type})
+	return ev
+}
+
+func (e *Engine) maxCascadeDepth() int {
+	if e.MaxCascadeDepth > 0 {
+		return e.MaxCascadeDepth
+	}
+	return DefaultMaxCascadeDepth
+}
+
+// maxCascadeDepthFromEnv reads RULES_MAX_CASCADE_DEPTH. It returns 0, and so
+// the default, when the variable is unset or not a positive integer.
+func maxCascadeDepthFromEnv() int {
+	v := strings.TrimSpace(os.Getenv(envMaxCascadeDepth))
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Printf("rules: ignoring %s=%q, want a positive integer; using %d", envMaxCascadeDepth, v, DefaultMaxCascadeDepth)
+		return 0
+	}
+	return n
+}
+
+func formatChain(chain []Cause) string {
+	parts := make([]string, len(chain))
+	for i, c := range chain {
+		parts[i] = fmt.Sprintf("%q (%s)", c.RuleName, c.Trigger)
+	}
+	return strings.Join(parts, " -> ")
+}
+
+/* ---------------------------- Static analysis ---------------------------- */
+
+// actionTriggers lists the triggers an action fires as a side effect, with the
+// trigger payload it produces. Keep in sync with the actions' dispatch hooks.
+var actionTriggers = map[string][]map[string]any{
+	"create_event": {{"type": "scheduled_event", "operation": "create", "updateField": ""}},
+}
+
+// cascadeLoops reports possible trigger -> action -> trigger cycles that pass
+// through rule. Conditions are not considered, so a reported loop may never
+// happen at runtime; it is a warning, not an error. others should hold the
+// enabled rules, and may include rule itself (matched by ID).
+func cascadeLoops(rule Rulev2, others []Rulev2) []string {
+	nodes := []Rulev2{rule}
+	for _, o := range others {
+		if rule.ID != "" && o.ID == rule.ID {
+			continue
+		}
+		nodes = append(nodes, o)
+	}
+
+	// edges[i] lists (action type, target node) pairs reachable from node i.
+	type edge struct {
+		action string
+		to     int
+	}
+	edges := make([][]edge, len(nodes))
+	for i, n := range nodes {
+		for _, a := range n.Actions {
+			for _, payload := range actionTriggers[a.Type] {
+				ev := EvalContext{Data: map[string]any{"trigger": payload}}
+				for j, m := range nodes {
+					if m.Trigger.Type == payload["type"] && matchTriggerParams(ev, m.Trigger.Parameters) {
+						edges[i] = append(edges[i], edge{action: a.Type, to: j})
+					}
+				}
+			}
+		}
+	}
+
+	var loops []string
+	seen := map[string]bool{}
+	onPath := make([]bool, len(nodes))
+	var path []string
+	var walk func(i int)
+	walk = func(i int) {
+		onPath[i] = true
+		for _, ed := range edges[i] {
+			step := fmt.Sprintf("%q -[%s]-> %s", nodes[i].Name, ed.action, nodes[ed.to].Trigger.Type)
+			if ed.to == 0 {
+				loop := strings.Join(append(path, step), " -> ") + fmt.Sprintf(" -> %q", rule.Name)
+				if !seen[loop] {
+					seen[loop] = true
+					loops = append(loops, "possible rule cascade loop: "+loop)
+				}
+				continue
+			}
+			if onPath[ed.to] {
+				continue
+			}
+			path = append(path, step)
+			walk(ed.to)
+			path = path[:len(path)-1]
+		}
+		onPath[i] = false
+	}
+	walk(0)
+	return loops
+}
+
+// CascadeWarnings checks rule against the enabled rules in the store and
+// returns a warning for every potential cascade loop through it.
+func (s *RuleBackEndService) CascadeWarnings(ctx context.Context, rule Rulev2) ([]string, error) {
+	rows, err := s.Store.ListAllRuleRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	others := make([]Rulev2, 0, len(rows))
+	for _, row := range rows {
+		if !row.Enabled {
+			continue
+		}
+		var spec Rulev2
+		if err := json.Unmarshal(row.Spec, &spec); err != nil {
+			continue
+		}
+		spec.ID = strconv.FormatUint(uint64(row.ID), 10)
+		others = append(others, spec)
+	}
+	return cascadeLoops(rule, others), nil
+}
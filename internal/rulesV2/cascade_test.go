@@ -0,0 +1,135 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// redispatchAction fires the EV trigger again, like create_event does for
+// scheduled_event, and records what it saw.
+type redispatchAction struct {
+	eng    *Engine
+	store  RuleStore
+	depths []int
+	errs   []error
+}
+
+func (a *redispatchAction) Execute(ctx EvalContext, _ map[string]any) error {
+	a.depths = append(a.depths, ctx.Depth())
+	err := DispatchEvent(WithCausation(context.Background(), ctx.Chain), a.eng, a.store, "EV", map[string]any{})
+	a.errs = append(a.errs, err)
+	return nil
+}
+
+func TestDispatchEvent_StopsAtMaxCascadeDepth(t *testing.T) {
+	act := &redispatchAction{}
+	eng := newTestEngine(map[string]ActionHandler{"REDISPATCH": act})
+	eng.MaxCascadeDepth = 2
+	rec := &memRecorder{}
+	eng.Recorder = rec
+	store := memStore{ByTrig: map[string][]Rulev2{"EV": {{
+		ID: "7", Name: "loop", Trigger: TriggerSpec{Type: "EV"},
+		Actions: []ActionSpec{{Type: "REDISPATCH"}},
+	}}}}
+	act.eng, act.store = eng, store
+
+	require.NoError(t, DispatchEvent(context.Background(), eng, store, "EV", map[string]any{}))
+
+	assert.Equal(t, []int{1, 2}, act.depths)
+	// errs is filled innermost first: the second re-dispatch was refused.
+	require.Len(t, act.errs, 2)
+	assert.ErrorIs(t, act.errs[0], ErrCascadeDepth)
+	assert.Contains(t, act.errs[0].Error(), `"loop" (EV) -> "loop" (EV)`)
+	assert.NoError(t, act.errs[1])
+
+	// Runs are recorded once actions finish, so also innermost first.
+	require.Len(t, rec.Runs, 2)
+	assert.Equal(t, 1, rec.Runs[0].Depth)
+	assert.Equal(t, "loop", rec.Runs[0].CausedBy)
+	assert.Equal(t, 0, rec.Runs[1].Depth)
+	assert.Empty(t, rec.Runs[1].CausedBy)
+}
+
+func TestNewRuleBackEndService_MaxCascadeDepthFromEnv(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	t.Setenv("RULES_MAX_CASCADE_DEPTH", "5")
+	assert.Equal(t, 5, NewRuleBackEndService(db).Engine.maxCascadeDepth())
+
+	for _, v := range []string{"", "0", "-1", "deep"} {
+		t.Setenv("RULES_MAX_CASCADE_DEPTH", v)
+		assert.Equal(t, DefaultMaxCascadeDepth, NewRuleBackEndService(db).Engine.maxCascadeDepth(), v)
+	}
+}
+
+func TestActionQueue_KeepsCausationChain(t *testing.T) {
+	stub := &chainCapture{}
+	q := newTestQueue(t, map[string]ActionHandler{"STUB": stub})
+	chain := []Cause{{RuleID: "1", RuleName: "a", Trigger: "scheduled_event"}}
+	_, err := q.Enqueue(t.Context(), QueuedAction{RuleName: "a", ActionType: "STUB", Chain: chain})
+	require.NoError(t, err)
+
+	processed, err := q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	require.True(t, processed)
+	assert.Equal(t, chain, stub.chain)
+}
+
+type chainCapture struct{ chain []Cause }
+
+func (c *chainCapture) Execute(ctx EvalContext, _ map[string]any) error {
+	c.chain = ctx.Chain
+	return nil
+}
+
+func TestCascadeLoops(t *testing.T) {
+	createEvent := []ActionSpec{{Type: "create_event"}}
+	onScheduled := func(id, name string, params map[string]any, acts []ActionSpec) Rulev2 {
+		return Rulev2{ID: id, Name: name, Trigger: TriggerSpec{Type: "scheduled_event", Parameters: params}, Actions: acts}
+	}
+
+	self := onScheduled("1", "self", map[string]any{"operation": "create"}, createEvent)
+	loops := cascadeLoops(self, []Rulev2{self})
+	require.Len(t, loops, 1)
+	assert.Equal(t, `possible rule cascade loop: "self" -[create_event]-> scheduled_event -> "self"`, loops[0])
+
+	// Rules only listening to updates are not reached by create_event.
+	updates := onScheduled("1", "self", map[string]any{"operation": "update"}, createEvent)
+	assert.Empty(t, cascadeLoops(updates, nil))
+
+	// Two-rule loop, reported from either side; a rule without a feeding
+	// action breaks the cycle.
+	a := onScheduled("", "a", nil, createEvent)
+	b := onScheduled("2", "b", map[string]any{"operation": ""}, createEvent)
+	notify := onScheduled("3", "notify", nil, []ActionSpec{{Type: "notification"}})
+	loops = cascadeLoops(a, []Rulev2{b, notify})
+	assert.Contains(t, loops, `possible rule cascade loop: "a" -[create_event]-> scheduled_event -> "b" -[create_event]-> scheduled_event -> "a"`)
+	assert.Contains(t, loops, `possible rule cascade loop: "a" -[create_event]-> scheduled_event -> "a"`)
+	assert.Empty(t, cascadeLoops(notify, []Rulev2{b}))
+}
+
+func TestCreateRule_ReturnsCascadeWarnings_Unit(t *testing.T) {
+	router, _ := setupRouter(t)
+	rule := map[string]any{
+		"name":    "reschedule",
+		"trigger": map[string]any{"type": "scheduled_event", "parameters": map[string]any{"operation": "create"}},
+		"actions": []map[string]any{{"type": "create_event", "parameters": map[string]any{"title": "again", "customEventID": 1, "startTime": "in 1 week"}}},
+	}
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/rules", rule)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"warnings":["possible rule cascade loop: \"reschedule\"`)
+
+	rule["actions"] = []map[string]any{{"type": "notification", "parameters": map[string]any{"recipients": `["EMP001"]`, "subject": "s", "message": "m"}}}
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/rules", rule)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "warnings")
+}
@@ -35,6 +35,17 @@ func resolveFromMapOrStruct(root any, segments []string) (any, bool) {
 						break
 					}
 				}
+				// Rows scanned into maps carry snake_case columns, so
+				// "EmployeeNumber" also finds "employee_number".
+				for k := range v {
+					if found {
+						break
+					}
+					if strings.EqualFold(strings.ReplaceAll(k, "_", ""), seg) {
+						nv = v[k]
+						found = true
+					}
+				}
 				if !found {
 					return nil, false
 				}
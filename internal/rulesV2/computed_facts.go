@@ -0,0 +1,86 @@
+package rulesv2
+
+import (
+	"strings"
+	"time"
+)
+
+// computedFact describes a number derived from a date already present in the
+// evaluation context, relative to EvalContext.Now.
+type computedFact struct {
+	sources []dateSource // first source found wins
+	compute func(now, t time.Time) (int, error)
+}
+
+// dateSource is a date field on a top-level Data entry; fields are tried in
+// order to cover both struct (ExpiryDate) and row-map (expiry_date) shapes.
+type dateSource struct {
+	key    string
+	fields []string
+}
+
+var (
+	ecExpiry   = dateSource{"employeeCompetency", []string{"ExpiryDate", "expiry_date"}}
+	ecAchieved = dateSource{"employeeCompetency", []string{"AchievementDate", "achievement_date"}}
+	compExpiry = dateSource{"competency", []string{"ExpiryDate", "expiry_date"}}
+	compAchvd  = dateSource{"competency", []string{"AchievementDate", "achievement_date"}}
+	histStart  = dateSource{"employmentHistory", []string{"StartDate", "start_date"}}
+	eventStart = dateSource{"scheduledEvent", []string{"EventStartDate", "event_start_date"}}
+)
+
+func daysUntil(now, t time.Time) (int, error) { return tplDaysBetween(now, t) }
+func daysSince(now, t time.Time) (int, error) {
+	d, err := tplDaysBetween(now, t)
+	return -d, err
+}
+func hoursUntil(now, t time.Time) (int, error) { return int(t.Sub(now).Hours()), nil }
+
+// computedFacts maps "<top>.<Name>" (lower-cased) to its definition. The same
+// value is offered under every top-level key a rule author is likely to use,
+// e.g. competency.DaysUntilExpiry as referenced by the original seed rules.
+var computedFacts = map[string]computedFact{
+	"employeecompetency.daysuntilexpiry":   {[]dateSource{ecExpiry}, daysUntil},
+	"competency.daysuntilexpiry":           {[]dateSource{compExpiry, ecExpiry}, daysUntil},
+	"employeecompetency.dayssinceachieved": {[]dateSource{ecAchieved}, daysSince},
+	"competency.dayssinceachieved":         {[]dateSource{compAchvd, ecAchieved}, daysSince},
+	"employmenthistory.tenuredays":         {[]dateSource{histStart}, daysSince},
+	"scheduledevent.hoursuntilstart":       {[]dateSource{eventStart}, hoursUntil},
+}
+
+// resolveComputedFact returns (value, handled). A fact whose entity is present
+// but whose date is empty (e.g. no expiry) resolves to nil.
+func resolveComputedFact(evCtx EvalContext, path string) (any, bool) {
+	cf, ok := computedFacts[strings.ToLower(path)]
+	if !ok {
+		return nil, false
+	}
+	now := evCtx.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, src := range cf.sources {
+		entity, ok := evCtx.Data[src.key]
+		if !ok || entity == nil {
+			continue
+		}
+		for _, f := range src.fields {
+			v, ok := resolveFromMapOrStruct(entity, []string{f})
+			if !ok {
+				continue
+			}
+			if isNilish(v) {
+				return nil, true
+			}
+			t, err := tplTime(v)
+			if err != nil || t.IsZero() {
+				return nil, true
+			}
+			n, err := cf.compute(now, t)
+			if err != nil {
+				return nil, true
+			}
+			return n, true
+		}
+	}
+	return nil, false
+}
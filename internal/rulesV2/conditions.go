@@ -0,0 +1,90 @@
+package rulesv2
+
+// Condition node kinds.
+const (
+	condLeaf = "leaf"
+	condAll  = "all"
+	condAny  = "any"
+	condNot  = "not"
+)
+
+// kind reports which shape a condition node has. Mixed nodes (e.g. a fact
+// plus an "any" group) report "" so validation can reject them.
+func (c Condition) kind() string {
+	n := 0
+	k := condLeaf
+	if c.All != nil {
+		n++
+		k = condAll
+	}
+	if c.Any != nil {
+		n++
+		k = condAny
+	}
+	if c.Not != nil {
+		n++
+		k = condNot
+	}
+	isLeaf := c.Fact != "" || c.Operator != ""
+	if n > 1 || (n == 1 && isLeaf) {
+		return ""
+	}
+	return k
+}
+
+// IsGroup reports whether the condition is an all/any/not group node.
+func (c Condition) IsGroup() bool {
+	k := c.kind()
+	return k == condAll || k == condAny || k == condNot
+}
+
+// walkConditions visits every node of a condition list depth-first.
+// Paths look like "0", "0.any[1]", "2.not".
+func walkConditions(conds []Condition, prefix string, fn func(path string, c Condition) error) error {
+	for i, c := range conds {
+		if err := walkCondition(c, childPath(prefix, i), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkCondition(c Condition, path string, fn func(path string, c Condition) error) error {
+	if err := fn(path, c); err != nil {
+		return err
+	}
+	switch c.kind() {
+	case condAll:
+		return walkConditions(c.All, path+".all", fn)
+	case condAny:
+		return walkConditions(c.Any, path+".any", fn)
+	case condNot:
+		return walkCondition(*c.Not, path+".not", fn)
+	}
+	return nil
+}
+
+// conditionShapeError returns a human readable problem with a node's shape
+// (not its operator/fact semantics), or "" when the node is well formed.
+func conditionShapeError(c Condition) string {
+	switch c.kind() {
+	case "":
+		return "condition must be either a fact/operator leaf or exactly one of all, any, not"
+	case condAll:
+		if len(c.All) == 0 {
+			return "all group must contain at least one condition"
+		}
+	case condAny:
+		if len(c.Any) == 0 {
+			return "any group must contain at least one condition"
+		}
+	case condLeaf:
+		if c.Fact == "" {
+			return "condition missing fact"
+		}
+		if c.Operator == "" {
+			return "condition missing operator"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package rulesv2
+
+import (
+	"sort"
+
+	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
+)
+
+// Metadata describes what the registry accepts: its triggers, actions and
+// operators, and the facts its resolvers supply. Handlers that do not
+// describe themselves are listed by name only. Entries follow the order of
+// the metadata package, then the remaining names alphabetically.
+func (r *Registry) Metadata() meta.RulesMetadata {
+	out := meta.RulesMetadata{
+		Triggers:  []meta.TriggerMetadata{},
+		Actions:   []meta.ActionMetadata{},
+		Facts:     []meta.FactMetadata{},
+		Operators: []meta.OperatorMetadata{},
+		Functions: meta.GetFunctionMetadata(),
+	}
+
+	var triggerOrder []string
+	for _, t := range meta.GetTriggerMetadata() {
+		triggerOrder = append(triggerOrder, t.Type)
+	}
+	for _, name := range registeredNames(r.Triggers, triggerOrder) {
+		var m meta.TriggerMetadata
+		if d, ok := r.Triggers[name].(DescribedTrigger); ok {
+			m = d.Describe()
+		}
+		m.Type = name
+		if m.Name == "" {
+			m.Name = name
+		}
+		out.Triggers = append(out.Triggers, m)
+	}
+
+	var actionOrder []string
+	for _, a := range meta.GetActionMetadata() {
+		actionOrder = append(actionOrder, a.Type)
+	}
+	for _, name := range registeredNames(r.Actions, actionOrder) {
+		var m meta.ActionMetadata
+		if d, ok := r.Actions[name].(DescribedAction); ok {
+			m = d.Describe()
+		}
+		m.Type = name
+		if m.Name == "" {
+			m.Name = name
+		}
+		out.Actions = append(out.Actions, m)
+	}
+
+	// Earlier resolvers win, as they do in resolveFact.
+	seen := map[string]bool{}
+	for _, fr := range r.Facts {
+		d, ok := fr.(DescribedFactResolver)
+		if !ok {
+			continue
+		}
+		for _, f := range d.DescribeFacts() {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				out.Facts = append(out.Facts, f)
+			}
+		}
+	}
+
+	var operatorOrder []string
+	for _, o := range meta.GetOperatorMetadata() {
+		operatorOrder = append(operatorOrder, o.Name)
+	}
+	ops := map[string]bool{}
+	for name := range r.Operators {
+		ops[name] = true
+	}
+	for name := range r.RelativeOperators {
+		ops[name] = true
+	}
+	for _, name := range registeredNames(ops, operatorOrder) {
+		m, ok := r.OperatorInfo[name]
+		if !ok {
+			m = meta.OperatorMetadata{Name: name, Symbol: name}
+		}
+		out.Operators = append(out.Operators, m)
+	}
+	return out
+}
+
+// registeredNames returns the keys of m, those listed in order first.
+func registeredNames[V any](m map[string]V, order []string) []string {
+	out := make([]string, 0, len(m))
+	listed := map[string]bool{}
+	for _, name := range order {
+		if _, ok := m[name]; ok && !listed[name] {
+			listed[name] = true
+			out = append(out, name)
+		}
+	}
+	var rest []string
+	for name := range m {
+		if !listed[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(out, rest...)
+}
@@ -0,0 +1,84 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryMetadata_MatchesRegistrations(t *testing.T) {
+	router, svc := setupRouter(t)
+	reg := svc.Engine.R
+	md := reg.Metadata()
+
+	require.Len(t, md.Triggers, len(reg.Triggers))
+	for _, tr := range md.Triggers {
+		assert.Contains(t, reg.Triggers, tr.Type)
+		assert.NotEmpty(t, tr.Description, "trigger %s is not described", tr.Type)
+	}
+	require.Len(t, md.Actions, len(reg.Actions))
+	for _, a := range md.Actions {
+		assert.Contains(t, reg.Actions, a.Type)
+		assert.NotEmpty(t, a.Description, "action %s is not described", a.Type)
+	}
+	for _, name := range []string{"webhook", "audit_log", "competency_assignment"} {
+		assert.Contains(t, reg.Actions, name)
+	}
+	require.Len(t, md.Operators, len(reg.Operators)+len(reg.RelativeOperators))
+	for _, o := range md.Operators {
+		assert.True(t, reg.hasOperator(o.Name), o.Name)
+		assert.NotEmpty(t, o.Description, "operator %s is not described", o.Name)
+	}
+	assert.ElementsMatch(t, meta.GetFactMetadata(), md.Facts, "DbFacts and UnifiedFacts together describe every fact")
+
+	rec := doJSON(t, router, http.MethodGet, "/api/rules/metadata", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var body struct {
+		Data meta.RulesMetadata `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Len(t, body.Data.Actions, len(reg.Actions))
+}
+
+func TestRegistryMetadata_ListsOnlyWhatIsRegistered(t *testing.T) {
+	reg := NewRegistry().
+		UseOperator("equals", opEquals).
+		UseOperator("sameAs", opEquals).
+		UseTrigger("competency", NewTrigger(nil, "competency")).
+		UseTrigger("custom_trigger", NewTrigger(nil, "custom_trigger")).
+		UseAction("notification", &NotificationAction{}).
+		UseAction("custom", &capturingAction{})
+	reg.DescribeOperator("equals", meta.OperatorMetadata{Symbol: "=", Description: "Equal to"})
+
+	md := reg.Metadata()
+	require.Len(t, md.Triggers, 2)
+	assert.Equal(t, "competency", md.Triggers[0].Type)
+	assert.Equal(t, "Competency", md.Triggers[0].Name)
+	assert.Equal(t, meta.TriggerMetadata{Type: "custom_trigger", Name: "custom_trigger"}, md.Triggers[1], "an unknown kind describes nothing")
+
+	require.Len(t, md.Actions, 2)
+	assert.Equal(t, "notification", md.Actions[0].Type)
+	assert.Equal(t, meta.ActionMetadata{Type: "custom", Name: "custom"}, md.Actions[1])
+
+	require.Len(t, md.Operators, 2)
+	assert.Equal(t, meta.OperatorMetadata{Name: "equals", Symbol: "=", Description: "Equal to"}, md.Operators[0])
+	assert.Equal(t, "sameAs", md.Operators[1].Name)
+	assert.Empty(t, md.Facts, "no resolver describes facts")
+
+	// Described in the metadata package but not registered here.
+	r := Rulev2{Name: "x", Trigger: TriggerSpec{Type: "job_position", Parameters: map[string]any{"operation": "create"}},
+		Actions: []ActionSpec{{Type: "webhook", Parameters: map[string]any{"url": "https://example.com"}}}}
+	errs := map[string]string{}
+	for _, e := range ValidateRuleSpec(reg, r).Errors {
+		errs[e.Parameter] = e.Message
+	}
+	assert.Contains(t, errs["trigger.type"], "Unknown trigger type: job_position")
+	assert.Contains(t, errs["actions[0].type"], "Unknown action type: webhook")
+}
@@ -0,0 +1,250 @@
+package rulesv2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+// DigestTick is the digest parameter value that sends buffered notifications
+// after the next scheduler tick rather than after a fixed window.
+const DigestTick = "tick"
+
+// maxDigestAttempts is how often a failing digest is retried before its
+// items are left for an admin to inspect.
+const maxDigestAttempts = 3
+
+// defaultDigestTemplate lists each buffered notification.
+const defaultDigestTemplate = `{{range .Items}}- {{.Subject}}: {{.Message}}
+{{end}}`
+
+// DigestView is the data digest subject and message templates render
+// against, e.g. "{{.Count}} competencies expire soon".
+type DigestView struct {
+	Recipient string
+	Channel   string
+	Count     int
+	Items     []DigestEntry
+}
+
+// DigestEntry is one notification within a digest.
+type DigestEntry struct {
+	Subject string
+	Message string
+	Rule    string
+	At      time.Time
+}
+
+// RawParams keeps the digest templates for flush time; they render against
+// a DigestView, not the rule's data.
+func (a *NotificationAction) RawParams() []string {
+	return []string{"digestSubject", "digestTemplate"}
+}
+
+// bufferDigest stores one item per recipient instead of sending. Items join
+// the recipient's pending digest, or open a new one due after the window
+// ("1h", "1d") or at the next scheduler tick.
+func (a *NotificationAction) bufferDigest(ctx EvalContext, params map[string]any, digest string, recipients []string, channel, subject, message string) error {
+	now := ctx.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	flushAfter := now
+	if !strings.EqualFold(digest, DigestTick) {
+		window, err := parseLimitDuration(digest)
+		if err != nil {
+			return fmt.Errorf("invalid digest %q: want %q or a window like 1h or 1d", digest, DigestTick)
+		}
+		flushAfter = now.Add(window)
+	}
+	digestSubject, _ := params["digestSubject"].(string)
+	digestTemplate, _ := params["digestTemplate"].(string)
+	var ruleID, ruleName, triggerType string
+	if n := len(ctx.Chain); n > 0 {
+		ruleID, ruleName, triggerType = ctx.Chain[n-1].RuleID, ctx.Chain[n-1].RuleName, ctx.Chain[n-1].Trigger
+	}
+
+	for _, employeeNumber := range recipients {
+		if employeeNumber == "" || ctx.steps.Done(employeeNumber) {
+			continue
+		}
+		item := models.NotificationDigestItem{
+			RuleID:         ruleID,
+			RuleName:       ruleName,
+			TriggerType:    triggerType,
+			Recipient:      employeeNumber,
+			Channel:        channel,
+			Subject:        subject,
+			Message:        message,
+			DigestSubject:  digestSubject,
+			DigestTemplate: digestTemplate,
+			FlushAfter:     flushAfter,
+		}
+		// Join a digest that is already open for this recipient.
+		var open models.NotificationDigestItem
+		err := a.DB.Where("recipient = ? AND channel = ? AND digest_subject = ? AND digest_template = ? AND sent_at IS NULL AND attempts < ? AND flush_after > ?",
+			employeeNumber, channel, digestSubject, digestTemplate, maxDigestAttempts, now).
+			Order("flush_after ASC").Limit(1).Find(&open).Error
+		if err != nil {
+			return fmt.Errorf("failed to look up digest for %s: %w", employeeNumber, err)
+		}
+		if open.ID != 0 && open.FlushAfter.Before(item.FlushAfter) {
+			item.FlushAfter = open.FlushAfter
+		}
+		if err := a.DB.Create(&item).Error; err != nil {
+			return fmt.Errorf("failed to buffer notification for %s: %w", employeeNumber, err)
+		}
+		ctx.steps.Mark(employeeNumber)
+	}
+	return nil
+}
+
+// tickDigestWait bounds how long the scheduler waits for a tick's queued
+// notifications before flushing. Items buffered later go out on the next tick.
+const tickDigestWait = 30 * time.Second
+
+// flushTickDigests sends due digests after a scheduler tick. When actions go
+// through q, the tick's notifications are buffered by queue workers, so it
+// first waits for those jobs; otherwise a "tick" digest would be split
+// between this flush and the next one.
+func flushTickDigests(ctx context.Context, q *ActionQueue, notify *NotificationAction, now time.Time) (int, error) {
+	if q != nil {
+		waitCtx, cancel := context.WithTimeout(ctx, tickDigestWait)
+		err := q.WaitIdle(waitCtx, "notification", now)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("notification digests: queued notifications still pending, flushing anyway: %v", err)
+		}
+	}
+	return notify.FlushDigests(ctx, now)
+}
+
+// digestGroup is the pending items sent together as one message.
+type digestGroup struct {
+	items []models.NotificationDigestItem
+	due   time.Time // earliest FlushAfter
+}
+
+// FlushDigests sends every digest whose earliest item is due at now, as one
+// message per recipient, channel and template. A digest of a single item is
+// sent as that notification. It returns the number of messages sent. Items
+// of rules held back by the kill switch or maintenance wait for a later
+// flush.
+func (a *NotificationAction) FlushDigests(ctx context.Context, now time.Time) (int, error) {
+	db := a.DB.WithContext(ctx)
+	if a.Limiter != nil {
+		all, types, err := a.Limiter.held(ctx)
+		switch {
+		case err != nil:
+			log.Printf("notification digests: kill switch and maintenance check failed, flushing anyway: %v", err)
+		case all:
+			return 0, nil
+		case len(types) > 0:
+			db = db.Where("trigger_type NOT IN ?", types)
+		}
+	}
+	var pending []models.NotificationDigestItem
+	if err := db.
+		Where("sent_at IS NULL AND attempts < ?", maxDigestAttempts).
+		Order("id ASC").
+		Find(&pending).Error; err != nil {
+		return 0, err
+	}
+
+	groups := map[string]*digestGroup{}
+	var order []string
+	for _, it := range pending {
+		key := strings.Join([]string{it.Recipient, it.Channel, it.DigestSubject, it.DigestTemplate}, "\x00")
+		g, ok := groups[key]
+		if !ok {
+			g = &digestGroup{due: it.FlushAfter}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.items = append(g.items, it)
+		if it.FlushAfter.Before(g.due) {
+			g.due = it.FlushAfter
+		}
+	}
+
+	sent := 0
+	for _, key := range order {
+		g := groups[key]
+		if g.due.After(now) {
+			continue
+		}
+		ids := make([]uint, len(g.items))
+		for i, it := range g.items {
+			ids[i] = it.ID
+		}
+		q := a.DB.WithContext(ctx).Model(&models.NotificationDigestItem{}).Where("id IN ?", ids)
+
+		first := g.items[0]
+		subject, message, err := renderDigest(g.items, now)
+		if err == nil {
+			err = a.deliver(first.Recipient, first.Channel, subject, message)
+		}
+		if err != nil {
+			log.Printf("Failed to send digest of %d notification(s) to %s: %v", len(g.items), first.Recipient, err)
+			if uerr := q.Updates(map[string]any{"attempts": gorm.Expr("attempts + 1"), "error": err.Error()}).Error; uerr != nil {
+				return sent, uerr
+			}
+			continue
+		}
+		if err := q.Updates(map[string]any{"sent_at": now, "error": ""}).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// renderDigest builds the combined subject and message of a digest.
+func renderDigest(items []models.NotificationDigestItem, now time.Time) (string, string, error) {
+	first := items[0]
+	if len(items) == 1 {
+		return first.Subject, first.Message, nil
+	}
+	view := DigestView{Recipient: first.Recipient, Channel: first.Channel, Count: len(items)}
+	for _, it := range items {
+		view.Items = append(view.Items, DigestEntry{Subject: it.Subject, Message: it.Message, Rule: it.RuleName, At: it.CreatedAt})
+	}
+
+	subjectTpl := first.DigestSubject
+	if subjectTpl == "" {
+		subjectTpl = "You have {{.Count}} notifications"
+	}
+	bodyTpl := first.DigestTemplate
+	if bodyTpl == "" {
+		bodyTpl = defaultDigestTemplate
+	}
+	subject, err := executeDigestTemplate(subjectTpl, view, now)
+	if err != nil {
+		return "", "", fmt.Errorf("digest subject: %w", err)
+	}
+	message, err := executeDigestTemplate(bodyTpl, view, now)
+	if err != nil {
+		return "", "", fmt.Errorf("digest template: %w", err)
+	}
+	return strings.TrimSpace(subject), strings.TrimSpace(message), nil
+}
+
+func executeDigestTemplate(tmpl string, view DigestView, now time.Time) (string, error) {
+	t, err := template.New("digest").Funcs(templateFuncs(now)).Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, view); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
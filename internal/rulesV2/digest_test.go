@@ -0,0 +1,187 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/gen_models"
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newDigestAction(t *testing.T) (*NotificationAction, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&gen_models.Employee{}, &models.NotificationDigestItem{}))
+	require.NoError(t, db.Create(&gen_models.Employee{Employeenumber: "EMP001", Firstname: "Test", Lastname: "User"}).Error)
+	return &NotificationAction{DB: db}, db
+}
+
+func digestParams(digest, subject, message string) map[string]any {
+	return map[string]any{
+		"type":       "push",
+		"recipients": `["EMP001"]`,
+		"subject":    subject,
+		"message":    message,
+		"digest":     digest,
+	}
+}
+
+func pendingDigestItems(t *testing.T, db *gorm.DB) []models.NotificationDigestItem {
+	t.Helper()
+	var items []models.NotificationDigestItem
+	require.NoError(t, db.Where("sent_at IS NULL").Order("id ASC").Find(&items).Error)
+	return items
+}
+
+func TestDigest_TickBuffersUntilFlush(t *testing.T) {
+	action, db := newDigestAction(t)
+	now := fixedNow()
+	ctx := EvalContext{Now: now, Chain: []Cause{{RuleID: "7", RuleName: "expiry reminder"}}}
+
+	require.NoError(t, action.Execute(ctx, digestParams("tick", "Forklift", "expires in 30 days")))
+	require.NoError(t, action.Execute(ctx, digestParams("tick", "First aid", "expires in 30 days")))
+
+	items := pendingDigestItems(t, db)
+	require.Len(t, items, 2, "each notification is recorded individually")
+	assert.Equal(t, "7", items[0].RuleID)
+	assert.Equal(t, "expiry reminder", items[0].RuleName)
+	assert.Equal(t, "push", items[0].Channel)
+
+	sent, err := action.FlushDigests(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "both items go out as one message")
+	assert.Empty(t, pendingDigestItems(t, db))
+
+	sent, err = action.FlushDigests(context.Background(), now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+}
+
+func TestDigest_TickWaitsForQueuedNotifications(t *testing.T) {
+	action, db := newDigestAction(t)
+	require.NoError(t, db.AutoMigrate(&models.RuleJob{}))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // one in-memory database shared with the workers
+
+	eng := newTestEngine(map[string]ActionHandler{"notification": action})
+	q := NewActionQueue(db, eng.R)
+	q.PollInterval = 20 * time.Millisecond
+	eng.Queue = q
+
+	now := fixedNow()
+	rule := Rulev2{
+		Name:    "expiry reminder",
+		Trigger: TriggerSpec{Type: "T"},
+		Actions: []ActionSpec{{Type: "notification", Parameters: digestParams("tick", "{{.competency}}", "expires in 30 days")}},
+	}
+	for _, c := range []string{"Forklift", "First aid"} {
+		require.NoError(t, eng.EvaluateOnce(EvalContext{Now: now, Data: map[string]any{"competency": c}}, rule))
+	}
+	require.Empty(t, pendingDigestItems(t, db), "notifications are queued, not yet buffered")
+
+	require.NoError(t, q.Start(t.Context()))
+	t.Cleanup(func() { _ = q.Stop(context.Background()) })
+
+	sent, err := flushTickDigests(t.Context(), q, action, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "the tick's notifications go out as one message")
+	assert.Empty(t, pendingDigestItems(t, db))
+}
+
+func TestDigest_WindowJoinsOpenDigest(t *testing.T) {
+	action, _ := newDigestAction(t)
+	now := fixedNow()
+
+	require.NoError(t, action.Execute(EvalContext{Now: now}, digestParams("1h", "A", "first")))
+	require.NoError(t, action.Execute(EvalContext{Now: now.Add(40 * time.Minute)}, digestParams("1h", "B", "second")))
+
+	sent, err := action.FlushDigests(context.Background(), now.Add(59*time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, sent, "the window has not passed yet")
+
+	// The second item joined the digest opened at now, so both are due at now+1h.
+	sent, err = action.FlushDigests(context.Background(), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+}
+
+func TestDigest_InvalidWindow(t *testing.T) {
+	action, _ := newDigestAction(t)
+	err := action.Execute(EvalContext{Now: fixedNow()}, digestParams("soon", "A", "first"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid digest "soon"`)
+}
+
+func TestDigest_FailedDeliveryIsRetried(t *testing.T) {
+	action, db := newDigestAction(t)
+	now := fixedNow()
+	params := digestParams("tick", "A", "first")
+	params["recipients"] = `["EMP404"]` // no such employee
+	require.NoError(t, action.Execute(EvalContext{Now: now}, params))
+
+	for i := 0; i < maxDigestAttempts+1; i++ {
+		sent, err := action.FlushDigests(context.Background(), now)
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+	}
+	items := pendingDigestItems(t, db)
+	require.Len(t, items, 1)
+	assert.Equal(t, maxDigestAttempts, items[0].Attempts)
+	assert.Contains(t, items[0].Error, "EMP404")
+}
+
+func TestRenderDigest(t *testing.T) {
+	now := fixedNow()
+	one := []models.NotificationDigestItem{{Recipient: "EMP001", Subject: "Forklift", Message: "expires soon"}}
+	subject, message, err := renderDigest(one, now)
+	require.NoError(t, err)
+	assert.Equal(t, "Forklift", subject, "a single item is sent as is")
+	assert.Equal(t, "expires soon", message)
+
+	two := append(one, models.NotificationDigestItem{Recipient: "EMP001", Subject: "First aid", Message: "expires later"})
+	subject, message, err = renderDigest(two, now)
+	require.NoError(t, err)
+	assert.Equal(t, "You have 2 notifications", subject)
+	assert.Equal(t, "- Forklift: expires soon\n- First aid: expires later", message)
+
+	for i := range two {
+		two[i].DigestSubject = "{{.Count}} competencies expire soon"
+		two[i].DigestTemplate = "{{range .Items}}* {{.Subject}}\n{{end}}"
+	}
+	subject, message, err = renderDigest(two, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2 competencies expire soon", subject)
+	assert.Equal(t, "* Forklift\n* First aid", message)
+}
+
+// rawCapturingAction captures params like capturingAction but asks the
+// engine to leave "template" unrendered.
+type rawCapturingAction struct{ capturingAction }
+
+func (a *rawCapturingAction) RawParams() []string { return []string{"template"} }
+
+func TestEngine_RawParamsNotRendered(t *testing.T) {
+	act := &rawCapturingAction{}
+	eng := newTestEngine(map[string]ActionHandler{"raw": act})
+	rule := Rulev2{Name: "raw", Trigger: TriggerSpec{Type: "ANY"},
+		Actions: []ActionSpec{{Type: "raw", Parameters: map[string]any{
+			"subject":  "Hello {{.employee.Name}}",
+			"template": "{{.Count}} items",
+		}}}}
+	ctx := EvalContext{Now: fixedNow(), Data: map[string]any{"employee": map[string]any{"Name": "Ann"}}}
+
+	require.NoError(t, eng.EvaluateOnce(ctx, rule))
+	require.Len(t, act.Calls, 1)
+	assert.Equal(t, "Hello Ann", act.Calls[0]["subject"])
+	assert.Equal(t, "{{.Count}} items", act.Calls[0]["template"])
+}
@@ -2,6 +2,8 @@ package rulesv2
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"time"
 )
 
@@ -11,6 +13,8 @@ type RuleStore interface {
 
 // Dispatch all rules for triggerType and runs them once
 // Uses the provided context as data
+// Rules run in priority order; a matching rule with stopProcessing ends the
+// dispatch, and only the first matching rule of an exclusive group fires.
 
 func DispatchEvent(ctx context.Context, eng *Engine, store RuleStore, triggerType string, data map[string]any) error{
     rs, err := store.ListByTrigger(ctx, triggerType)
@@ -18,13 +22,33 @@ func DispatchEvent(ctx context.Context, eng *Engine, store RuleStore, triggerTyp
         return err
     }
 
-    ev := EvalContext{Now: time.Now().UTC(), Data:data}
+    sortByPriority(rs)
+
+    // Events fired by rule actions carry the causation chain in ctx; refuse
+    // to go deeper than the engine allows so rules cannot re-trigger forever.
+    chain := CausationFrom(ctx)
+    if len(chain) >= eng.maxCascadeDepth(){
+        err := fmt.Errorf("%w: %s event at depth %d via %s", ErrCascadeDepth, triggerType, len(chain), formatChain(chain))
+        log.Printf("rules: %v", err)
+        return err
+    }
+
+    ev := EvalContext{Now: time.Now().UTC(), Data:data, Chain: chain}
 
     var agg MultiError
+    var gate dispatchGate
     for _,r := range rs{
-        if err := eng.EvaluateOnce(ev,r); err != nil{
+        if why := gate.skip(r); why != ""{
+            eng.debugf("Skip rule %q: %s", r.Name, why)
+            continue
+        }
+        matched, err := eng.evaluate(ev,r)
+        if err != nil{
             agg.Append(err)
         }
+        if matched{
+            gate.matched(r)
+        }
     }
     return agg.Err()
 }
@@ -22,6 +22,23 @@ type Engine struct {
 	StopOnFirstConditionErr bool // if true, aborts rule on first condition error
 
 	Debug bool // added
+
+	// Recorder, when set, persists one RunRecord per evaluation (best-effort).
+	Recorder RunRecorder
+
+	// Queue, when set, receives rendered actions instead of running them
+	// inline. If enqueueing fails the action is executed inline as before.
+	Queue ActionEnqueuer
+
+	// MaxCascadeDepth caps how many rules may chain through actions that
+	// fire triggers; DefaultMaxCascadeDepth when zero. The rules service
+	// reads it from RULES_MAX_CASCADE_DEPTH.
+	MaxCascadeDepth int
+
+	// Limiter, when set, may suppress matched rules (kill switch,
+	// maintenance, quotas, cooldowns). Rules outside their active period are
+	// suppressed too. Suppressed runs are recorded with their reason.
+	Limiter Limiter
 }
 
 func (e *Engine) debugf(format string, args ...any) { // added
@@ -45,13 +62,23 @@ func ValidateRule(r *Registry, rule Rulev2) error {
 		return fmt.Errorf("rule %q: trigger type is empty", rule.Name)
 	}
 
-	for i, c := range rule.Conditions {
-		if c.Operator == "" {
-			return fmt.Errorf("rule %q: condition %d missing operator", rule.Name, i)
+	err := walkConditions(rule.Conditions, "", func(path string, c Condition) error {
+		if msg := conditionShapeError(c); msg != "" {
+			return fmt.Errorf("rule %q: condition %s: %s", rule.Name, path, msg)
+		}
+		if c.IsGroup() {
+			return nil
 		}
-		if _, ok := r.Operators[c.Operator]; !ok {
-			return fmt.Errorf("rule %q: condition %d unknown operator %q", rule.Name, i, c.Operator)
+		if !r.hasOperator(c.Operator) {
+			return fmt.Errorf("rule %q: condition %s unknown operator %q", rule.Name, path, c.Operator)
 		}
+		if err := validateOperatorValue(c.Operator, c.Value); err != nil {
+			return fmt.Errorf("rule %q: condition %s: %s: %v", rule.Name, path, c.Operator, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	for i, a := range rule.Actions {
@@ -63,6 +90,14 @@ func ValidateRule(r *Registry, rule Rulev2) error {
 		}
 	}
 
+	if err := rule.Limits.validate(); err != nil {
+		return fmt.Errorf("rule %q: limits: %v", rule.Name, err)
+	}
+
+	if _, err := parseActivePeriod(rule); err != nil {
+		return fmt.Errorf("rule %q: %v", rule.Name, err)
+	}
+
 	return nil
 }
 
@@ -83,8 +118,10 @@ func (e *Engine) RunRule(ctx context.Context, r Rulev2) error {
 		if evCtx.Now.IsZero() {
 			evCtx.Now = time.Now().UTC()
 		}
+		started := time.Now()
 		ok, err := e.evalConditions(evCtx, r.Conditions)
 		if err != nil {
+			e.record(evCtx, r, started, false, nil, err)
 			if e.StopOnFirstConditionErr {
 				return err
 			}
@@ -92,9 +129,16 @@ func (e *Engine) RunRule(ctx context.Context, r Rulev2) error {
 			return nil
 		}
 		if !ok {
+			e.record(evCtx, r, started, false, nil, nil)
+			return nil
+		}
+		if reason, _ := e.holdBack(evCtx, r); reason != "" {
+			e.recordSuppressed(evCtx, r, started, reason)
 			return nil
 		}
-		if err := e.execActions(evCtx, r.Actions); err != nil {
+		results, err := e.execActions(evCtx, r)
+		e.record(evCtx, r, started, true, results, err)
+		if err != nil {
 			agg.Append(err)
 		}
 		return nil
@@ -108,6 +152,22 @@ func (e *Engine) RunRule(ctx context.Context, r Rulev2) error {
 /* --------------------------- Condition Evaluation ------------------------ */
 
 func (e *Engine) EvaluateOnce(evCtx EvalContext, r Rulev2) error {
+	_, err := e.evaluate(evCtx, r)
+	return err
+}
+
+// evaluate runs one rule against evCtx and reports whether it matched
+// (trigger parameters and conditions passed), regardless of action errors.
+func (e *Engine) evaluate(evCtx EvalContext, r Rulev2) (bool, error) {
+	matched, _, err := e.evaluateFire(evCtx, r, false)
+	return matched, err
+}
+
+// evaluateFire is evaluate that also reports why a matched rule was held
+// back for now (see holdBack), for callers that can fire it later. With
+// retrying set, such a hold is not recorded as a run: the caller tries again
+// on every tick, and the run is recorded once the rule fires.
+func (e *Engine) evaluateFire(evCtx EvalContext, r Rulev2, retrying bool) (matched bool, later string, err error) {
 	if evCtx.Now.IsZero() {
 		evCtx.Now = time.Now().UTC()
 	}
@@ -119,104 +179,269 @@ func (e *Engine) EvaluateOnce(evCtx EvalContext, r Rulev2) error {
 		e.debugf("Evaluate rule=%q trigger=%v dataKeys=%v", r.Name, evCtx.Data["trigger"], keys)
 	}
 
-	matched := matchTriggerParams(evCtx, r.Trigger.Parameters)
+	started := time.Now()
+	matched = matchTriggerParams(evCtx, r.Trigger.Parameters)
 	e.debugf("Trigger params match=%v expected=%v actual=%v", matched, r.Trigger.Parameters, evCtx.Data["trigger"])
 
 	if !matched {
-		return nil
+		e.record(evCtx, r, started, false, nil, nil)
+		return false, "", nil
 	}
 
 	ok, err := e.evalConditions(evCtx, r.Conditions)
 	if err != nil || !ok {
-		return err
+		e.record(evCtx, r, started, false, nil, err)
+		return false, "", err
+	}
+
+	// A suppressed rule did not fire, so it neither stops processing nor
+	// claims its exclusive group.
+	if reason, wait := e.holdBack(evCtx, r); reason != "" {
+		e.debugf("Rule %q suppressed: %s", r.Name, reason)
+		if !wait {
+			e.recordSuppressed(evCtx, r, started, reason)
+			return false, "", nil
+		}
+		if !retrying {
+			e.recordSuppressed(evCtx, r, started, reason)
+		}
+		return false, reason, nil
 	}
 
-	return e.execActions(evCtx, r.Actions)
+	results, err := e.execActions(evCtx, r)
+	e.record(evCtx, r, started, true, results, err)
+	return true, "", err
 }
 
+// evalConditions evaluates the top-level condition list as an implicit "all".
 func (e *Engine) evalConditions(evCtx EvalContext, conds []Condition) (bool, error) {
-	for _, c := range conds {
-		e.debugf("Cond: fact=%q op=%s rhs=%#v", c.Fact, c.Operator, c.Value)
+	return e.evalAll(evCtx.withFactCache(), conds, "", nil)
+}
+
+// The eval* helpers take an optional trace sink. When non-nil, every node that
+// is actually evaluated (groups short-circuit) is appended to it; Simulate uses
+// this to build a ConditionTrace tree. Normal evaluation passes nil.
 
-		val, ok, err := e.resolveFact(evCtx, c)
+func (e *Engine) evalAll(evCtx EvalContext, conds []Condition, prefix string, tr *[]ConditionTrace) (bool, error) {
+	for i, c := range conds {
+		ok, err := e.evalCondition(evCtx, c, childPath(prefix, i), tr)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (e *Engine) evalAny(evCtx EvalContext, conds []Condition, prefix string, tr *[]ConditionTrace) (bool, error) {
+	for i, c := range conds {
+		ok, err := e.evalCondition(evCtx, c, childPath(prefix, i), tr)
 		if err != nil {
-			e.debugf(" -> resolve error: %v", err)
-			return false, fmt.Errorf("resolve fact %q: %w", c.Fact, err)
+			return false, err
 		}
-		if !ok {
-			e.debugf(" -> fact not found")
-			return false, nil
+		if ok {
+			return true, nil
 		}
-		e.debugf(" -> fact value: (%T) %#v", val, val)
+	}
+	return false, nil
+}
 
-		op, ok := e.R.Operators[c.Operator]
-		if !ok || op == nil {
-			return false, fmt.Errorf("unknown operator %q", c.Operator)
+func (e *Engine) evalCondition(evCtx EvalContext, c Condition, path string, tr *[]ConditionTrace) (bool, error) {
+	var node *ConditionTrace
+	var children *[]ConditionTrace
+	if tr != nil {
+		node = &ConditionTrace{Path: path, Kind: c.kind()}
+		children = &node.Children
+	}
+
+	ok, err := e.evalNode(evCtx, c, path, node, children)
+
+	if node != nil {
+		node.Result = ok
+		if err != nil {
+			node.Error = err.Error()
 		}
+		*tr = append(*tr, *node)
+	}
+	return ok, err
+}
 
-		pass, err := op(val, c.Value)
+func (e *Engine) evalNode(evCtx EvalContext, c Condition, path string, node *ConditionTrace, children *[]ConditionTrace) (bool, error) {
+	switch c.kind() {
+	case condAll:
+		ok, err := e.evalAll(evCtx, c.All, path+".all", children)
+		e.debugf("Cond[%s]: all -> %v", path, ok)
+		return ok, err
+	case condAny:
+		ok, err := e.evalAny(evCtx, c.Any, path+".any", children)
+		e.debugf("Cond[%s]: any -> %v", path, ok)
+		return ok, err
+	case condNot:
+		ok, err := e.evalCondition(evCtx, *c.Not, path+".not", children)
 		if err != nil {
-			e.debugf(" -> operator error: %v", err)
-			return false, fmt.Errorf("operator %q: %w", c.Operator, err)
+			return false, err
 		}
-		e.debugf(" -> result: %v", pass)
-		if !pass {
-			return false, nil
+		e.debugf("Cond[%s]: not -> %v", path, !ok)
+		return !ok, nil
+	case condLeaf:
+		return e.evalLeaf(evCtx, c, path, node)
+	default:
+		return false, fmt.Errorf("condition %s: %s", path, conditionShapeError(c))
+	}
+}
+
+func (e *Engine) evalLeaf(evCtx EvalContext, c Condition, path string, node *ConditionTrace) (bool, error) {
+	e.debugf("Cond[%s]: fact=%q op=%s rhs=%#v", path, c.Fact, c.Operator, c.Value)
+	if node != nil {
+		node.Fact, node.Operator, node.Value = c.Fact, c.Operator, c.Value
+	}
+
+	val, ok, resolver, err := e.resolveFact(evCtx, c)
+	if node != nil {
+		node.Resolver, node.FactFound, node.FactValue = resolver, ok, val
+	}
+	if err != nil {
+		e.debugf(" -> resolve error: %v", err)
+		return false, fmt.Errorf("resolve fact %q: %w", c.Fact, err)
+	}
+	if !ok {
+		e.debugf(" -> fact not found")
+		return false, nil
+	}
+	e.debugf(" -> fact value: (%T) %#v", val, val)
+
+	var pass bool
+	if op, ok := e.R.Operators[c.Operator]; ok && op != nil {
+		pass, err = op(val, c.Value)
+	} else if rop, ok := e.R.RelativeOperators[c.Operator]; ok && rop != nil {
+		now := evCtx.Now
+		if now.IsZero() {
+			now = time.Now()
 		}
+		pass, err = rop(now, val, c.Value)
+	} else {
+		return false, fmt.Errorf("unknown operator %q", c.Operator)
 	}
-	return true, nil
+	if err != nil {
+		e.debugf(" -> operator error: %v", err)
+		return false, fmt.Errorf("operator %q: %w", c.Operator, err)
+	}
+	e.debugf(" -> result: %v", pass)
+	return pass, nil
 }
 
-func (e *Engine) resolveFact(evCtx EvalContext, c Condition) (any, bool, error) {
+func childPath(prefix string, i int) string {
+	if prefix == "" {
+		return fmt.Sprintf("%d", i)
+	}
+	return fmt.Sprintf("%s[%d]", prefix, i)
+}
+
+// resolveFact asks each registered resolver in turn; the returned name is the
+// resolver that handled (or failed on) the fact, for tracing.
+func (e *Engine) resolveFact(evCtx EvalContext, c Condition) (any, bool, string, error) {
 	for _, fr := range e.R.Facts {
 		name := fmt.Sprintf("%T", fr)
 		v, handled, err := fr.Resolve(evCtx, c.Fact)
 		if err != nil {
 			e.debugf("Resolver %s error on %q: %v", name, c.Fact, err)
-			return nil, handled, err
+			return nil, handled, name, err
 		}
 		if handled {
 			e.debugf("Resolver %s handled %q -> (%T) %#v", name, c.Fact, v, v)
-			return v, true, nil
+			return v, true, name, nil
 		}
 		e.debugf("Resolver %s skipped %q", name, c.Fact)
 	}
-	return nil, false, nil
+	return nil, false, "", nil
 }
 
 /* ------------------------------- Actions --------------------------------- */
 
-func (e *Engine) execActions(evCtx EvalContext, acts []ActionSpec) error {
+// execActions runs (or enqueues) the rule's actions in order and returns one
+// ActionResult per attempted action alongside the aggregated error.
+func (e *Engine) execActions(evCtx EvalContext, r Rulev2) ([]ActionResult, error) {
+	// Anything the actions trigger is caused by r.
+	evCtx = evCtx.causedBy(r)
+	if e.Queue != nil {
+		if results, ok, err := e.enqueueActions(evCtx, r); ok {
+			return results, err
+		}
+	}
+	acts := r.Actions
 	var agg MultiError
+	results := make([]ActionResult, 0, len(acts))
+	fail := func(res ActionResult, err error) {
+		res.Error = err.Error()
+		results = append(results, res)
+		agg.Append(err)
+	}
 	for _, a := range acts {
-		ah, ok := e.R.Actions[a.Type]
-		if !ok || ah == nil {
-			agg.Append(fmt.Errorf("unknown action %q", a.Type))
-			if !e.ContinueActionsOnError {
-				break
-			}
-			continue
-		}
-		params, err := renderParams(evCtx, a.Parameters)
+		res := ActionResult{Type: a.Type}
+		params, err := e.prepareAction(evCtx, a)
 		if err != nil {
-			agg.Append(fmt.Errorf("render params for action %q: %w", a.Type, err))
+			fail(res, err)
 			if !e.ContinueActionsOnError {
 				break
 			}
 			continue
 		}
-		if err := ah.Execute(evCtx, params); err != nil {
-			agg.Append(fmt.Errorf("action %q failed: %w", a.Type, err))
+		res.Parameters = params
+		if err := e.R.Actions[a.Type].Execute(evCtx, params); err != nil {
+			fail(res, fmt.Errorf("action %q failed: %w", a.Type, err))
 			if !e.ContinueActionsOnError {
 				break
 			}
+			continue
 		}
+		res.OK = true
+		results = append(results, res)
 	}
-	return agg.Err()
+	return results, agg.Err()
+}
+
+// prepareAction looks up the action's handler and renders its parameters.
+func (e *Engine) prepareAction(evCtx EvalContext, a ActionSpec) (map[string]any, error) {
+	ah, ok := e.R.Actions[a.Type]
+	if !ok || ah == nil {
+		return nil, fmt.Errorf("unknown action %q", a.Type)
+	}
+	params, err := renderActionParams(evCtx, ah, a.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("render params for action %q: %w", a.Type, err)
+	}
+	return params, nil
 }
 
 /* --------------------------- Template Rendering -------------------------- */
 
+// renderActionParams renders an action's parameters, leaving those the
+// action declares raw (see RawParamsAction) as written.
+func renderActionParams(evCtx EvalContext, ah ActionHandler, in map[string]any) (map[string]any, error) {
+	raw, _ := ah.(RawParamsAction)
+	if raw == nil {
+		return renderParams(evCtx, in)
+	}
+	keep := map[string]any{}
+	rest := make(map[string]any, len(in))
+	for k, v := range in {
+		rest[k] = v
+	}
+	for _, k := range raw.RawParams() {
+		if v, ok := in[k]; ok {
+			keep[k] = v
+			delete(rest, k)
+		}
+	}
+	out, err := renderParams(evCtx, rest)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range keep {
+		out[k] = v
+	}
+	return out, nil
+}
+
 // renderParams walks a map and renders any string values as Go templates against
 // the EvalContext.Data (so {{employee.EmployeeNumber}} works). It also recurses
 // into nested maps and slices.
@@ -235,7 +460,7 @@ func renderParams(evCtx EvalContext, in map[string]any) (map[string]any, error)
 func renderAny(evCtx EvalContext, v any) (any, error) {
 	switch t := v.(type) {
 	case string:
-		return renderStringTemplate(t, evCtx.Data)
+		return renderStringTemplate(t, evCtx)
 	case map[string]any:
 		return renderParams(evCtx, t)
 	case []any:
@@ -283,15 +508,14 @@ func renderAny(evCtx EvalContext, v any) (any, error) {
 	}
 }
 
-func renderStringTemplate(tmpl string, data map[string]any) (string, error) {
-	// Restricted, data-only templates—no custom funcs by default.
-	// Add a FuncMap here if/when you need helpers (date formatting, math, etc).
-	t, err := template.New("param").Option("missingkey=default").Parse(tmpl)
+func renderStringTemplate(tmpl string, evCtx EvalContext) (string, error) {
+	// Data-only templates plus the curated, side-effect-free helpers in templateFuncs.
+	t, err := template.New("param").Option("missingkey=default").Funcs(templateFuncs(evCtx.Now)).Parse(tmpl)
 	if err != nil {
 		return "", err
 	}
 	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
+	if err := t.Execute(&buf, evCtx.Data); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
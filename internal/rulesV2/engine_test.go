@@ -4,8 +4,10 @@ package rulesv2
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -280,6 +282,108 @@ func TestConditions_MissingFactIsFalse(t *testing.T) {
 	}
 }
 
+/* -------------------------------------------------------------------------- */
+/* Nested condition groups                                                    */
+/* -------------------------------------------------------------------------- */
+
+func TestConditions_NestedGroups(t *testing.T) {
+	data := map[string]any{
+		"employee": map[string]any{"EmployeeStatus": "Active", "Department": "Ops"},
+		"event":    map[string]any{"Type": "Cancelled"},
+	}
+	// status == Active AND (dept == HR OR dept == Ops) AND NOT(event.Type == Cancelled)
+	conds := []Condition{
+		{Fact: "employee.EmployeeStatus", Operator: "equals", Value: "Active"},
+		{Any: []Condition{
+			{Fact: "employee.Department", Operator: "equals", Value: "HR"},
+			{Fact: "employee.Department", Operator: "equals", Value: "Ops"},
+		}},
+		{Not: &Condition{Fact: "event.Type", Operator: "equals", Value: "Cancelled"}},
+	}
+
+	cases := []struct {
+		name      string
+		eventType string
+		want      int
+	}{
+		{"not blocks", "Cancelled", 0},
+		{"all pass", "Scheduled", 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &capturingAction{}
+			eng := newTestEngine(map[string]ActionHandler{"STUB": stub})
+			data["event"] = map[string]any{"Type": tc.eventType}
+			rule := Rulev2{
+				Name:       "nested",
+				Trigger:    TriggerSpec{Type: "ANY"},
+				Conditions: conds,
+				Actions:    []ActionSpec{{Type: "STUB"}},
+			}
+			if err := eng.EvaluateOnce(EvalContext{Now: fixedNow(), Data: data}, rule); err != nil {
+				t.Fatalf("EvaluateOnce error: %v", err)
+			}
+			if len(stub.Calls) != tc.want {
+				t.Fatalf("expected %d calls, got %d", tc.want, len(stub.Calls))
+			}
+		})
+	}
+}
+
+func TestConditions_JSONBackwardCompatible(t *testing.T) {
+	flat := `{"name":"flat","trigger":{"type":"T"},"conditions":[{"fact":"a","operator":"equals","value":1}],"actions":[{"type":"STUB"}]}`
+	var r Rulev2
+	if err := json.Unmarshal([]byte(flat), &r); err != nil {
+		t.Fatalf("unmarshal flat: %v", err)
+	}
+	if len(r.Conditions) != 1 || r.Conditions[0].IsGroup() || r.Conditions[0].Fact != "a" {
+		t.Fatalf("flat condition decoded wrongly: %+v", r.Conditions)
+	}
+
+	nested := `{"name":"n","trigger":{"type":"T"},"conditions":[{"any":[{"fact":"a","operator":"equals","value":1},{"not":{"fact":"b","operator":"isNull"}}]}],"actions":[{"type":"STUB"}]}`
+	var n Rulev2
+	if err := json.Unmarshal([]byte(nested), &n); err != nil {
+		t.Fatalf("unmarshal nested: %v", err)
+	}
+	if !n.Conditions[0].IsGroup() || len(n.Conditions[0].Any) != 2 || n.Conditions[0].Any[1].Not == nil {
+		t.Fatalf("nested condition decoded wrongly: %+v", n.Conditions)
+	}
+
+	// Leaves must not grow empty group keys when re-encoded.
+	out, _ := json.Marshal(Condition{Fact: "a", Operator: "equals", Value: 1})
+	if strings.Contains(string(out), "all") || strings.Contains(string(out), "not") {
+		t.Fatalf("leaf encoded with group keys: %s", out)
+	}
+}
+
+func TestValidateRule_ConditionGroups(t *testing.T) {
+	reg := NewRegistryWithDefaults().UseAction("STUB", &capturingAction{})
+	base := Rulev2{Name: "g", Trigger: TriggerSpec{Type: "T"}, Actions: []ActionSpec{{Type: "STUB"}}}
+
+	bad := map[string][]Condition{
+		"empty any":         {{Any: []Condition{}}},
+		"mixed node":        {{Fact: "a", Operator: "equals", All: []Condition{{Fact: "b", Operator: "isNull"}}}},
+		"nested unknown op": {{All: []Condition{{Not: &Condition{Fact: "a", Operator: "nope"}}}}},
+		"leaf no operator":  {{Any: []Condition{{Fact: "a"}}}},
+	}
+	for name, conds := range bad {
+		r := base
+		r.Conditions = conds
+		if err := ValidateRule(reg, r); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	r := base
+	r.Conditions = []Condition{{Any: []Condition{
+		{Fact: "a", Operator: "equals", Value: 1},
+		{Not: &Condition{Fact: "b", Operator: "isNull"}},
+	}}}
+	if err := ValidateRule(reg, r); err != nil {
+		t.Fatalf("expected nested rule to validate, got %v", err)
+	}
+}
+
 /* -------------------------------------------------------------------------- */
 /* Template rendering nested structures                                       */
 /* -------------------------------------------------------------------------- */
@@ -0,0 +1,324 @@
+package rulesv2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
+
+	"gorm.io/gorm"
+)
+
+// factCache memoises DB-backed facts for the duration of one rule evaluation,
+// so a fact referenced by several conditions is only queried once.
+type factCache struct {
+	mu   sync.Mutex
+	vals map[string]any
+}
+
+func newFactCache() *factCache { return &factCache{vals: map[string]any{}} }
+
+// withFactCache returns evCtx with a fresh cache unless it already has one.
+func (evCtx EvalContext) withFactCache() EvalContext {
+	if evCtx.facts == nil {
+		evCtx.facts = newFactCache()
+	}
+	return evCtx
+}
+
+// cached returns the value stored under key, computing it with load on a miss.
+// Errors are not cached. A nil cache simply calls load.
+func (c *factCache) cached(key string, load func() (any, error)) (any, error) {
+	if c == nil {
+		return load()
+	}
+	c.mu.Lock()
+	v, ok := c.vals[key]
+	c.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.vals[key] = v
+	c.mu.Unlock()
+	return v, nil
+}
+
+// DbFacts resolves derived facts that need a database lookup rather than a
+// field of the trigger payload:
+//
+//	employee.HasCompetency[<competencyID>]  bool   – holds a non-expired record
+//	employee.CurrentPositions               []string position codes held now
+//	employee.OutstandingRequiredCount       int    – required competencies not held
+//	employee.TenureDays                     int    – days since the earliest employment start
+//	scheduledEvent.BookedCount              int    – employees booked on the schedule
+//	competency.PrerequisiteIDs              []int  – prerequisite competency ids
+//
+// The entity is taken from EvalContext.Data (employee, employeeCompetency or
+// employmentHistory for employee facts). Unknown paths are left to the next
+// resolver.
+type DbFacts struct {
+	DB *gorm.DB
+}
+
+// DescribeFacts lists the facts DbFacts looks up.
+func (f DbFacts) DescribeFacts() []meta.FactMetadata {
+	return meta.DerivedFactMetadata()
+}
+
+func (f DbFacts) Resolve(evCtx EvalContext, path string) (any, bool, error) {
+	if f.DB == nil {
+		return nil, false, nil
+	}
+	seg := getPathSegments(path)
+	if len(seg) != 2 {
+		return nil, false, nil
+	}
+	top, name := strings.ToLower(seg[0]), seg[1]
+	arg, hasArg := parseBracketArg(name)
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	now := evCtx.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	switch {
+	case top == "employee" && strings.EqualFold(name, "HasCompetency"):
+		if !hasArg {
+			return nil, true, fmt.Errorf("%s: missing competency id, use employee.HasCompetency[<id>]", path)
+		}
+		compID, err := strconv.Atoi(strings.TrimSpace(arg))
+		if err != nil {
+			return nil, true, fmt.Errorf("%s: invalid competency id %q", path, arg)
+		}
+		emp, ok := employeeNumberFrom(evCtx.Data)
+		if !ok {
+			return nil, false, nil
+		}
+		held, err := f.heldCompetencies(evCtx, emp, now)
+		if err != nil {
+			return nil, true, err
+		}
+		_, has := held[compID]
+		return has, true, nil
+
+	case top == "employee" && strings.EqualFold(name, "CurrentPositions"):
+		emp, ok := employeeNumberFrom(evCtx.Data)
+		if !ok {
+			return nil, false, nil
+		}
+		v, err := f.currentPositions(evCtx, emp, now)
+		return v, true, err
+
+	case top == "employee" && strings.EqualFold(name, "OutstandingRequiredCount"):
+		emp, ok := employeeNumberFrom(evCtx.Data)
+		if !ok {
+			return nil, false, nil
+		}
+		v, err := f.outstandingRequired(evCtx, emp, now)
+		return v, true, err
+
+	case top == "employee" && strings.EqualFold(name, "TenureDays"):
+		emp, ok := employeeNumberFrom(evCtx.Data)
+		if !ok {
+			return nil, false, nil
+		}
+		v, err := f.tenureDays(evCtx, emp, now)
+		return v, true, err
+
+	case top == "scheduledevent" && strings.EqualFold(name, "BookedCount"):
+		id, ok := entityIntID(evCtx.Data, "scheduledEvent", "CustomEventScheduleID", "custom_event_schedule_id")
+		if !ok {
+			return nil, false, nil
+		}
+		v, err := evCtx.facts.cached(fmt.Sprintf("booked:%d", id), func() (any, error) {
+			var n int64
+			err := f.DB.Model(&models.EventScheduleEmployee{}).
+				Where("custom_event_schedule_id = ?", id).
+				Count(&n).Error
+			return int(n), err
+		})
+		return v, true, err
+
+	case top == "competency" && strings.EqualFold(name, "PrerequisiteIDs"):
+		id, ok := 0, false
+		if hasArg {
+			n, err := strconv.Atoi(strings.TrimSpace(arg))
+			if err != nil {
+				return nil, true, fmt.Errorf("%s: invalid competency id %q", path, arg)
+			}
+			id, ok = n, true
+		} else {
+			id, ok = entityIntID(evCtx.Data, "competency", "CompetencyID", "competency_id")
+		}
+		if !ok {
+			return nil, false, nil
+		}
+		v, err := evCtx.facts.cached(fmt.Sprintf("prereqs:%d", id), func() (any, error) {
+			ids := []int{}
+			err := f.DB.Model(&models.CompetencyPrerequisite{}).
+				Where("competency_id = ?", id).
+				Order("prerequisite_competency_id").
+				Pluck("prerequisite_competency_id", &ids).Error
+			return ids, err
+		})
+		return v, true, err
+	}
+	return nil, false, nil
+}
+
+// heldCompetencies returns the set of competency ids the employee has achieved
+// by now and that have not expired. Rows with no achievement date are
+// assignments that have not been completed yet and do not count as held.
+func (f DbFacts) heldCompetencies(evCtx EvalContext, emp string, now time.Time) (map[int]struct{}, error) {
+	v, err := evCtx.facts.cached("held:"+emp, func() (any, error) {
+		var ids []int
+		err := f.DB.Model(&models.EmployeeCompetency{}).
+			Where("employee_number = ?", emp).
+			Where("achievement_date IS NOT NULL AND achievement_date <= ?", now).
+			Where("expiry_date IS NULL OR expiry_date > ?", now).
+			Pluck("competency_id", &ids).Error
+		if err != nil {
+			return nil, err
+		}
+		set := make(map[int]struct{}, len(ids))
+		for _, id := range ids {
+			set[id] = struct{}{}
+		}
+		return set, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[int]struct{}), nil
+}
+
+// currentPositions returns the position codes from employment history rows
+// that are active at now.
+func (f DbFacts) currentPositions(evCtx EvalContext, emp string, now time.Time) ([]string, error) {
+	v, err := evCtx.facts.cached("positions:"+emp, func() (any, error) {
+		codes := []string{}
+		err := f.DB.Model(&models.EmploymentHistory{}).
+			Where("employee_number = ?", emp).
+			Where("start_date <= ?", now).
+			Where("end_date IS NULL OR end_date > ?", now).
+			Distinct().
+			Order("position_matrix_code").
+			Pluck("position_matrix_code", &codes).Error
+		return codes, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]string), nil
+}
+
+// outstandingRequired counts competencies marked Required for any of the
+// employee's current positions that the employee does not currently hold.
+func (f DbFacts) outstandingRequired(evCtx EvalContext, emp string, now time.Time) (int, error) {
+	positions, err := f.currentPositions(evCtx, emp, now)
+	if err != nil || len(positions) == 0 {
+		return 0, err
+	}
+	v, err := evCtx.facts.cached("required:"+strings.Join(positions, ","), func() (any, error) {
+		var ids []int
+		err := f.DB.Model(&models.CustomJobMatrix{}).
+			Where("position_matrix_code IN ?", positions).
+			Where("requirement_status = ?", "Required").
+			Distinct().
+			Pluck("competency_id", &ids).Error
+		return ids, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	held, err := f.heldCompetencies(evCtx, emp, now)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, id := range v.([]int) {
+		if _, ok := held[id]; !ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// tenureDays returns the days since the employee's earliest employment
+// history start date, or nil when the employee has no employment history.
+func (f DbFacts) tenureDays(evCtx EvalContext, emp string, now time.Time) (any, error) {
+	v, err := evCtx.facts.cached("firststart:"+emp, func() (any, error) {
+		var rows []models.EmploymentHistory
+		err := f.DB.Select("start_date").
+			Where("employee_number = ?", emp).
+			Order("start_date").
+			Limit(1).
+			Find(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return nil, err
+		}
+		return rows[0].StartDate, nil
+	})
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return daysSince(now, v.(time.Time))
+}
+
+// employeeNumberFrom finds the employee an evaluation is about, looking at the
+// employee payload first and then records that carry an employee number.
+func employeeNumberFrom(data map[string]any) (string, bool) {
+	lookups := []struct {
+		key    string
+		fields []string
+	}{
+		{"employee", []string{"Employeenumber", "employee_number"}},
+		{"employeeCompetency", []string{"EmployeeNumber", "employee_number"}},
+		{"employmentHistory", []string{"EmployeeNumber", "employee_number"}},
+		{"eventAttendance", []string{"EmployeeNumber", "employee_number"}},
+		{"user", []string{"EmployeeNumber", "employee_number"}},
+	}
+	for _, l := range lookups {
+		v, ok := data[l.key]
+		if !ok || v == nil {
+			continue
+		}
+		for _, fld := range l.fields {
+			if id, ok := resolveFromMapOrStruct(v, []string{fld}); ok && !isNilish(id) {
+				if s := fmt.Sprint(id); s != "" {
+					return s, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// entityIntID reads a numeric id from Data[key] using the first field present.
+func entityIntID(data map[string]any, key string, fields ...string) (int, bool) {
+	v, ok := data[key]
+	if !ok || v == nil {
+		return 0, false
+	}
+	for _, fld := range fields {
+		id, ok := resolveFromMapOrStruct(v, []string{fld})
+		if !ok || isNilish(id) {
+			continue
+		}
+		if f, ok := asFloat(id); ok {
+			return int(f), true
+		}
+	}
+	return 0, false
+}
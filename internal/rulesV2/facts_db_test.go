@@ -0,0 +1,143 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"testing"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newFactsDB(t *testing.T) (*gorm.DB, *int) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.EmployeeCompetency{},
+		&models.EmploymentHistory{},
+		&models.CustomJobMatrix{},
+		&models.CompetencyPrerequisite{},
+		&models.EventScheduleEmployee{},
+	))
+
+	now := fixedNow()
+	past, future := now.AddDate(-1, 0, 0), now.AddDate(1, 0, 0)
+	require.NoError(t, db.Create([]models.EmployeeCompetency{
+		{EmployeeCompetencyID: 1, EmployeeNumber: "E1", CompetencyID: 10, AchievementDate: &past},                      // no expiry
+		{EmployeeCompetencyID: 2, EmployeeNumber: "E1", CompetencyID: 11, AchievementDate: &past, ExpiryDate: &past},   // expired
+		{EmployeeCompetencyID: 3, EmployeeNumber: "E1", CompetencyID: 12, AchievementDate: &past, ExpiryDate: &future}, // valid
+		{EmployeeCompetencyID: 4, EmployeeNumber: "E1", CompetencyID: 13},                                              // assigned, not achieved
+		{EmployeeCompetencyID: 5, EmployeeNumber: "E1", CompetencyID: 16, AchievementDate: &future},                    // achieved later
+	}).Error)
+	require.NoError(t, db.Create([]models.EmploymentHistory{
+		{EmploymentID: 1, EmployeeNumber: "E1", PositionMatrixCode: "DEV", StartDate: past},
+		{EmploymentID: 2, EmployeeNumber: "E1", PositionMatrixCode: "OLD", StartDate: past.AddDate(-1, 0, 0), EndDate: &past},
+	}).Error)
+	require.NoError(t, db.Create([]models.CustomJobMatrix{
+		{CustomMatrixID: 1, PositionMatrixCode: "DEV", CompetencyID: 10, RequirementStatus: "Required"},
+		{CustomMatrixID: 2, PositionMatrixCode: "DEV", CompetencyID: 11, RequirementStatus: "Required"},
+		{CustomMatrixID: 3, PositionMatrixCode: "DEV", CompetencyID: 13, RequirementStatus: "Required"},
+		{CustomMatrixID: 4, PositionMatrixCode: "DEV", CompetencyID: 14, RequirementStatus: "Optional"},
+		{CustomMatrixID: 5, PositionMatrixCode: "OLD", CompetencyID: 15, RequirementStatus: "Required"},
+	}).Error)
+	require.NoError(t, db.Create([]models.CompetencyPrerequisite{
+		{CompetencyID: 12, PrerequisiteCompetencyID: 11},
+		{CompetencyID: 12, PrerequisiteCompetencyID: 10},
+	}).Error)
+	require.NoError(t, db.Create([]models.EventScheduleEmployee{
+		{CustomEventScheduleID: 7, EmployeeNumber: "E1"},
+		{CustomEventScheduleID: 7, EmployeeNumber: "E2"},
+	}).Error)
+
+	queries := 0
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count", func(*gorm.DB) { queries++ }))
+	return db, &queries
+}
+
+func TestDbFacts_Resolve(t *testing.T) {
+	db, _ := newFactsDB(t)
+	f := DbFacts{DB: db}
+	ev := EvalContext{Now: fixedNow(), Data: map[string]any{
+		"employeeCompetency": map[string]any{"employee_number": "E1"},
+		"scheduledEvent":     map[string]any{"CustomEventScheduleID": float64(7)},
+		"competency":         map[string]any{"competency_id": 12},
+	}}.withFactCache()
+
+	cases := map[string]any{
+		"employee.HasCompetency[10]":        true,
+		"employee.HasCompetency[11]":        false, // expired
+		"employee.HasCompetency[12]":        true,
+		"employee.HasCompetency[13]":        false, // not achieved yet
+		"employee.HasCompetency[16]":        false, // achieved after now
+		"employee.HasCompetency[99]":        false,
+		"employee.CurrentPositions":         []string{"DEV"},
+		"employee.OutstandingRequiredCount": 2, // 11 expired, 13 not achieved
+		"employee.TenureDays":               731, // earliest start, the ended OLD position
+		"scheduledEvent.BookedCount":        2,
+		"competency.PrerequisiteIDs":        []int{10, 11},
+		"competency.PrerequisiteIDs[99]":    []int{},
+	}
+	for path, want := range cases {
+		v, ok, err := f.Resolve(ev, path)
+		require.NoError(t, err, path)
+		require.True(t, ok, path)
+		assert.Equal(t, want, v, path)
+	}
+
+	_, ok, _ := f.Resolve(ev, "employee.Firstname")
+	assert.False(t, ok, "payload facts are left to UnifiedFacts")
+	_, _, err := f.Resolve(ev, "employee.HasCompetency[abc]")
+	assert.Error(t, err)
+	_, ok, err = f.Resolve(EvalContext{Now: fixedNow(), Data: map[string]any{}}, "employee.CurrentPositions")
+	assert.NoError(t, err)
+	assert.False(t, ok, "no employee in context")
+
+	// The employee trigger carries no employment history of its own.
+	v, ok, err := f.Resolve(EvalContext{Now: fixedNow(), Data: map[string]any{"employee": map[string]any{"Employeenumber": "E1"}}}, "employee.TenureDays")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 731, v)
+	v, ok, err = f.Resolve(EvalContext{Now: fixedNow(), Data: map[string]any{"employee": map[string]any{"Employeenumber": "E9"}}}, "employee.TenureDays")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, v, "no employment history")
+}
+
+func TestDbFacts_CachedPerEvaluation(t *testing.T) {
+	db, queries := newFactsDB(t)
+	ca := &capturingAction{}
+	eng := newTestEngine(map[string]ActionHandler{"STUB": ca})
+	eng.R.Facts = append([]FactResolver{DbFacts{DB: db}}, eng.R.Facts...)
+
+	rule := Rulev2{
+		Name:    "compliance",
+		Trigger: TriggerSpec{Type: "ANY"},
+		Conditions: []Condition{
+			{Fact: "employee.HasCompetency[10]", Operator: "isTrue"},
+			{Fact: "employee.HasCompetency[12]", Operator: "isTrue"},
+			{Fact: "employee.OutstandingRequiredCount", Operator: "greaterThan", Value: 0},
+			{Fact: "employee.CurrentPositions", Operator: "contains", Value: "DEV"},
+		},
+		Actions: []ActionSpec{{Type: "STUB"}},
+	}
+	ev := EvalContext{Now: fixedNow(), Data: map[string]any{"employee": map[string]any{"Employeenumber": "E1"}}}
+
+	require.NoError(t, eng.EvaluateOnce(ev, rule))
+	assert.Len(t, ca.Calls, 1)
+	// held competencies, current positions and required competencies: one query each
+	assert.Equal(t, 3, *queries)
+
+	// A new evaluation starts with an empty cache.
+	require.NoError(t, eng.EvaluateOnce(ev, rule))
+	assert.Equal(t, 6, *queries)
+
+	// Later evaluations see data changes.
+	require.NoError(t, db.Where("competency_id = ?", 10).Delete(&models.EmployeeCompetency{}).Error)
+	require.NoError(t, eng.EvaluateOnce(ev, rule))
+	assert.Len(t, ca.Calls, 2, "HasCompetency[10] is now false")
+}
@@ -3,8 +3,13 @@ package rulesv2
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,9 +19,9 @@ import (
 	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
 )
 
-// GetRulesMetadataHandler returns metadata about all available triggers, actions, facts, and operators
-func GetRulesMetadataHandler(c *gin.Context) {
-	metadata := meta.GetRulesMetadata()
+// GetRulesMetadataHandler returns metadata about the triggers, actions, facts and operators the engine has registered
+func GetRulesMetadataHandler(c *gin.Context, service *RuleBackEndService) {
+	metadata := service.Engine.R.Metadata()
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   metadata,
@@ -24,8 +29,8 @@ func GetRulesMetadataHandler(c *gin.Context) {
 }
 
 // GetTriggersMetadataHandler returns metadata about available triggers
-func GetTriggersMetadataHandler(c *gin.Context) {
-	triggers := meta.GetTriggerMetadata()
+func GetTriggersMetadataHandler(c *gin.Context, service *RuleBackEndService) {
+	triggers := service.Engine.R.Metadata().Triggers
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   triggers,
@@ -33,8 +38,8 @@ func GetTriggersMetadataHandler(c *gin.Context) {
 }
 
 // GetActionsMetadataHandler returns metadata about available actions
-func GetActionsMetadataHandler(c *gin.Context) {
-	actions := meta.GetActionMetadata()
+func GetActionsMetadataHandler(c *gin.Context, service *RuleBackEndService) {
+	actions := service.Engine.R.Metadata().Actions
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   actions,
@@ -42,8 +47,8 @@ func GetActionsMetadataHandler(c *gin.Context) {
 }
 
 // GetFactsMetadataHandler returns metadata about available facts for conditions
-func GetFactsMetadataHandler(c *gin.Context) {
-	facts := meta.GetFactMetadata()
+func GetFactsMetadataHandler(c *gin.Context, service *RuleBackEndService) {
+	facts := service.Engine.R.Metadata().Facts
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   facts,
@@ -51,16 +56,26 @@ func GetFactsMetadataHandler(c *gin.Context) {
 }
 
 // GetOperatorsMetadataHandler returns metadata about available operators
-func GetOperatorsMetadataHandler(c *gin.Context) {
-	operators := meta.GetOperatorMetadata()
+func GetOperatorsMetadataHandler(c *gin.Context, service *RuleBackEndService) {
+	operators := service.Engine.R.Metadata().Operators
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   operators,
 	})
 }
 
-// ValidateRuleHandler validates a rule without saving it
-func ValidateRuleHandler(c *gin.Context) {
+// GetFunctionsMetadataHandler returns metadata about template functions usable in action parameters
+func GetFunctionsMetadataHandler(c *gin.Context) {
+	functions := meta.GetFunctionMetadata()
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   functions,
+	})
+}
+
+// ValidateRuleHandler validates a rule without saving it, with the same
+// checks CreateRule and UpdateRule apply
+func ValidateRuleHandler(c *gin.Context, service *RuleBackEndService) {
 	var rule Rulev2
 	if err := c.ShouldBindJSON(&rule); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -70,7 +85,7 @@ func ValidateRuleHandler(c *gin.Context) {
 		return
 	}
 
-	result := ValidateRuleParameters(rule)
+	result := ValidateRuleSpec(service.Engine.R, rule)
 
 	status := http.StatusOK
 	if !result.Valid {
@@ -95,12 +110,204 @@ func GetRulesStatus(c *gin.Context, service *RuleBackEndService) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	order, err := service.Store.ExecutionOrder(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "online",
-		"timestamp": time.Now().UTC(),
-		"stats":     stats,
-	})
+	resp := gin.H{
+		"status":         "online",
+		"timestamp":      time.Now().UTC(),
+		"stats":          stats,
+		"executionOrder": order,
+	}
+	if service.Scheduler != nil {
+		leader, err := service.Scheduler.LeaderStatus(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp["scheduler"] = leader
+	}
+	if service.Limiter != nil {
+		kill, err := service.Limiter.KillSwitch(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp["killSwitch"] = kill
+		maint, err := service.Limiter.ListMaintenance(ctx, true)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp["maintenance"] = maint
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetKillSwitch reports whether the engine-wide kill switch is engaged
+func GetKillSwitch(c *gin.Context, service *RuleBackEndService) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	state, err := service.Limiter.KillSwitch(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// SetKillSwitch engages or releases the kill switch: {"engaged": true}.
+// While engaged every matched rule is suppressed instead of firing.
+func SetKillSwitch(c *gin.Context, service *RuleBackEndService) {
+	var req struct {
+		Engaged *bool `json:"engaged"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Engaged == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body must be {\"engaged\": true|false}"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := service.Limiter.SetKillSwitch(ctx, *req.Engaged, c.GetString("email")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	state, err := service.Limiter.KillSwitch(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// ListMaintenance lists recent maintenance windows, newest first; ?active=true
+// returns only those silencing rules now
+func ListMaintenance(c *gin.Context, service *RuleBackEndService) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := service.Limiter.ListMaintenance(ctx, c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"maintenance": rows})
+}
+
+// StartMaintenance silences rules for a bounded time:
+// {"triggerTypes": ["competency"], "duration": "4h", "reason": "HR import"}.
+// Without triggerTypes every rule is silenced.
+func StartMaintenance(c *gin.Context, service *RuleBackEndService) {
+	var in MaintenanceInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	row, err := service.StartMaintenance(ctx, in, c.GetString("email"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidMaintenance) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"maintenance": row})
+}
+
+// EndMaintenance ends a maintenance window before its time is up
+func EndMaintenance(c *gin.Context, service *RuleBackEndService) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := service.Limiter.EndMaintenance(ctx, uint(id), c.GetString("email")); err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance ended"})
+}
+
+// GetSchedule lists scheduled_time rules with their next/previous fire times
+// and last run, plus the relative_time fires due within ?days= (default 7, max 90)
+func GetSchedule(c *gin.Context, service *RuleBackEndService) {
+	days := 7
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 90 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 90"})
+			return
+		}
+		days = n
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	overview, err := service.Schedule(ctx, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, overview)
+}
+
+// RunRuleNow fires a time-based rule on demand. The optional body is
+// {"data": {...}, "now": "..."}; data is merged under the synthetic trigger.
+func RunRuleNow(c *gin.Context, service *RuleBackEndService) {
+	var req struct {
+		Data map[string]any `json:"data"`
+		Now  *time.Time     `json:"now"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var now time.Time
+	if req.Now != nil {
+		now = req.Now.UTC()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Running a rule executes its actions.
+	rule, err := service.Store.GetRuleByID(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeActions(c, service, *rule) {
+		return
+	}
+
+	run, err := service.RunRuleNow(ctx, c.Param("id"), req.Data, now)
+	if errors.Is(err, ErrNotTimeBased) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"run": run})
 }
 
 // ListRules returns all rules in the system
@@ -115,6 +322,12 @@ func ListRules(c *gin.Context, service *RuleBackEndService) {
 		return
 	}
 
+	order, err := service.Store.ExecutionOrder(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Return the exact shape the frontend normalizer expects
 	out := make([]map[string]any, 0, len(rows))
 	for _, r := range rows {
@@ -123,11 +336,13 @@ func ListRules(c *gin.Context, service *RuleBackEndService) {
 			"name":        r.Name,
 			"triggerType": r.TriggerType,
 			"enabled":     r.Enabled,
+			"priority":    r.Priority,
 			"spec":        r.Spec, // datatypes.JSON marshals as raw JSON
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"rules": out})
+	// executionOrder lists enabled rule ids per trigger type in dispatch order
+	c.JSON(http.StatusOK, gin.H{"rules": out, "executionOrder": order})
 }
 
 // CreateRule creates a new rule and returns the DB id
@@ -137,17 +352,51 @@ func CreateRule(c *gin.Context, service *RuleBackEndService) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !authorizeActions(c, service, rule) {
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	ctx = WithAuthor(ctx, c.GetString("email"))
 
 	newID, err := service.CreateRule(ctx, rule)
 	if err != nil {
+		if invalid := invalidRuleResponse(err); invalid != nil {
+			c.JSON(http.StatusBadRequest, invalid)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"id": newID, "message": "Rule created successfully"})
+	resp := gin.H{"id": newID, "message": "Rule created successfully"}
+	rule.ID = newID
+	addCascadeWarnings(ctx, service, rule, resp)
+	c.JSON(http.StatusCreated, resp)
+}
+
+// invalidRuleResponse is the 400 body for a rule rejected by validation, with
+// one entry per failing field; nil for any other error.
+func invalidRuleResponse(err error) gin.H {
+	var invalid *InvalidRuleError
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+	return gin.H{"error": "rule is invalid", "errors": invalid.Errors}
+}
+
+// addCascadeWarnings adds "warnings" to resp when the saved rule may take part
+// in a trigger -> action -> trigger loop. Saving is never blocked by this.
+func addCascadeWarnings(ctx context.Context, service *RuleBackEndService, rule Rulev2, resp gin.H) {
+	warnings, err := service.CascadeWarnings(ctx, rule)
+	if err != nil {
+		log.Printf("rules: cascade analysis for rule %s failed: %v", rule.ID, err)
+		return
+	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
 }
 
 // GetRule returns a specific rule by ID
@@ -190,16 +439,27 @@ func UpdateRule(c *gin.Context, service *RuleBackEndService) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !authorizeActions(c, service, rule) {
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	ctx = WithAuthor(ctx, c.GetString("email"))
 
 	if err := service.UpdateRule(ctx, ruleID, rule); err != nil {
+		if invalid := invalidRuleResponse(err); invalid != nil {
+			c.JSON(http.StatusBadRequest, invalid)
+			return
+		}
 		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Rule updated successfully"})
+	resp := gin.H{"message": "Rule updated successfully"}
+	rule.ID = ruleID
+	addCascadeWarnings(ctx, service, rule, resp)
+	c.JSON(http.StatusOK, resp)
 }
 
 // DeleteRule removes a rule
@@ -247,6 +507,566 @@ func DisableRule(c *gin.Context, service *RuleBackEndService) {
 	c.JSON(http.StatusOK, gin.H{"message": "Rule disabled successfully"})
 }
 
+// ListRuleRevisions returns the saved revisions of a rule, newest first
+func ListRuleRevisions(c *gin.Context, service *RuleBackEndService) {
+	ruleID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revs, err := service.Store.ListRevisions(ctx, ruleID)
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revs})
+}
+
+// GetRuleRevision returns a single revision including its spec snapshot
+func GetRuleRevision(c *gin.Context, service *RuleBackEndService) {
+	ruleID := c.Param("id")
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revision, err := service.Store.GetRevision(ctx, ruleID, rev)
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revision": revision})
+}
+
+// DiffRuleRevisions compares two revisions given as ?from=N&to=M
+func DiffRuleRevisions(c *gin.Context, service *RuleBackEndService) {
+	ruleID := c.Param("id")
+	from, err1 := strconv.Atoi(c.Query("from"))
+	to, err2 := strconv.Atoi(c.Query("to"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be revision numbers"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	changes, err := service.Store.DiffRevisions(ctx, ruleID, from, to)
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "changes": changes})
+}
+
+// RollbackRuleRevision restores a rule to an earlier revision
+func RollbackRuleRevision(c *gin.Context, service *RuleBackEndService) {
+	ruleID := c.Param("id")
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = WithAuthor(ctx, c.GetString("email"))
+
+	// Restoring a revision saves its actions again.
+	revision, err := service.Store.GetRevision(ctx, ruleID, rev)
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+	spec, err := jsonToSpec(revision.Spec)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to unmarshal revision: %v", err)})
+		return
+	}
+	if !authorizeActions(c, service, spec) {
+		return
+	}
+
+	rule, err := service.RollbackRule(ctx, ruleID, rev)
+	if err != nil {
+		if invalid := invalidRuleResponse(err); invalid != nil {
+			c.JSON(http.StatusBadRequest, invalid)
+			return
+		}
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rule rolled back successfully", "rule": rule})
+}
+
+// parseRunFilter reads the run history query string:
+// rule_id, trigger_type, status, entity_type, entity_id, matched, since, until (RFC3339 or YYYY-MM-DD), limit, offset
+func parseRunFilter(c *gin.Context) (RunFilter, error) {
+	var f RunFilter
+	if v := c.Query("rule_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return f, fmt.Errorf("invalid rule_id: %w", err)
+		}
+		f.RuleID = uint(id)
+	}
+	f.TriggerType = c.Query("trigger_type")
+	f.Status = c.Query("status")
+	f.EntityType = c.Query("entity_type")
+	f.EntityID = c.Query("entity_id")
+	if v := c.Query("matched"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid matched: %w", err)
+		}
+		f.Matched = &b
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		t, ok := asTime(v)
+		if !ok {
+			return f, fmt.Errorf("invalid %s: expected RFC3339 or YYYY-MM-DD", p.name)
+		}
+		*p.dst = t
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &f.Limit}, {"offset", &f.Offset}} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("invalid %s", p.name)
+		}
+		*p.dst = n
+	}
+	return f, nil
+}
+
+// ListRuns returns the global run history feed with optional filters
+func ListRuns(c *gin.Context, service *RuleBackEndService) {
+	f, err := parseRunFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	listRuns(c, service, f)
+}
+
+// ListRuleRuns returns the run history of a single rule
+func ListRuleRuns(c *gin.Context, service *RuleBackEndService) {
+	f, err := parseRunFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+	f.RuleID = uint(id)
+	listRuns(c, service, f)
+}
+
+func listRuns(c *gin.Context, service *RuleBackEndService, f RunFilter) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	runs, total, err := service.Runs.ListRuns(ctx, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs, "total": total})
+}
+
+// ListJobs returns queued action jobs, filterable by status, rule_id and action_type
+func ListJobs(c *gin.Context, service *RuleBackEndService) {
+	f := JobFilter{Status: c.Query("status"), ActionType: c.Query("action_type")}
+	if v := c.Query("rule_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule_id"})
+			return
+		}
+		f.RuleID = uint(id)
+	}
+	f.Limit, _ = strconv.Atoi(c.Query("limit"))
+	f.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	jobs, total, err := service.Queue.ListJobs(ctx, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs, "total": total})
+}
+
+// GetJob returns a single queued job
+func GetJob(c *gin.Context, service *RuleBackEndService) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job, err := service.Queue.GetJob(ctx, uint(id))
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// RetryJob re-queues a dead or discarded job
+func RetryJob(c *gin.Context, service *RuleBackEndService) {
+	jobAdminAction(c, service, service.Queue.RetryJob, "Job re-queued", true)
+}
+
+// DiscardJob abandons a pending or dead job
+func DiscardJob(c *gin.Context, service *RuleBackEndService) {
+	jobAdminAction(c, service, service.Queue.DiscardJob, "Job discarded", false)
+}
+
+// jobAdminAction applies fn to the job in the path. When runsAction is set,
+// fn executes the job's action again, so the caller needs the permission
+// that action requires.
+func jobAdminAction(c *gin.Context, service *RuleBackEndService, fn func(context.Context, uint) error, msg string, runsAction bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if runsAction {
+		job, err := service.Queue.GetJob(ctx, uint(id))
+		if err != nil {
+			c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+			return
+		}
+		if !authorizeActions(c, service, Rulev2{Actions: []ActionSpec{{Type: job.ActionType}}}) {
+			return
+		}
+	}
+
+	if err := fn(ctx, uint(id)); err != nil {
+		status := httpStatusForStoreErr(err)
+		if errors.Is(err, ErrJobState) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": msg})
+}
+
+// bundleFormat picks json or yaml from ?format= or, for uploads, the Content-Type.
+func bundleFormat(c *gin.Context) string {
+	if f := c.Query("format"); f != "" {
+		return strings.ToLower(f)
+	}
+	if strings.Contains(c.ContentType(), "yaml") {
+		return BundleFormatYAML
+	}
+	return BundleFormatJSON
+}
+
+// ExportRulesBundle downloads all rules, or those listed in ?ids=1,2, as a bundle
+func ExportRulesBundle(c *gin.Context, service *RuleBackEndService) {
+	var ids []uint
+	for _, v := range strings.Split(c.Query("ids"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid id %q", v)})
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bundle, err := service.Store.ExportBundle(ctx, ids)
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+	format := bundleFormat(c)
+	body, err := EncodeBundle(bundle, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType, ext := "application/json", "json"
+	if format != BundleFormatJSON {
+		contentType, ext = "application/yaml", "yaml"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="rules-%s.%s"`, bundle.ExportedAt.Format("20060102-150405"), ext))
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// ImportRulesBundle imports a bundle. Query: mode=skip|overwrite, dryRun=true.
+// Invalid bundles are rejected as a whole with 422 and a per-rule report.
+func ImportRulesBundle(c *gin.Context, service *RuleBackEndService) {
+	raw, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	bundle, err := DecodeBundle(raw, bundleFormat(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Specs that do not decode are reported by ImportBundle.
+	var specs []Rulev2
+	for _, br := range bundle.Rules {
+		var spec Rulev2
+		if json.Unmarshal(br.Spec, &spec) == nil {
+			specs = append(specs, spec)
+		}
+	}
+	if !authorizeActions(c, service, specs...) {
+		return
+	}
+	opts := ImportOptions{Mode: c.Query("mode")}
+	if v := c.Query("dryRun"); v != "" {
+		if opts.DryRun, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dryRun"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = WithAuthor(ctx, c.GetString("email"))
+
+	report, err := service.ImportBundle(ctx, bundle, opts)
+	switch {
+	case errors.Is(err, ErrInvalidBundle):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+	case errors.Is(err, ErrImportMode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"report": report})
+	}
+}
+
+// ListHolidays returns stored public holidays. Query: region, year.
+func ListHolidays(c *gin.Context, service *RuleBackEndService) {
+	year := 0
+	if v := c.Query("year"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
+			return
+		}
+		year = n
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := service.ListHolidays(ctx, c.Query("region"), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"holidays": rows})
+}
+
+// CreateHoliday stores one holiday: {"region": "ZA", "date": "2025-12-16", "name": "..."}
+func CreateHoliday(c *gin.Context, service *RuleBackEndService) {
+	var in HolidayInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := service.AddHoliday(ctx, in); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidHoliday) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Holiday saved"})
+}
+
+// DeleteHoliday removes a stored holiday
+func DeleteHoliday(c *gin.Context, service *RuleBackEndService) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid holiday id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := service.DeleteHoliday(ctx, uint(id)); err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Holiday deleted"})
+}
+
+// holidayFormat picks ics or csv from ?format=, the upload's file extension
+// or the Content-Type.
+func holidayFormat(c *gin.Context, filename string) string {
+	if f := c.Query("format"); f != "" {
+		return strings.ToLower(f)
+	}
+	switch {
+	case strings.HasSuffix(strings.ToLower(filename), ".ics"), strings.Contains(c.ContentType(), "calendar"):
+		return HolidayFormatICS
+	case strings.HasSuffix(strings.ToLower(filename), ".csv"), strings.Contains(c.ContentType(), "csv"):
+		return HolidayFormatCSV
+	}
+	return ""
+}
+
+// ImportHolidays loads a holiday calendar from an ICS or CSV upload, sent as
+// the raw body or as multipart field "file". Query: region, format=ics|csv.
+func ImportHolidays(c *gin.Context, service *RuleBackEndService) {
+	body, filename := io.Reader(c.Request.Body), ""
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "missing file"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		body, filename = f, fh.Filename
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	n, err := service.ImportHolidays(ctx, body, c.Query("region"), holidayFormat(c, filename))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidHoliday) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"imported": n})
+}
+
+// simulateRequest is the body accepted by the simulate endpoints.
+// "data" is the full evaluation context (e.g. employee, event); "trigger" is a
+// shortcut for data.trigger. "rule" is only used by the ad-hoc variant.
+type simulateRequest struct {
+	Rule    *Rulev2        `json:"rule"`
+	Trigger map[string]any `json:"trigger"`
+	Data    map[string]any `json:"data"`
+	Now     *time.Time     `json:"now"`
+}
+
+func (r simulateRequest) evalContext() EvalContext {
+	data := make(map[string]any, len(r.Data)+1)
+	for k, v := range r.Data {
+		data[k] = v
+	}
+	if r.Trigger != nil {
+		data["trigger"] = r.Trigger
+	}
+	ev := EvalContext{Data: data}
+	if r.Now != nil {
+		ev.Now = r.Now.UTC()
+	}
+	return ev
+}
+
+// SimulateRule dry-runs a stored rule against a sample payload and returns the evaluation trace
+func SimulateRule(c *gin.Context, service *RuleBackEndService) {
+	ruleID := c.Param("id")
+	var req simulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	trace, err := service.SimulateRule(ctx, ruleID, req.evalContext())
+	if err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trace": trace})
+}
+
+// SimulateAdHocRule dry-runs an unsaved rule supplied in the request body
+func SimulateAdHocRule(c *gin.Context, service *RuleBackEndService) {
+	var req simulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Rule == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rule is required"})
+		return
+	}
+	if err := ValidateRule(service.Engine.R, *req.Rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trace := service.Engine.Simulate(req.evalContext(), *req.Rule)
+	c.JSON(http.StatusOK, gin.H{"trace": trace})
+}
+
 // Trigger handlers (updated payloads, plus two new)
 
 func TriggerJobPosition(c *gin.Context, service *RuleBackEndService) {
@@ -398,3 +1218,58 @@ func TriggerCompetencyPrerequisite(c *gin.Context, service *RuleBackEndService)
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
+
+// ListAPIKeys lists the API keys external systems use on trigger endpoints
+func ListAPIKeys(c *gin.Context, service *RuleBackEndService) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := service.ListAPIKeys(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"apiKeys": rows})
+}
+
+// CreateAPIKey issues a key: {"name": "HR system", "scopes": ["trigger:competency"]}.
+// The key is only returned by this call.
+func CreateAPIKey(c *gin.Context, service *RuleBackEndService) {
+	var in APIKeyInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, row, err := service.CreateAPIKey(ctx, in, c.GetString("email"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidAPIKey) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"key": key, "apiKey": row})
+}
+
+// RevokeAPIKey disables an API key
+func RevokeAPIKey(c *gin.Context, service *RuleBackEndService) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := service.RevokeAPIKey(ctx, uint(id)); err != nil {
+		c.JSON(httpStatusForStoreErr(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
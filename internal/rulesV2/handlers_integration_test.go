@@ -4,6 +4,7 @@ package rulesv2
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -28,12 +29,21 @@ func setupITRouter(t *testing.T) (*gin.Engine, *RuleBackEndService) {
 	require.NoError(t, err)
 
 	// Automigrate the rules table
-	err = db.AutoMigrate(&models.Rule{})
+	err = db.AutoMigrate(&models.Rule{}, &models.RuleRevision{}, &models.RuleRun{}, &models.RuleTriggerState{}, &models.SchedulerLease{},
+		&models.RuleThrottleHit{}, &models.RuleThrottleLock{}, &models.RuleSetting{}, &models.RuleAPIKey{}, &models.RuleMaintenance{})
 	require.NoError(t, err)
 
 	svc := NewRuleBackEndService(db)
+	key, _, err := svc.CreateAPIKey(context.Background(), APIKeyInput{Name: "tests", Scopes: []string{ScopeAllTriggers}}, "")
+	require.NoError(t, err)
 	r := gin.New()
-	RegisterRulesRoutes(r, svc)
+	r.Use(func(c *gin.Context) { c.Request.Header.Set(APIKeyHeader, key) })
+	allow := func(c *gin.Context) { c.Next() }
+	RegisterRulesRoutes(r, svc, RouteAccess{
+		Authenticate:      allow,
+		RequirePermission: func(string) gin.HandlerFunc { return allow },
+		HasPermission:     func(*gin.Context, string) (bool, error) { return true, nil },
+	})
 	return r, svc
 }
 
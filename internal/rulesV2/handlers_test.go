@@ -4,6 +4,7 @@ package rulesv2
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -12,8 +13,11 @@ import (
 	"testing"
 	"time"
 
+	"Automated-Scheduling-Project/internal/database/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -25,6 +29,7 @@ type testRuleRow struct {
 	TriggerType string
 	Spec        string
 	Enabled     bool
+	Priority    int
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -34,6 +39,13 @@ func (testRuleRow) TableName() string { return "rules" }
 // setup helpers
 
 func setupRouter(t *testing.T) (*gin.Engine, *RuleBackEndService) {
+	t.Helper()
+	return setupRouterWith(t, grantedAccess(PermissionRulesView, PermissionRulesEdit, PermissionRulesAdmin))
+}
+
+// setupRouterWith registers the routes behind access. Trigger requests
+// without an API key get one allowed to fire every trigger.
+func setupRouterWith(t *testing.T, access RouteAccess) (*gin.Engine, *RuleBackEndService) {
 	t.Helper()
 	gin.SetMode(gin.TestMode)
 
@@ -41,11 +53,20 @@ func setupRouter(t *testing.T) (*gin.Engine, *RuleBackEndService) {
 	require.NoError(t, err)
 
 	// Ensure the rules table exists for store queries used by handlers.
-	require.NoError(t, db.AutoMigrate(&testRuleRow{}))
+	require.NoError(t, db.AutoMigrate(&testRuleRow{}, &models.RuleRevision{}, &models.RuleRun{}, &models.RuleJob{},
+		&models.RuleTriggerState{}, &models.SchedulerLease{}, &models.PublicHoliday{},
+		&models.RuleThrottleHit{}, &models.RuleThrottleLock{}, &models.RuleSetting{}, &models.RuleAPIKey{}, &models.RuleMaintenance{}))
 
 	svc := NewRuleBackEndService(db)
+	key, _, err := svc.CreateAPIKey(context.Background(), APIKeyInput{Name: "tests", Scopes: []string{ScopeAllTriggers}}, "")
+	require.NoError(t, err)
 	router := gin.New()
-	RegisterRulesRoutes(router, svc)
+	router.Use(func(c *gin.Context) {
+		if len(c.Request.Header.Values(APIKeyHeader)) == 0 {
+			c.Request.Header.Set(APIKeyHeader, key)
+		}
+	})
+	RegisterRulesRoutes(router, svc, access)
 
 	return router, svc
 }
@@ -83,6 +104,7 @@ func TestMetadataHandlers_Unit(t *testing.T) {
 		{name: "Actions", endpoint: "/api/rules/metadata/actions"},
 		{name: "Facts", endpoint: "/api/rules/metadata/facts"},
 		{name: "Operators", endpoint: "/api/rules/metadata/operators"},
+		{name: "Functions", endpoint: "/api/rules/metadata/functions"},
 	} {
 		t.Run(c.name, func(t *testing.T) {
 			rec := doJSON(t, router, http.MethodGet, c.endpoint, nil)
@@ -294,6 +316,14 @@ func TestRuleCRUDAndStatus_Unit(t *testing.T) {
 	rec = doJSON(t, router, http.MethodPut, "/api/rules/rules/"+id, updateRule)
 	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
 
+	// Simulate the stored rule against a sample trigger payload
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/rules/"+id+"/simulate", map[string]any{
+		"trigger": map[string]any{"operation": "update"},
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"triggerMatched":true`)
+	require.Contains(t, rec.Body.String(), `"subject":"Updated"`)
+
 	// Disable
 	rec = doJSON(t, router, http.MethodPost, "/api/rules/rules/"+id+"/disable", nil)
 	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
@@ -306,6 +336,8 @@ func TestRuleCRUDAndStatus_Unit(t *testing.T) {
 	rec = doJSON(t, router, http.MethodGet, "/api/rules/status", nil)
 	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
 	require.Contains(t, rec.Body.String(), "total_rules")
+	require.Contains(t, rec.Body.String(), `"executionOrder":{"scheduled_event":[{"id":`+id)
+	require.Contains(t, rec.Body.String(), `"scheduler":{"instance":`)
 
 	// Delete
 	rec = doJSON(t, router, http.MethodDelete, "/api/rules/rules/"+id, nil)
@@ -315,3 +347,384 @@ func TestRuleCRUDAndStatus_Unit(t *testing.T) {
 	rec = doJSON(t, router, http.MethodGet, "/api/rules/rules/"+id, nil)
 	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
 }
+
+func TestSimulateAdHocHandler_Unit(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	body := map[string]any{
+		"rule": Rulev2{
+			Name:    "Ad hoc",
+			Trigger: TriggerSpec{Type: "scheduled_event"},
+			Conditions: []Condition{
+				{Fact: "scheduledEvent.StatusName", Operator: "equals", Value: "Scheduled"},
+			},
+			Actions: []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"message": "{{.scheduledEvent.Title}}"}}},
+		},
+		"data": map[string]any{
+			"scheduledEvent": map[string]any{"StatusName": "Scheduled", "Title": "Induction"},
+		},
+		"now": "2025-01-01T00:00:00Z",
+	}
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/simulate", body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Trace Trace `json:"trace"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.True(t, resp.Trace.WouldFire)
+	require.Equal(t, "Induction", resp.Trace.Actions[0].Parameters["message"])
+
+	// Missing rule -> 400
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/simulate", map[string]any{"data": map[string]any{}})
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
+
+func TestRunHistoryHandlers_Unit(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	rule := Rulev2{
+		Name:    "Job audit",
+		Trigger: TriggerSpec{Type: "job_position"},
+		Actions: []ActionSpec{{Type: "audit_log", Parameters: map[string]any{
+			"action": "job {{.jobPosition.PositionMatrixCode}} changed",
+		}}},
+	}
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/rules", rule)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/trigger/job-position", map[string]any{
+		"operation":   "update",
+		"jobPosition": map[string]any{"PositionMatrixCode": "DEV"},
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Runs  []models.RuleRun `json:"runs"`
+		Total int64            `json:"total"`
+	}
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/rules/"+created.ID+"/runs", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.EqualValues(t, 1, resp.Total)
+	run := resp.Runs[0]
+	require.Equal(t, RunStatusSuccess, run.Status)
+	require.Equal(t, "job_position", run.EntityType)
+	require.Equal(t, "DEV", run.EntityID)
+	require.Contains(t, string(run.Actions), "job DEV changed")
+
+	// Global feed with filters
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/runs?status=failed", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.EqualValues(t, 0, resp.Total)
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/runs?entity_type=job_position&entity_id=DEV&since=2000-01-01", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.EqualValues(t, 1, resp.Total)
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/runs?matched=maybe", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
+
+func TestScheduleAndRunNowHandlers_Unit(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	create := func(rule Rulev2) string {
+		rec := doJSON(t, router, http.MethodPost, "/api/rules/rules", rule)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var created struct {
+			ID string `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		return created.ID
+	}
+	daily := create(Rulev2{
+		Name:    "Morning digest",
+		Trigger: TriggerSpec{Type: "scheduled_time", Parameters: map[string]any{"frequency": "daily", "time_of_day": "08:00"}},
+		Actions: []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"action": "digest manual={{.trigger.manual}}"}}},
+	})
+	event := create(Rulev2{
+		Name:    "Job audit",
+		Trigger: TriggerSpec{Type: "job_position"},
+		Actions: []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"action": "job"}}},
+	})
+
+	var overview ScheduleOverview
+	rec := doJSON(t, router, http.MethodGet, "/api/rules/schedule", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overview))
+	require.Len(t, overview.Scheduled, 1)
+	sr := overview.Scheduled[0]
+	require.Equal(t, daily, sr.RuleID)
+	require.Equal(t, "0 8 * * *", sr.Cron)
+	require.Equal(t, "UTC", sr.Timezone)
+	require.NotNil(t, sr.Next)
+	require.Equal(t, 8, sr.Next.Hour())
+	require.Nil(t, sr.LastRun)
+
+	var runResp struct {
+		Run ManualRun `json:"run"`
+	}
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/rules/"+daily+"/run", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &runResp))
+	require.True(t, runResp.Run.Matched)
+	require.Empty(t, runResp.Run.Error)
+	require.Equal(t, true, runResp.Run.Trigger["manual"])
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/schedule?days=14", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overview))
+	require.Equal(t, 14, overview.HorizonDays)
+	require.NotNil(t, overview.Scheduled[0].LastRun)
+	require.Equal(t, RunStatusSuccess, overview.Scheduled[0].LastRun.Status)
+	require.Contains(t, string(overview.Scheduled[0].LastRun.Actions), "digest manual=true")
+
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/rules/"+event+"/run", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/rules/9999/run", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/schedule?days=0", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
+
+func TestRuleRevisionsHandlers_Unit(t *testing.T) {
+	router, svc := setupRouter(t)
+
+	v1 := Rulev2{
+		Name:    "Reminder",
+		Trigger: TriggerSpec{Type: "competency"},
+		Actions: []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"action": "v1"}}},
+	}
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/rules", v1)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	base := "/api/rules/rules/" + created.ID
+
+	v2 := v1
+	v2.Name = "Reminder (edited)"
+	v2.Actions = []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"action": "v2"}}}
+	rec = doJSON(t, router, http.MethodPut, base, v2)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var list struct {
+		Revisions []models.RuleRevision `json:"revisions"`
+	}
+	rec = doJSON(t, router, http.MethodGet, base+"/revisions", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Revisions, 2)
+	require.Equal(t, 2, list.Revisions[0].Revision)
+
+	var diff struct {
+		Changes []SpecChange `json:"changes"`
+	}
+	rec = doJSON(t, router, http.MethodGet, base+"/revisions/diff?from=1&to=2", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &diff))
+	paths := map[string]SpecChange{}
+	for _, ch := range diff.Changes {
+		paths[ch.Path] = ch
+	}
+	require.Equal(t, "changed", paths["name"].Op)
+	require.Equal(t, "v2", paths["actions[0].parameters.action"].To)
+
+	rec = doJSON(t, router, http.MethodGet, base+"/revisions/9", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	// Rollback to revision 1 restores the spec and appends revision 3
+	rec = doJSON(t, router, http.MethodPost, base+"/revisions/1/rollback", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	got, err := svc.Store.GetRuleByID(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Reminder", got.Name)
+	require.Equal(t, "v1", got.Actions[0].Parameters["action"])
+
+	rec = doJSON(t, router, http.MethodGet, base+"/revisions/3", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "rollback to revision 1")
+
+	// A revision that no longer validates is not restored
+	ruleID, err := strconv.ParseUint(created.ID, 10, 64)
+	require.NoError(t, err)
+	require.NoError(t, svc.Store.DB.Create(&models.RuleRevision{RuleID: uint(ruleID), Revision: 4, Name: "Reminder",
+		TriggerType: "competency", Spec: datatypes.JSON(`{"name":"Reminder","trigger":{"type":"competency"},"actions":[{"type":"retired_action"}]}`)}).Error)
+	rec = doJSON(t, router, http.MethodPost, base+"/revisions/4/rollback", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "retired_action")
+	got, err = svc.Store.GetRuleByID(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "audit_log", got.Actions[0].Type)
+
+	// An unreadable revision is an error, not a skipped permission check
+	require.NoError(t, svc.Store.DB.Create(&models.RuleRevision{RuleID: uint(ruleID), Revision: 5, Name: "Reminder",
+		TriggerType: "competency", Spec: datatypes.JSON(`["not a rule"]`)}).Error)
+	rec = doJSON(t, router, http.MethodPost, base+"/revisions/5/rollback", nil)
+	require.Equal(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+}
+
+func TestJobAdminHandlers_Unit(t *testing.T) {
+	router, svc := setupRouter(t)
+
+	id, err := svc.Queue.Enqueue(t.Context(), QueuedAction{RuleID: "1", RuleName: "r", ActionType: "webhook", MaxAttempts: 1})
+	require.NoError(t, err)
+	jobPath := fmt.Sprintf("/api/rules/jobs/%d", id)
+
+	rec := doJSON(t, router, http.MethodGet, "/api/rules/jobs?status=pending", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"total":1`)
+
+	// Pending jobs cannot be retried
+	rec = doJSON(t, router, http.MethodPost, jobPath+"/retry", nil)
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+	rec = doJSON(t, router, http.MethodPost, jobPath+"/discard", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doJSON(t, router, http.MethodPost, jobPath+"/retry", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doJSON(t, router, http.MethodGet, jobPath, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"status":"pending"`)
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/jobs/999", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+}
+
+func TestHolidayHandlers_Unit(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/holidays", HolidayInput{Region: "ZA", Date: "2025-12-16", Name: "Day of Reconciliation"})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/holidays", HolidayInput{Region: "ZA", Date: "16 Dec"})
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	ics := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20251225\r\nSUMMARY:Christmas Day\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	req, err := http.NewRequest(http.MethodPost, "/api/rules/holidays/import?region=ZA", bytes.NewBufferString(ics))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/calendar")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.JSONEq(t, `{"imported":1}`, rec.Body.String())
+
+	req, err = http.NewRequest(http.MethodPost, "/api/rules/holidays/import?format=csv", bytes.NewBufferString("2025-07-04,Independence Day,US\n"))
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	req, err = http.NewRequest(http.MethodPost, "/api/rules/holidays/import?region=ZA", bytes.NewBufferString("x"))
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code, "format cannot be guessed")
+
+	var list struct {
+		Holidays []models.PublicHoliday `json:"holidays"`
+	}
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/holidays?region=ZA&year=2025", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Holidays, 2)
+	require.Equal(t, "Christmas Day", list.Holidays[1].Name)
+	require.Equal(t, "ics", list.Holidays[1].Source)
+
+	rec = doJSON(t, router, http.MethodDelete, "/api/rules/holidays/"+strconv.FormatUint(uint64(list.Holidays[0].ID), 10), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = doJSON(t, router, http.MethodDelete, "/api/rules/holidays/"+strconv.FormatUint(uint64(list.Holidays[0].ID), 10), nil)
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+}
+
+func TestKillSwitchHandlers_Unit(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/rules", Rulev2{
+		Name:    "Job audit",
+		Trigger: TriggerSpec{Type: "job_position"},
+		Actions: []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"action": "job"}}},
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = doJSON(t, router, http.MethodPut, "/api/rules/kill-switch", map[string]any{})
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	rec = doJSON(t, router, http.MethodPut, "/api/rules/kill-switch", map[string]any{"engaged": true})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var status struct {
+		KillSwitch KillSwitchState `json:"killSwitch"`
+	}
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/status", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.True(t, status.KillSwitch.Engaged)
+
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/trigger/job-position", map[string]any{
+		"operation": "create", "jobPosition": map[string]any{"PositionMatrixCode": "DEV"},
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var runs struct {
+		Runs []models.RuleRun `json:"runs"`
+	}
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/runs?status=suppressed", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &runs))
+	require.Len(t, runs.Runs, 1)
+	require.Equal(t, "kill switch engaged", runs.Runs[0].Reason)
+
+	rec = doJSON(t, router, http.MethodPut, "/api/rules/kill-switch", map[string]any{"engaged": false})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/kill-switch", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var state KillSwitchState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	require.False(t, state.Engaged)
+}
+
+func TestCreateUpdateRule_RejectInvalidSpecs_Unit(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	rule := Rulev2{
+		Name:       "Bad fact",
+		Trigger:    TriggerSpec{Type: "job_position"},
+		Conditions: []Condition{{Fact: "employee.FirstName", Operator: "equals", Value: "Ann"}},
+		Actions:    []ActionSpec{{Type: "audit_log", Parameters: map[string]any{"action": "{{.jobPosition.JobTitle}}"}}},
+	}
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/rules", rule)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	var body struct {
+		Errors []ValidationError `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Errors, 1)
+	require.Equal(t, "conditions[0].fact", body.Errors[0].Parameter)
+
+	rule.Conditions[0].Fact = "jobPosition.JobTitle"
+	rec = doJSON(t, router, http.MethodPost, "/api/rules/rules", rule)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	rule.Actions[0].Parameters["action"] = "{{.role.RoleName}}"
+	rec = doJSON(t, router, http.MethodPut, "/api/rules/rules/"+created.ID, rule)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"parameter":"actions[0].action"`)
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/rules/"+created.ID, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "jobPosition.JobTitle", "a rejected update leaves the rule unchanged")
+}
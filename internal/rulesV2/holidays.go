@@ -0,0 +1,89 @@
+package rulesv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+	"Automated-Scheduling-Project/internal/rulesV2/holidays"
+)
+
+// Holiday import formats accepted by ImportHolidays.
+const (
+	HolidayFormatICS = "ics"
+	HolidayFormatCSV = "csv"
+)
+
+// ErrInvalidHoliday is returned for holiday input that cannot be stored.
+var ErrInvalidHoliday = errors.New("invalid holiday")
+
+// HolidayInput is the body accepted by POST /api/rules/holidays.
+type HolidayInput struct {
+	Region string `json:"region"`
+	Date   string `json:"date"` // YYYY-MM-DD
+	Name   string `json:"name"`
+}
+
+// ListHolidays returns the stored holidays of region (all when empty),
+// optionally for one year only.
+func (s *RuleBackEndService) ListHolidays(ctx context.Context, region string, year int) ([]models.PublicHoliday, error) {
+	return holidays.List(ctx, s.DB, strings.TrimSpace(region), year)
+}
+
+// AddHoliday stores one holiday, renaming it if the day is already a holiday
+// of that region.
+func (s *RuleBackEndService) AddHoliday(ctx context.Context, in HolidayInput) error {
+	if strings.TrimSpace(in.Region) == "" {
+		return fmt.Errorf("%w: region is required", ErrInvalidHoliday)
+	}
+	d, err := time.Parse("2006-01-02", strings.TrimSpace(in.Date))
+	if err != nil {
+		return fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidHoliday)
+	}
+	_, err = holidays.Save(ctx, s.DB, []holidays.Holiday{{Region: in.Region, Date: d, Name: in.Name}}, holidays.SourceManual)
+	return err
+}
+
+// DeleteHoliday removes a stored holiday by id.
+func (s *RuleBackEndService) DeleteHoliday(ctx context.Context, id uint) error {
+	return holidays.Delete(ctx, s.DB, id)
+}
+
+// ImportHolidays parses an ICS or CSV calendar and stores its days under
+// region, returning how many were imported. CSV rows may name their own
+// region; ICS files always need one.
+func (s *RuleBackEndService) ImportHolidays(ctx context.Context, r io.Reader, region, format string) (int, error) {
+	region = strings.TrimSpace(region)
+	var (
+		hs     []holidays.Holiday
+		err    error
+		source string
+	)
+	switch strings.ToLower(format) {
+	case HolidayFormatICS:
+		if region == "" {
+			return 0, fmt.Errorf("%w: region is required for ics imports", ErrInvalidHoliday)
+		}
+		hs, err = holidays.ParseICS(r, region)
+		source = holidays.SourceICS
+	case HolidayFormatCSV:
+		hs, err = holidays.ParseCSV(r, region)
+		source = holidays.SourceCSV
+	default:
+		return 0, fmt.Errorf("%w: unsupported format %q (want ics or csv)", ErrInvalidHoliday, format)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidHoliday, err)
+	}
+	if len(hs) == 0 {
+		return 0, fmt.Errorf("%w: no holidays found", ErrInvalidHoliday)
+	}
+	if _, err := holidays.Save(ctx, s.DB, hs, source); err != nil {
+		return 0, err
+	}
+	return len(hs), nil
+}
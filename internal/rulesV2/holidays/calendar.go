@@ -0,0 +1,100 @@
+// Package holidays keeps public holiday calendars per region and does
+// business-day arithmetic over them.
+package holidays
+
+import (
+	"context"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+// Holiday is one non-working day of a region.
+type Holiday struct {
+	Region string
+	Date   time.Time // calendar day; the time of day is ignored
+	Name   string
+}
+
+type day struct {
+	y int
+	m time.Month
+	d int
+}
+
+func dayOf(t time.Time) day {
+	y, m, d := t.Date()
+	return day{y, m, d}
+}
+
+// Calendar is the set of holidays of one region. A nil Calendar treats only
+// Saturdays and Sundays as non-working days.
+type Calendar struct {
+	Region string
+	days   map[day]string
+}
+
+// NewCalendar builds a calendar from holidays. Dates are read as calendar
+// days in UTC, which is how DATE columns come back from the database.
+func NewCalendar(region string, hs []Holiday) *Calendar {
+	c := &Calendar{Region: region, days: make(map[day]string, len(hs))}
+	for _, h := range hs {
+		c.days[dayOf(h.Date.UTC())] = h.Name
+	}
+	return c
+}
+
+// Load reads the calendar of region from the database. An empty region or a
+// nil db gives a weekends-only calendar.
+func Load(ctx context.Context, db *gorm.DB, region string) (*Calendar, error) {
+	if region == "" || db == nil {
+		return NewCalendar(region, nil), nil
+	}
+	var rows []models.PublicHoliday
+	if err := db.WithContext(ctx).Where("region = ?", region).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	hs := make([]Holiday, 0, len(rows))
+	for _, r := range rows {
+		hs = append(hs, Holiday{Region: r.Region, Date: r.Date, Name: r.Name})
+	}
+	return NewCalendar(region, hs), nil
+}
+
+// Holiday returns the name of the holiday on t's calendar day, if any.
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	name, ok := c.days[dayOf(t)]
+	return name, ok
+}
+
+// IsBusinessDay reports whether t's calendar day is a weekday that is not a
+// holiday.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	_, holiday := c.Holiday(t)
+	return !holiday
+}
+
+// AddBusinessDays moves t by n business days (backwards for negative n),
+// keeping its wall-clock time. Non-working days are stepped over and not
+// counted, so Friday plus one business day is Monday.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
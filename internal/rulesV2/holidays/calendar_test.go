@@ -0,0 +1,131 @@
+//go:build unit
+
+package holidays
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func date(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+func TestCalendar_AddBusinessDays(t *testing.T) {
+	cal := NewCalendar("ZA", []Holiday{{Date: date(2025, 12, 16), Name: "Day of Reconciliation"}})
+
+	name, ok := cal.Holiday(date(2025, 12, 16).Add(9 * time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, "Day of Reconciliation", name)
+	assert.False(t, cal.IsBusinessDay(date(2025, 12, 13)), "Saturday")
+
+	thu := time.Date(2025, 12, 18, 14, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 12, 12, 14, 30, 0, 0, time.UTC), cal.AddBusinessDays(thu, -3))
+	assert.Equal(t, time.Date(2025, 12, 22, 14, 30, 0, 0, time.UTC), cal.AddBusinessDays(thu, 2))
+	assert.Equal(t, thu, cal.AddBusinessDays(thu, 0))
+
+	// A nil calendar only skips weekends.
+	var none *Calendar
+	assert.Equal(t, time.Date(2025, 12, 15, 14, 30, 0, 0, time.UTC), none.AddBusinessDays(thu, -3))
+}
+
+func TestParseICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20251216",
+		"DTEND;VALUE=DATE:20251217",
+		"SUMMARY:Day of Reconciliation",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20251225",
+		"DTEND;VALUE=DATE:20251227",
+		"SUMMARY:Christmas\\, and the",
+		"  day after",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	hs, err := ParseICS(strings.NewReader(ics), "ZA")
+	require.NoError(t, err)
+	require.Len(t, hs, 3)
+	assert.Equal(t, Holiday{Region: "ZA", Date: date(2025, 12, 16), Name: "Day of Reconciliation"}, hs[0])
+	assert.Equal(t, date(2025, 12, 26), hs[2].Date)
+	assert.Equal(t, "Christmas, and the day after", hs[2].Name)
+
+	_, err = ParseICS(strings.NewReader("BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT\n"), "ZA")
+	assert.ErrorContains(t, err, "invalid date")
+}
+
+func TestParseCSV(t *testing.T) {
+	hs, err := ParseCSV(strings.NewReader("date,name,region\n2025-12-16,Day of Reconciliation\n2025-07-04, Independence Day, US\n"), "ZA")
+	require.NoError(t, err)
+	assert.Equal(t, []Holiday{
+		{Region: "ZA", Date: date(2025, 12, 16), Name: "Day of Reconciliation"},
+		{Region: "US", Date: date(2025, 7, 4), Name: "Independence Day"},
+	}, hs)
+
+	_, err = ParseCSV(strings.NewReader("2025-12-16,a\n16/12/2025,b\n"), "ZA")
+	assert.ErrorContains(t, err, "row 2")
+	_, err = ParseCSV(strings.NewReader("2025-12-16,a\n"), "")
+	assert.ErrorContains(t, err, "no region")
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.PublicHoliday{}))
+	ctx := context.Background()
+
+	_, err = Save(ctx, db, []Holiday{
+		{Region: "ZA", Date: date(2025, 12, 16), Name: "Reconciliation"},
+		{Region: "ZA", Date: date(2026, 1, 1), Name: "New Year"},
+		{Region: "US", Date: date(2025, 7, 4), Name: "Independence Day"},
+	}, SourceCSV)
+	require.NoError(t, err)
+	// Re-importing a day renames it rather than duplicating it.
+	_, err = Save(ctx, db, []Holiday{{Region: "ZA", Date: date(2025, 12, 16), Name: "Day of Reconciliation"}}, SourceManual)
+	require.NoError(t, err)
+
+	rows, err := List(ctx, db, "ZA", 2025)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "Day of Reconciliation", rows[0].Name)
+	assert.Equal(t, SourceManual, rows[0].Source)
+
+	cal, err := Load(ctx, db, "ZA")
+	require.NoError(t, err)
+	assert.False(t, cal.IsBusinessDay(date(2025, 12, 16)))
+	assert.True(t, cal.IsBusinessDay(date(2025, 7, 4)))
+
+	require.NoError(t, Delete(ctx, db, rows[0].ID))
+	assert.ErrorIs(t, Delete(ctx, db, rows[0].ID), gorm.ErrRecordNotFound)
+}
+
+func TestSave_RepeatedDay(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.PublicHoliday{}))
+	ctx := context.Background()
+
+	// Two events on one day, as an ICS feed with overlapping observances has.
+	n, err := Save(ctx, db, []Holiday{
+		{Region: "ZA", Date: date(2026, 4, 3), Name: "Good Friday"},
+		{Region: "ZA", Date: date(2026, 4, 6), Name: "Family Day"},
+		{Region: "ZA", Date: time.Date(2026, 4, 3, 15, 0, 0, 0, time.UTC), Name: "Observance"},
+	}, SourceICS)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "the repeated day is written once")
+
+	rows, err := List(ctx, db, "ZA", 2026)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Observance", rows[0].Name, "the last holiday on a day wins")
+	assert.Equal(t, "Family Day", rows[1].Name)
+}
@@ -0,0 +1,151 @@
+package holidays
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxEventDays caps how many days a single ICS event may expand to.
+const maxEventDays = 31
+
+// ParseICS reads the all-day VEVENTs of an iCalendar file as holidays of
+// region. Multi-day events give one holiday per day (DTEND is exclusive).
+// Recurrence rules are not expanded; export a calendar with concrete dates.
+func ParseICS(r io.Reader, region string) ([]Holiday, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+	var out []Holiday
+	var inEvent bool
+	var start, end time.Time
+	var summary string
+	for n, line := range lines {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent, start, end, summary = true, time.Time{}, time.Time{}, ""
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if !inEvent {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", n+1, summary)
+			}
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for d, i := start, 0; d.Before(end); d, i = d.AddDate(0, 0, 1), i+1 {
+				if i == maxEventDays {
+					return nil, fmt.Errorf("line %d: event %q spans more than %d days", n+1, summary, maxEventDays)
+				}
+				out = append(out, Holiday{Region: region, Date: d, Name: summary})
+			}
+		case !inEvent:
+		case name == "DTSTART" || name == "DTEND":
+			t, err := parseICSDate(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			if name == "DTSTART" {
+				start = t
+			} else {
+				end = t
+			}
+		case name == "SUMMARY":
+			summary = unescapeICS(value)
+		}
+	}
+	return out, nil
+}
+
+// unfoldICS joins continuation lines (those starting with a space or tab).
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// splitICSLine splits "DTSTART;VALUE=DATE:20251225" into its name,
+// parameters and value.
+func splitICSLine(line string) (string, string, string) {
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return strings.ToUpper(line), "", ""
+	}
+	head, value := line[:i], line[i+1:]
+	name, params, _ := strings.Cut(head, ";")
+	return strings.ToUpper(name), params, strings.TrimSpace(value)
+}
+
+func parseICSDate(value, params string) (time.Time, error) {
+	layouts := []string{"20060102T150405Z", "20060102T150405", "20060102"}
+	if strings.Contains(strings.ToUpper(params), "VALUE=DATE") && !strings.Contains(strings.ToUpper(params), "VALUE=DATE-TIME") {
+		layouts = []string{"20060102"}
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, value); err == nil {
+			y, m, d := t.Date()
+			return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(strings.TrimSpace(s))
+}
+
+// ParseCSV reads "date,name[,region]" rows, with or without a header row.
+// Dates are YYYY-MM-DD; rows without a region belong to region.
+func ParseCSV(r io.Reader, region string) ([]Holiday, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var out []Holiday
+	for n := 1; ; n++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) == 0 || (len(rec) == 1 && strings.TrimSpace(rec[0]) == "") {
+			continue
+		}
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(rec[0]))
+		if err != nil {
+			if n == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("row %d: invalid date %q (want YYYY-MM-DD)", n, rec[0])
+		}
+		h := Holiday{Region: region, Date: d}
+		if len(rec) > 1 {
+			h.Name = strings.TrimSpace(rec[1])
+		}
+		if len(rec) > 2 && strings.TrimSpace(rec[2]) != "" {
+			h.Region = strings.TrimSpace(rec[2])
+		}
+		if h.Region == "" {
+			return nil, fmt.Errorf("row %d: no region", n)
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package holidays
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Source values recorded on stored holidays.
+const (
+	SourceManual = "manual"
+	SourceICS    = "ics"
+	SourceCSV    = "csv"
+)
+
+// Save stores holidays, replacing the name of any already on the same region
+// and day. When hs has several holidays on one region and day (overlapping
+// ICS events, repeated CSV dates) the last one wins, since Postgres refuses an
+// upsert that touches the same row twice. It returns the number of rows
+// written.
+func Save(ctx context.Context, db *gorm.DB, hs []Holiday, source string) (int, error) {
+	if len(hs) == 0 {
+		return 0, nil
+	}
+	type key struct {
+		region string
+		date   time.Time
+	}
+	seen := make(map[key]int, len(hs))
+	rows := make([]models.PublicHoliday, 0, len(hs))
+	for _, h := range hs {
+		region := strings.TrimSpace(h.Region)
+		if region == "" {
+			return 0, fmt.Errorf("holiday %s has no region", h.Date.Format("2006-01-02"))
+		}
+		y, m, d := h.Date.Date()
+		row := models.PublicHoliday{
+			Region: region,
+			Date:   time.Date(y, m, d, 0, 0, 0, 0, time.UTC),
+			Name:   strings.TrimSpace(h.Name),
+			Source: source,
+		}
+		k := key{row.Region, row.Date}
+		if i, ok := seen[k]; ok {
+			rows[i] = row
+			continue
+		}
+		seen[k] = len(rows)
+		rows = append(rows, row)
+	}
+	res := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "region"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "source"}),
+	}).Create(&rows)
+	return int(res.RowsAffected), res.Error
+}
+
+// List returns the holidays of region (all regions when empty), optionally
+// limited to one year, in date order.
+func List(ctx context.Context, db *gorm.DB, region string, year int) ([]models.PublicHoliday, error) {
+	q := db.WithContext(ctx).Model(&models.PublicHoliday{})
+	if region != "" {
+		q = q.Where("region = ?", region)
+	}
+	if year > 0 {
+		q = q.Where("date >= ? AND date < ?", time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC))
+	}
+	var rows []models.PublicHoliday
+	err := q.Order("date ASC, region ASC").Find(&rows).Error
+	return rows, err
+}
+
+// Delete removes one stored holiday, returning gorm.ErrRecordNotFound when
+// there is none with that id.
+func Delete(ctx context.Context, db *gorm.DB, id uint) error {
+	res := db.WithContext(ctx).Delete(&models.PublicHoliday{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
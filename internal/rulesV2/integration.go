@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"Automated-Scheduling-Project/internal/database/models"
 	rsched "Automated-Scheduling-Project/internal/rulesV2/scheduler"
@@ -19,7 +20,14 @@ type RuleBackEndService struct {
 	DB        *gorm.DB
 	Engine    *Engine
 	Store     *DbRuleStore
+	Runs      *DbRunStore
+	Queue     *ActionQueue
 	Scheduler *rsched.Service
+	Limiter   *DbLimiter
+
+	// ActionPermissions maps action types to the permission needed to save
+	// rules using them over the API (see RouteAccess).
+	ActionPermissions map[string]string
 }
 
 // scheduler store adapter to avoid import cycles
@@ -30,7 +38,7 @@ func (a *schedStoreAdapter) ListByTrigger(ctx context.Context, triggerType strin
 	var rows []models.Rule
 	if err := a.inner.DB.WithContext(ctx).
 		Where("trigger_type = ? AND enabled = ?", triggerType, true).
-		Order("id ASC").
+		Order(ruleExecutionOrder).
 		Find(&rows).Error; err != nil {
 		return nil, err
 	}
@@ -42,6 +50,7 @@ func (a *schedStoreAdapter) ListByTrigger(ctx context.Context, triggerType strin
 			log.Printf("Failed to unmarshal rule id=%d: %v", r.ID, err)
 			continue
 		}
+		spec.ID = strconv.FormatUint(uint64(r.ID), 10)
 		out = append(out, rsched.Rule{
 			ID:   strconv.FormatUint(uint64(r.ID), 10),
 			Name: r.Name,
@@ -57,8 +66,11 @@ func (a *schedStoreAdapter) ListByTrigger(ctx context.Context, triggerType strin
 
 // NewRuleBackEndService creates a new integration service with all components wired
 func NewRuleBackEndService(db *gorm.DB) *RuleBackEndService {
+	createEvent := &CreateEventAction{DB: db}
+	notify := &NotificationAction{DB: db}
 	registry := NewRegistryWithDefaults().
-		UseFactResolver(UnifiedFacts{}). // single resolver
+		UseFactResolver(DbFacts{DB: db}). // DB-derived facts, e.g. employee.HasCompetency[12]
+		UseFactResolver(UnifiedFacts{}).  // payload passthrough
 		UseTrigger("job_position", NewTrigger(db, "job_position")).
 		UseTrigger("competency_type", NewTrigger(db, "competency_type")).
 		UseTrigger("competency", NewTrigger(db, "competency")).
@@ -69,18 +81,25 @@ func NewRuleBackEndService(db *gorm.DB) *RuleBackEndService {
 		UseTrigger("competency_prerequisite", NewTrigger(db, "competency_prerequisite")).
 		UseTrigger("scheduled_time", NewTrigger(db, "scheduled_time")).
 		UseTrigger("relative_time", NewTrigger(db, "relative_time")).
-		UseAction("notification", &NotificationAction{DB: db}).
+		UseAction("notification", notify).
 		// UseAction("schedule_training", &ScheduleTrainingAction{DB: db}).
 		UseAction("competency_assignment", &CompetencyAssignmentAction{DB: db}).
 		UseAction("webhook", &WebhookAction{}).
 		UseAction("audit_log", &AuditLogAction{DB: db}).
-		UseAction("create_event", &CreateEventAction{DB: db})
+		UseAction("create_event", createEvent)
+
+	runs := &DbRunStore{DB: db}
+	limiter := &DbLimiter{DB: db}
+	notify.Limiter = limiter
 
 	engine := &Engine{
 		R:                       registry,
 		ContinueActionsOnError:  true,
 		StopOnFirstConditionErr: false,
 		Debug:                   true,
+		Recorder:                runs,
+		Limiter:                 limiter,
+		MaxCascadeDepth:         maxCascadeDepthFromEnv(),
 	}
 
 	store := &DbRuleStore{DB: db}
@@ -91,20 +110,46 @@ func NewRuleBackEndService(db *gorm.DB) *RuleBackEndService {
 		if !ok {
 			return nil
 		}
-		return engine.EvaluateOnce(EvalContext{
+		// The relative_time poller fires a held-back occurrence on a later tick.
+		_, later, err := engine.evaluateFire(EvalContext{
 			Now:  ev.Now,
 			Data: ev.Data,
-		}, rr)
+		}, rr, rr.Trigger.Type == "relative_time")
+		if later != "" {
+			return fmt.Errorf("%s: %w", later, rsched.ErrHeldBack)
+		}
+		return err
 	}
 
+	queue := NewActionQueue(db, registry)
+	queue.Limiter = limiter
 	sched := rsched.New(db, &schedStoreAdapter{inner: store}, evalFn)
+	// Digest notifications go out after the tick that buffered them, or
+	// once their window has passed.
+	sched.OnTick(func(ctx context.Context, now time.Time) {
+		if _, err := flushTickDigests(ctx, queue, notify, now); err != nil {
+			log.Printf("notification digests: %v", err)
+		}
+	})
 
-	return &RuleBackEndService{
+	svc := &RuleBackEndService{
 		DB:        db,
 		Engine:    engine,
 		Store:     store,
+		Runs:      runs,
+		Queue:     queue,
 		Scheduler: sched,
+		Limiter:   limiter,
+
+		ActionPermissions: defaultActionPermissions,
 	}
+
+	// Events created by rules fire scheduled_event like any other new event;
+	// DispatchEvent bounds the resulting cascade.
+	createEvent.OnCreated = func(ctx context.Context, schedule *models.CustomEventSchedule) error {
+		return svc.OnScheduledEvent(ctx, "create", "", *schedule)
+	}
+	return svc
 }
 
 // StartScheduler starts the background scheduler/poller.
@@ -117,6 +162,22 @@ func (s *RuleBackEndService) StopScheduler(ctx context.Context) error {
 	return s.Scheduler.Stop(ctx)
 }
 
+// StartQueue starts the action queue workers and routes engine actions
+// through the queue from now on. Without it actions run inline.
+func (s *RuleBackEndService) StartQueue(ctx context.Context) error {
+	if err := s.Queue.Start(ctx); err != nil {
+		return err
+	}
+	s.Engine.Queue = s.Queue
+	return nil
+}
+
+// StopQueue stops the workers. Jobs enqueued afterwards stay pending in the
+// table and are picked up on the next start.
+func (s *RuleBackEndService) StopQueue(ctx context.Context) error {
+	return s.Queue.Stop(ctx)
+}
+
 // DbRuleStore implements RuleStore interface for database persistence (uses models.Rule -> table "rules")
 type DbRuleStore struct {
 	DB *gorm.DB
@@ -135,7 +196,7 @@ func (s *DbRuleStore) ListByTrigger(ctx context.Context, triggerType string) ([]
 	var rows []models.Rule
 	if err := s.DB.WithContext(ctx).
 		Where("trigger_type = ? AND enabled = ?", triggerType, true).
-		Order("id ASC").
+		Order(ruleExecutionOrder).
 		Find(&rows).Error; err != nil {
 		return nil, err
 	}
@@ -146,6 +207,7 @@ func (s *DbRuleStore) ListByTrigger(ctx context.Context, triggerType string) ([]
 			log.Printf("Failed to unmarshal rule id=%d: %v", r.ID, err)
 			continue
 		}
+		spec.ID = strconv.FormatUint(uint64(r.ID), 10)
 		out = append(out, spec)
 	}
 	return out, nil
@@ -164,6 +226,7 @@ func (s *DbRuleStore) GetRuleByID(ctx context.Context, ruleID string) (*Rulev2,
 	if err := json.Unmarshal(row.Spec, &spec); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal rule: %w", err)
 	}
+	spec.ID = strconv.FormatUint(uint64(row.ID), 10)
 	return &spec, nil
 }
 
@@ -178,29 +241,24 @@ func (s *DbRuleStore) CreateRule(ctx context.Context, rule Rulev2) (string, erro
 		TriggerType: rule.Trigger.Type,
 		Spec:        datatypes.JSON(body),
 		Enabled:     true,
+		Priority:    rule.Priority,
 	}
-	if err := s.DB.WithContext(ctx).Create(&row).Error; err != nil {
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+		_, err := writeRevision(tx, row, authorFromContext(ctx), "")
+		return err
+	})
+	if err != nil {
 		return "", err
 	}
 	return strconv.FormatUint(uint64(row.ID), 10), nil
 }
 
+// UpdateRule replaces a rule's spec and records a new revision
 func (s *DbRuleStore) UpdateRule(ctx context.Context, ruleID string, rule Rulev2) error {
-	id, err := strconv.ParseUint(ruleID, 10, 64)
-	if err != nil {
-		return fmt.Errorf("invalid rule id: %w", err)
-	}
-	body, err := json.Marshal(rule)
-	if err != nil {
-		return fmt.Errorf("failed to marshal rule: %w", err)
-	}
-	return s.DB.WithContext(ctx).Model(&models.Rule{}).
-		Where("id = ?", uint(id)).
-		Updates(map[string]any{
-			"name":         rule.Name,
-			"trigger_type": rule.Trigger.Type,
-			"spec":         datatypes.JSON(body),
-		}).Error
+	return s.replaceSpec(ctx, ruleID, rule, "")
 }
 
 func (s *DbRuleStore) DeleteRule(ctx context.Context, ruleID string) error {
@@ -233,6 +291,7 @@ func (s *DbRuleStore) ListAllRules(ctx context.Context) ([]Rulev2, error) {
 			log.Printf("Failed to unmarshal rule id=%d: %v", r.ID, err)
 			continue
 		}
+		spec.ID = strconv.FormatUint(uint64(r.ID), 10)
 		out = append(out, spec)
 	}
 	return out, nil
@@ -389,11 +448,23 @@ func (s *RuleBackEndService) OnCompetencyPrerequisite(ctx context.Context, opera
 
 // Convenience wrappers so we can (un)schedule on rule changes
 
+// validateSpec rejects rules that would only fail once they fire.
+func (s *RuleBackEndService) validateSpec(rule Rulev2) error {
+	if res := ValidateRuleSpec(s.Engine.R, rule); !res.Valid {
+		return &InvalidRuleError{Errors: res.Errors}
+	}
+	return nil
+}
+
 func (s *RuleBackEndService) CreateRule(ctx context.Context, rule Rulev2) (string, error) {
+	if err := s.validateSpec(rule); err != nil {
+		return "", err
+	}
 	id, err := s.Store.CreateRule(ctx, rule)
 	if err != nil {
 		return "", err
 	}
+	rule.ID = id
 	// Schedule immediately if scheduled_time
 	if s.Scheduler != nil && rule.Trigger.Type == "scheduled_time" {
 		if err := s.Scheduler.ScheduleFixedRule(id, rule.Name, rule.Trigger.Parameters, rule); err != nil {
@@ -404,19 +475,30 @@ func (s *RuleBackEndService) CreateRule(ctx context.Context, rule Rulev2) (strin
 }
 
 func (s *RuleBackEndService) UpdateRule(ctx context.Context, ruleID string, rule Rulev2) error {
+	if err := s.validateSpec(rule); err != nil {
+		return err
+	}
 	if err := s.Store.UpdateRule(ctx, ruleID, rule); err != nil {
 		return err
 	}
-	if s.Scheduler != nil {
-		if rule.Trigger.Type == "scheduled_time" {
-			if err := s.Scheduler.ScheduleFixedRule(ruleID, rule.Name, rule.Trigger.Parameters, rule); err != nil {
-				log.Printf("UpdateRule schedule error id=%s: %v", ruleID, err)
-			}
-		} else {
-			s.Scheduler.UnscheduleFixedRule(ruleID)
+	rule.ID = ruleID
+	s.syncSchedule(ruleID, rule)
+	return nil
+}
+
+// syncSchedule (re)schedules a scheduled_time rule after its spec changed and
+// drops any cron entry when the trigger is something else.
+func (s *RuleBackEndService) syncSchedule(ruleID string, rule Rulev2) {
+	if s.Scheduler == nil {
+		return
+	}
+	if rule.Trigger.Type == "scheduled_time" {
+		if err := s.Scheduler.ScheduleFixedRule(ruleID, rule.Name, rule.Trigger.Parameters, rule); err != nil {
+			log.Printf("schedule error id=%s: %v", ruleID, err)
 		}
+	} else {
+		s.Scheduler.UnscheduleFixedRule(ruleID)
 	}
-	return nil
 }
 
 func (s *RuleBackEndService) EnableRule(ctx context.Context, ruleID string, enabled bool) error {
@@ -438,8 +520,11 @@ func (s *RuleBackEndService) EnableRule(ctx context.Context, ruleID string, enab
 			}
 		}
 	} else {
-		// Unschedule on disable
+		// Unschedule on disable; the time it stays off is not a misfire
 		s.Scheduler.UnscheduleFixedRule(ruleID)
+		if err := s.Scheduler.ResetFireState(ctx, ruleID); err != nil {
+			log.Printf("EnableRule reset fire state id=%s: %v", ruleID, err)
+		}
 	}
 	return nil
 }
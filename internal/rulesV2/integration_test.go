@@ -17,7 +17,7 @@ func newSQLite(t *testing.T) *gorm.DB {
 	t.Helper()
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
-	err = db.AutoMigrate(&models.Rule{})
+	err = db.AutoMigrate(&models.Rule{}, &models.RuleRevision{}, &models.RuleRun{})
 	require.NoError(t, err)
 	return db
 }
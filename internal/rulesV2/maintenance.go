@@ -0,0 +1,154 @@
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// maxMaintenance bounds how long a maintenance window may silence rules.
+const maxMaintenance = 7 * 24 * time.Hour
+
+// ErrInvalidMaintenance is returned for maintenance requests that cannot be
+// started.
+var ErrInvalidMaintenance = errors.New("invalid maintenance request")
+
+// MaintenanceInput is the body accepted by POST /api/rules/maintenance.
+type MaintenanceInput struct {
+	// TriggerTypes to silence; every trigger type when empty.
+	TriggerTypes []string `json:"triggerTypes"`
+	// Duration uses the rule limit syntax, e.g. "90m", "4h" or "1d", and
+	// may not exceed seven days.
+	Duration string `json:"duration"`
+	Reason   string `json:"reason"`
+}
+
+// silence is an active maintenance window as DbLimiter caches it.
+type silence struct {
+	triggerTypes []string // every trigger type when empty
+	endsAt       time.Time
+}
+
+func (s silence) covers(triggerType string, now time.Time) bool {
+	return now.Before(s.endsAt) && (len(s.triggerTypes) == 0 || slices.Contains(s.triggerTypes, triggerType))
+}
+
+// StartMaintenance silences the given trigger types, or every rule, for
+// in.Duration on all instances.
+func (s *RuleBackEndService) StartMaintenance(ctx context.Context, in MaintenanceInput, by string) (*models.RuleMaintenance, error) {
+	d, err := parseLimitDuration(in.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("%w: duration: %v", ErrInvalidMaintenance, err)
+	}
+	if d > maxMaintenance {
+		return nil, fmt.Errorf("%w: duration may not exceed 7d", ErrInvalidMaintenance)
+	}
+	types := []string{}
+	for _, t := range in.TriggerTypes {
+		t = strings.TrimSpace(t)
+		if s.Engine.R.Triggers[t] == nil {
+			return nil, fmt.Errorf("%w: unknown trigger type %q", ErrInvalidMaintenance, t)
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return s.Limiter.StartMaintenance(ctx, types, d, strings.TrimSpace(in.Reason), by)
+}
+
+// StartMaintenance stores a maintenance window starting now.
+func (l *DbLimiter) StartMaintenance(ctx context.Context, triggerTypes []string, d time.Duration, reason, by string) (*models.RuleMaintenance, error) {
+	types, err := json.Marshal(triggerTypes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	row := models.RuleMaintenance{
+		TriggerTypes: datatypes.JSON(types),
+		Reason:       reason,
+		StartedBy:    by,
+		StartedAt:    now,
+		EndsAt:       now.Add(d),
+	}
+	if err := l.DB.WithContext(ctx).Create(&row).Error; err != nil {
+		return nil, err
+	}
+	l.invalidate()
+	return &row, nil
+}
+
+// EndMaintenance ends a maintenance window early, returning
+// gorm.ErrRecordNotFound when there is no active window with that id.
+func (l *DbLimiter) EndMaintenance(ctx context.Context, id uint, by string) error {
+	now := time.Now().UTC()
+	res := l.DB.WithContext(ctx).Model(&models.RuleMaintenance{}).
+		Where("id = ? AND ended_at IS NULL AND ends_at > ?", id, now).
+		Updates(map[string]any{"ended_at": now, "ended_by": by})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	l.invalidate()
+	return nil
+}
+
+// ListMaintenance returns the most recent maintenance windows, newest first,
+// with activeOnly limiting them to those silencing rules now.
+func (l *DbLimiter) ListMaintenance(ctx context.Context, activeOnly bool) ([]models.RuleMaintenance, error) {
+	q := l.DB.WithContext(ctx).Order("id DESC").Limit(100)
+	if activeOnly {
+		q = q.Where("ended_at IS NULL AND ends_at > ?", time.Now().UTC())
+	}
+	var rows []models.RuleMaintenance
+	err := q.Find(&rows).Error
+	return rows, err
+}
+
+// held reports what the kill switch and maintenance hold back now: every
+// rule (all), or else the listed trigger types. Queue workers and the digest
+// flush leave that work for later.
+func (l *DbLimiter) held(ctx context.Context) (all bool, triggerTypes []string, err error) {
+	killed, silences, err := l.engaged(ctx)
+	if err != nil || killed {
+		return killed, nil, err
+	}
+	wall := time.Now()
+	for _, s := range silences {
+		if !wall.Before(s.endsAt) {
+			continue
+		}
+		if len(s.triggerTypes) == 0 {
+			return true, nil, nil
+		}
+		triggerTypes = append(triggerTypes, s.triggerTypes...)
+	}
+	return false, triggerTypes, nil
+}
+
+// activeSilences loads the maintenance windows in force now.
+func (l *DbLimiter) activeSilences(ctx context.Context) ([]silence, error) {
+	rows, err := l.ListMaintenance(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]silence, 0, len(rows))
+	for _, row := range rows {
+		var types []string
+		if err := json.Unmarshal(row.TriggerTypes, &types); err != nil {
+			return nil, fmt.Errorf("maintenance %d has unreadable trigger types: %w", row.ID, err)
+		}
+		out = append(out, silence{triggerTypes: types, endsAt: row.EndsAt})
+	}
+	return out, nil
+}
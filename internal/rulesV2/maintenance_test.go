@@ -0,0 +1,167 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Maintenance(t *testing.T) {
+	eng, lim, act, rec := newThrottledEngine(t)
+	ctx := context.Background()
+	competency := Rulev2{ID: "5", Name: "competency", Trigger: TriggerSpec{Type: "competency"}, Actions: []ActionSpec{{Type: "notify"}}}
+	roles := Rulev2{ID: "6", Name: "roles", Trigger: TriggerSpec{Type: "roles"}, Actions: []ActionSpec{{Type: "notify"}}}
+	ev := EvalContext{Now: fixedNow(), Data: map[string]any{}}
+
+	row, err := lim.StartMaintenance(ctx, []string{"competency"}, time.Hour, "HR import", "admin@example.com")
+	require.NoError(t, err)
+	require.NoError(t, eng.EvaluateOnce(ev, competency))
+	require.NoError(t, eng.EvaluateOnce(ev, roles))
+	assert.Len(t, act.Calls, 1, "only competency rules are silenced")
+	require.Len(t, rec.Runs, 2)
+	assert.Equal(t, RunStatusSuppressed, rec.Runs[0].Status)
+	assert.True(t, strings.HasPrefix(rec.Runs[0].Reason, "maintenance mode until "), rec.Runs[0].Reason)
+
+	require.NoError(t, lim.EndMaintenance(ctx, row.ID, "ops@example.com"))
+	require.NoError(t, eng.EvaluateOnce(ev, competency))
+	assert.Len(t, act.Calls, 2)
+
+	_, err = lim.StartMaintenance(ctx, []string{}, time.Hour, "migration", "admin@example.com")
+	require.NoError(t, err)
+	require.NoError(t, eng.EvaluateOnce(ev, roles))
+	assert.Len(t, act.Calls, 2, "an empty list silences every rule")
+
+	history, err := lim.ListMaintenance(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "ops@example.com", history[1].EndedBy)
+	assert.NotNil(t, history[1].EndedAt)
+	active, err := lim.ListMaintenance(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "migration", active[0].Reason)
+}
+
+func TestActionQueue_HeldBackByKillSwitchAndMaintenance(t *testing.T) {
+	stub := &capturingAction{}
+	q := newTestQueue(t, map[string]ActionHandler{"STUB": stub})
+	require.NoError(t, q.DB.AutoMigrate(&models.RuleSetting{}, &models.RuleMaintenance{}))
+	lim := &DbLimiter{DB: q.DB}
+	q.Limiter = lim
+	ctx := context.Background()
+	run := func() bool {
+		processed, err := q.RunOnce(ctx, "w")
+		require.NoError(t, err)
+		return processed
+	}
+
+	_, err := q.Enqueue(ctx, QueuedAction{ActionType: "STUB", TriggerType: "competency"})
+	require.NoError(t, err)
+	require.NoError(t, lim.SetKillSwitch(ctx, true, "admin@example.com"))
+	assert.False(t, run(), "no job runs while the kill switch is on")
+	require.NoError(t, q.WaitIdle(ctx, "STUB", time.Now().UTC()), "held jobs are not waited for")
+
+	require.NoError(t, lim.SetKillSwitch(ctx, false, "admin@example.com"))
+	row, err := lim.StartMaintenance(ctx, []string{"competency"}, time.Hour, "HR import", "admin@example.com")
+	require.NoError(t, err)
+	_, err = q.Enqueue(ctx, QueuedAction{ActionType: "STUB", TriggerType: "roles"})
+	require.NoError(t, err)
+	assert.True(t, run())
+	assert.False(t, run(), "the competency job waits for the maintenance window")
+	assert.Len(t, stub.Calls, 1)
+
+	require.NoError(t, lim.EndMaintenance(ctx, row.ID, "ops@example.com"))
+	assert.True(t, run())
+	assert.Len(t, stub.Calls, 2)
+}
+
+func TestDigest_FlushHeldBackByKillSwitchAndMaintenance(t *testing.T) {
+	action, db := newDigestAction(t)
+	require.NoError(t, db.AutoMigrate(&models.RuleSetting{}, &models.RuleMaintenance{}))
+	lim := &DbLimiter{DB: db}
+	action.Limiter = lim
+	ctx := context.Background()
+	now := fixedNow()
+	ev := EvalContext{Now: now, Chain: []Cause{{RuleID: "5", RuleName: "expiry", Trigger: "competency"}}}
+	require.NoError(t, action.Execute(ev, digestParams("tick", "A", "first")))
+
+	require.NoError(t, lim.SetKillSwitch(ctx, true, "admin@example.com"))
+	sent, err := action.FlushDigests(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	require.NoError(t, lim.SetKillSwitch(ctx, false, "admin@example.com"))
+	row, err := lim.StartMaintenance(ctx, []string{"competency"}, time.Hour, "HR import", "admin@example.com")
+	require.NoError(t, err)
+	sent, err = action.FlushDigests(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	require.Len(t, pendingDigestItems(t, db), 1)
+
+	require.NoError(t, lim.EndMaintenance(ctx, row.ID, "ops@example.com"))
+	sent, err = action.FlushDigests(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+}
+
+func TestRoutes_Maintenance(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	for _, body := range []map[string]any{
+		{"duration": "8d"},
+		{"duration": "soon"},
+		{"duration": "1h", "triggerTypes": []string{"nope"}},
+	} {
+		rec := doJSON(t, router, http.MethodPost, "/api/rules/maintenance", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "%v: %s", body, rec.Body.String())
+	}
+
+	rec := doJSON(t, router, http.MethodPost, "/api/rules/maintenance",
+		map[string]any{"triggerTypes": []string{"competency", "competency"}, "duration": "4h", "reason": "HR import"})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		Maintenance struct {
+			ID           uint      `json:"id"`
+			TriggerTypes []string  `json:"triggerTypes"`
+			StartedBy    string    `json:"startedBy"`
+			StartedAt    time.Time `json:"startedAt"`
+			EndsAt       time.Time `json:"endsAt"`
+		} `json:"maintenance"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	m := created.Maintenance
+	assert.Equal(t, []string{"competency"}, m.TriggerTypes)
+	assert.Equal(t, "tester@example.com", m.StartedBy)
+	assert.Equal(t, 4*time.Hour, m.EndsAt.Sub(m.StartedAt))
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/status", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"reason":"HR import"`)
+
+	path := "/api/rules/maintenance/" + fmt.Sprint(m.ID)
+	rec = doJSON(t, router, http.MethodDelete, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = doJSON(t, router, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code, "already ended")
+
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/maintenance?active=true", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"maintenance": []}`, rec.Body.String())
+	rec = doJSON(t, router, http.MethodGet, "/api/rules/maintenance", nil)
+	assert.Contains(t, rec.Body.String(), `"endedBy":"tester@example.com"`)
+
+	viewer, _ := setupRouterWith(t, grantedAccess(PermissionRulesView))
+	rec = doJSON(t, viewer, http.MethodPost, "/api/rules/maintenance", map[string]any{"duration": "1h"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
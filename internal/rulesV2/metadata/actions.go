@@ -11,8 +11,8 @@ func GetActionMetadata() []ActionMetadata {
 				{
 					Name:        "type",
 					Type:        "string",
-					Required:    true,
-					Description: "Email or SMS",
+					Required:    false,
+					Description: "Email or SMS (defaults to email)",
 					Options:     []any{"sms", "email"},
 					Example:     "sms",
 				},
@@ -37,6 +37,27 @@ func GetActionMetadata() []ActionMetadata {
 					Description: "Message content",
 					Example:     "Employee needs safety training by end of month",
 				},
+				{
+					Name:        "digest",
+					Type:        "string",
+					Required:    false,
+					Description: "Combine messages per recipient instead of sending each one: 'tick' sends them after the current scheduler tick, a window like '1h' or '1d' after that long",
+					Example:     "1d",
+				},
+				{
+					Name:        "digestSubject",
+					Type:        "string",
+					Required:    false,
+					Description: "Subject of a combined message; a template over {{.Count}} and {{.Recipient}}. Defaults to 'You have N notifications'",
+					Example:     "{{.Count}} competencies expire soon",
+				},
+				{
+					Name:        "digestTemplate",
+					Type:        "text_area",
+					Required:    false,
+					Description: "Body of a combined message; a list template ranging over {{.Items}} (each with .Subject, .Message, .Rule, .At). Defaults to one line per message",
+					Example:     "{{range .Items}}- {{.Message}}\n{{end}}",
+				},
 			},
 		},
 		{
@@ -62,14 +83,14 @@ func GetActionMetadata() []ActionMetadata {
 					Name:        "startTime",
 					Type:        "date",
 					Required:    true,
-					Description: "Event start date and time. Supports relative dates like 'today', 'in 1 month', 'tomorrow', etc. or absolute dates in YYYY-MM-DD HH:MM format",
+					Description: "Event start date and time. Supports relative dates like 'today', 'in 1 month', 'in 3 business days', 'tomorrow', etc. or absolute dates in YYYY-MM-DD HH:MM format",
 					Example:     "in 1 month",
 				},
 				{
 					Name:        "endTime",
 					Type:        "date",
 					Required:    false,
-					Description: "Event end date and time. Supports relative dates like 'today', 'in 1 month', 'tomorrow', etc. or absolute dates in YYYY-MM-DD HH:MM format. Defaults to 2 hours after start time if not provided",
+					Description: "Event end date and time. Supports relative dates like 'today', 'in 1 month', 'in 3 business days', 'tomorrow', etc. or absolute dates in YYYY-MM-DD HH:MM format. Defaults to 2 hours after start time if not provided",
 					Example:     "in 1 month",
 				},
 				{
@@ -79,6 +100,13 @@ func GetActionMetadata() []ActionMetadata {
 					Description: "Room or location name for the event",
 					Example:     "Conference Room A",
 				},
+				{
+					Name:        "holidayRegion",
+					Type:        "string",
+					Required:    false,
+					Description: "Holiday calendar region whose public holidays 'business days' expressions skip. Weekends are always skipped",
+					Example:     "ZA",
+				},
 				{
 					Name:        "maxAttendees",
 					Type:        "number",
@@ -109,5 +137,101 @@ func GetActionMetadata() []ActionMetadata {
 				},
 			},
 		},
+		{
+			Type:        "competency_assignment",
+			Name:        "Assign Competency",
+			Description: "Add a competency to, or remove it from, an employee's job matrix",
+			Parameters: []Parameter{
+				{
+					Name:        "employeeNumber",
+					Type:        "string",
+					Required:    true,
+					Description: "Employee number of the employee to update",
+					Example:     "{{.employee.EmployeeNumber}}",
+				},
+				{
+					Name:        "competencyID",
+					Type:        "number",
+					Required:    true,
+					Description: "Competency definition ID to assign or remove",
+					Example:     12,
+				},
+				{
+					Name:        "action",
+					Type:        "string",
+					Required:    true,
+					Description: "Whether to assign the competency as required or remove it",
+					Options:     []any{"assign", "remove"},
+					Example:     "assign",
+				},
+			},
+		},
+		{
+			Type:        "webhook",
+			Name:        "Call Webhook",
+			Description: "Send an HTTP request with a JSON payload to an external system",
+			Parameters: []Parameter{
+				{
+					Name:        "url",
+					Type:        "string",
+					Required:    true,
+					Description: "URL to call",
+					Example:     "https://hooks.example.com/training",
+				},
+				{
+					Name:        "method",
+					Type:        "string",
+					Required:    false,
+					Description: "HTTP method (defaults to POST)",
+					Options:     []any{"POST", "PUT", "PATCH", "GET", "DELETE"},
+					Example:     "POST",
+				},
+				{
+					Name:        "payload",
+					Type:        "object",
+					Required:    false,
+					Description: "JSON body to send; string values may be templates",
+					Example:     map[string]any{"employee": "{{.employee.EmployeeNumber}}"},
+				},
+			},
+		},
+		{
+			Type:        "audit_log",
+			Name:        "Write Audit Log",
+			Description: "Record an audit log entry",
+			Parameters: []Parameter{
+				{
+					Name:        "action",
+					Type:        "string",
+					Required:    true,
+					Description: "Short name of what happened",
+					Example:     "competency_expired",
+				},
+				{
+					Name:        "details",
+					Type:        "text_area",
+					Required:    false,
+					Description: "Free-text details of the entry",
+					Example:     "{{.competency.CompetencyName}} expired",
+				},
+				{
+					Name:        "employeeNumber",
+					Type:        "string",
+					Required:    false,
+					Description: "Employee the entry is about (defaults to the employee in the trigger data)",
+					Example:     "EMP001",
+				},
+			},
+		},
+	}
+}
+
+// Action returns the metadata of the action type, if it is described.
+func Action(actionType string) (ActionMetadata, bool) {
+	for _, a := range GetActionMetadata() {
+		if a.Type == actionType {
+			return a, true
+		}
 	}
+	return ActionMetadata{}, false
 }
@@ -1,27 +1,46 @@
 package metadata
 
+// Trigger types facts are supplied for; relative_time facts are listed under
+// the entity_type they come from.
+const (
+    trJobPos      = "job_position"
+    trCompType    = "competency_type"
+    trCompetency  = "competency"
+    trEventDef    = "event_definition"
+    trSchedEvent  = "scheduled_event"
+    trRoles       = "roles"
+    trLinkJobComp = "link_job_to_competency"
+    trCompPrereq  = "competency_prerequisite"
+    // added entity types supported by relative_time
+    trEmployee           = "employee"
+    trEmployeeCompetency = "employee_competency"
+    trEmploymentHistory  = "employment_history"
+    trEventAttendance    = "event_attendance"
+    trJobMatrix          = "job_matrix"
+    trUser               = "user"
+)
+
+// entityEventTriggers are the triggers raised by entity changes, which carry
+// an operation in their payload.
+var entityEventTriggers = []string{trJobPos, trCompType, trCompetency, trEventDef, trSchedEvent, trRoles, trLinkJobComp, trCompPrereq}
+
+// Operator sets shared by facts of the same type.
+var (
+    strOps  = []string{"equals", "notEquals", "contains", "notContains", "startsWith", "endsWith", "matches", "in", "notIn", "isEmpty", "isNotEmpty"}
+    numOps  = []string{"equals", "notEquals", "greaterThan", "lessThan", "greaterThanEqual", "lessThanEqual", "between", "notBetween", "in", "notIn"}
+    boolOps = []string{"isTrue", "isFalse"}
+    listOps = []string{"contains", "notContains", "isEmpty", "isNotEmpty"}
+    dateOps = []string{"before", "after", "equals", "between", "withinNextDays", "withinLastDays", "olderThanDays", "sameDayAs", "isNull", "isNotNull"}
+)
+
 // GetFactMetadata returns metadata for all available facts in conditions
 func GetFactMetadata() []FactMetadata {
-    const (
-        trJobPos      = "job_position"
-        trCompType    = "competency_type"
-        trCompetency  = "competency"
-        trEventDef    = "event_definition"
-        trSchedEvent  = "scheduled_event"
-        trRoles       = "roles"
-        trLinkJobComp = "link_job_to_competency"
-        trCompPrereq  = "competency_prerequisite"
-        // added entity types supported by relative_time
-        trEmployee           = "employee"
-        trEmployeeCompetency = "employee_competency"
-        trEmploymentHistory  = "employment_history"
-    )
-
-    strOps := []string{"equals", "notEquals", "contains"}
-    numOps := []string{"equals", "notEquals", "greaterThan", "lessThan", "greaterThanEqual", "lessThanEqual"}
-    boolOps := []string{"isTrue", "isFalse"}
-    dateOps := []string{"before", "after", "equals"}
+    return append(PayloadFactMetadata(), DerivedFactMetadata()...)
+}
 
+// PayloadFactMetadata describes the facts read from trigger payloads,
+// including the dates computed from them (UnifiedFacts).
+func PayloadFactMetadata() []FactMetadata {
     return []FactMetadata{
         //competency facts
         {
@@ -142,21 +161,21 @@ func GetFactMetadata() []FactMetadata {
 
         // Event definition facts
         {
-            Name:        "eventDef.EventName",
+            Name:        "eventDefinition.EventName",
             Type:        "string",
             Description: "Event definition name",
             Operators:   strOps,
             Triggers:    []string{trEventDef},
         },
         {
-            Name:        "eventDef.Facilitator",
+            Name:        "eventDefinition.Facilitator",
             Type:        "string",
             Description: "Default facilitator for the event definition",
             Operators:   strOps,
             Triggers:    []string{trEventDef},
         },
         {
-            Name:        "eventDef.GrantsCertificateID",
+            Name:        "eventDefinition.GrantsCertificateID",
             Type:        "number",
             Description: "Certificate ID granted on completion (if applicable)",
             Operators:   numOps,
@@ -370,5 +389,243 @@ func GetFactMetadata() []FactMetadata {
             Operators:   strOps,
             Triggers:    []string{trEmploymentHistory},
         },
+
+        // Event attendance, job matrix and user account facts (relative_time entities)
+        {
+            Name:        "eventAttendance.EmployeeNumber",
+            Type:        "string",
+            Description: "Employee number of the attendee",
+            Operators:   strOps,
+            Triggers:    []string{trEventAttendance},
+        },
+        {
+            Name:        "eventAttendance.CustomEventScheduleID",
+            Type:        "number",
+            Description: "Scheduled event ID",
+            Operators:   numOps,
+            Triggers:    []string{trEventAttendance},
+        },
+        {
+            Name:        "eventAttendance.Attended",
+            Type:        "boolean",
+            Description: "Whether the employee attended",
+            Operators:   boolOps,
+            Triggers:    []string{trEventAttendance},
+        },
+        {
+            Name:        "eventAttendance.CheckInTime",
+            Type:        "date",
+            Description: "Check-in time",
+            Operators:   dateOps,
+            Triggers:    []string{trEventAttendance},
+        },
+        {
+            Name:        "eventAttendance.CheckOutTime",
+            Type:        "date",
+            Description: "Check-out time (nullable)",
+            Operators:   dateOps,
+            Triggers:    []string{trEventAttendance},
+        },
+        {
+            Name:        "eventAttendance.EventTitle",
+            Type:        "string",
+            Description: "Title of the scheduled event",
+            Operators:   strOps,
+            Triggers:    []string{trEventAttendance},
+        },
+        {
+            Name:        "jobMatrix.CustomMatrixID",
+            Type:        "number",
+            Description: "Job matrix entry ID",
+            Operators:   numOps,
+            Triggers:    []string{trJobMatrix},
+        },
+        {
+            Name:        "jobMatrix.PositionMatrixCode",
+            Type:        "string",
+            Description: "Position code",
+            Operators:   strOps,
+            Triggers:    []string{trJobMatrix},
+        },
+        {
+            Name:        "jobMatrix.CompetencyID",
+            Type:        "number",
+            Description: "Required competency ID",
+            Operators:   numOps,
+            Triggers:    []string{trJobMatrix},
+        },
+        {
+            Name:        "jobMatrix.RequirementStatus",
+            Type:        "string",
+            Description: "Requirement status (Required, Optional)",
+            Operators:   strOps,
+            Triggers:    []string{trJobMatrix},
+            Options:     []any{"Required", "Optional"},
+        },
+        {
+            Name:        "jobMatrix.JobTitle",
+            Type:        "string",
+            Description: "Title of the job position",
+            Operators:   strOps,
+            Triggers:    []string{trJobMatrix},
+        },
+        {
+            Name:        "jobMatrix.CreationDate",
+            Type:        "date",
+            Description: "When the requirement was added",
+            Operators:   dateOps,
+            Triggers:    []string{trJobMatrix},
+        },
+        {
+            Name:        "user.Username",
+            Type:        "string",
+            Description: "Account username",
+            Operators:   strOps,
+            Triggers:    []string{trUser},
+        },
+        {
+            Name:        "user.Role",
+            Type:        "string",
+            Description: "Account role",
+            Operators:   strOps,
+            Triggers:    []string{trUser},
+        },
+        {
+            Name:        "user.EmployeeNumber",
+            Type:        "string",
+            Description: "Employee number linked to the account",
+            Operators:   strOps,
+            Triggers:    []string{trUser},
+        },
+        {
+            Name:        "user.EmployeeEmail",
+            Type:        "string",
+            Description: "Email of the linked employee",
+            Operators:   strOps,
+            Triggers:    []string{trUser},
+        },
+        {
+            Name:        "user.CreatedAt",
+            Type:        "date",
+            Description: "When the account was created",
+            Operators:   dateOps,
+            Triggers:    []string{trUser},
+        },
+
+        // Computed from dates in the trigger payload, relative to the evaluation time
+        {
+            Name:        "employeeCompetency.DaysUntilExpiry",
+            Type:        "number",
+            Description: "Days until the competency expires (negative once expired, empty if it never expires)",
+            Operators:   numOps,
+            Triggers:    []string{trEmployeeCompetency},
+        },
+        {
+            Name:        "employeeCompetency.DaysSinceAchieved",
+            Type:        "number",
+            Description: "Days since the competency was achieved",
+            Operators:   numOps,
+            Triggers:    []string{trEmployeeCompetency},
+        },
+        {
+            Name:        "competency.DaysUntilExpiry",
+            Type:        "number",
+            Description: "Days until the employee's competency expires (same as employeeCompetency.DaysUntilExpiry)",
+            Operators:   numOps,
+            Triggers:    []string{trEmployeeCompetency},
+        },
+        {
+            Name:        "competency.DaysSinceAchieved",
+            Type:        "number",
+            Description: "Days since the employee's competency was achieved (same as employeeCompetency.DaysSinceAchieved)",
+            Operators:   numOps,
+            Triggers:    []string{trEmployeeCompetency},
+        },
+        {
+            Name:        "employmentHistory.TenureDays",
+            Type:        "number",
+            Description: "Days since the employment record's start date",
+            Operators:   numOps,
+            Triggers:    []string{trEmploymentHistory},
+        },
+        {
+            Name:        "scheduledEvent.HoursUntilStart",
+            Type:        "number",
+            Description: "Whole hours until the event starts (negative once started)",
+            Operators:   numOps,
+            Triggers:    []string{trSchedEvent},
+        },
+
+        // Read from the trigger payload of entity events
+        {
+            Name:        "event.Operation",
+            Type:        "string",
+            Description: "Operation that raised the event, e.g. create or update",
+            Operators:   strOps,
+            Triggers:    entityEventTriggers,
+        },
+        {
+            Name:        "event.UpdateKind",
+            Type:        "string",
+            Description: "Kind of role update, when supplied",
+            Operators:   strOps,
+            Triggers:    []string{trRoles},
+        },
+        {
+            Name:        "event.Action",
+            Type:        "string",
+            Description: "Action named in the trigger payload, when supplied",
+            Operators:   strOps,
+            Triggers:    entityEventTriggers,
+        },
+    }
+}
+
+// DerivedFactMetadata describes the facts looked up in the database at
+// evaluation time (DbFacts).
+func DerivedFactMetadata() []FactMetadata {
+    return []FactMetadata{
+        {
+            Name:        "employee.HasCompetency[competencyID]",
+            Type:        "boolean",
+            Description: "Employee holds the competency (replace competencyID with its ID) and it has not expired",
+            Operators:   boolOps,
+            Triggers:    []string{trEmployee, trEmployeeCompetency, trEmploymentHistory},
+        },
+        {
+            Name:        "employee.CurrentPositions",
+            Type:        "list",
+            Description: "Position codes the employee currently holds",
+            Operators:   listOps,
+            Triggers:    []string{trEmployee, trEmployeeCompetency, trEmploymentHistory},
+        },
+        {
+            Name:        "employee.OutstandingRequiredCount",
+            Type:        "number",
+            Description: "Required competencies for the employee's current positions that they do not hold",
+            Operators:   numOps,
+            Triggers:    []string{trEmployee, trEmployeeCompetency, trEmploymentHistory},
+        },
+        {
+            Name:        "employee.TenureDays",
+            Type:        "number",
+            Description: "Days since the employee's earliest employment start date",
+            Operators:   numOps,
+            Triggers:    []string{trEmployee, trEmployeeCompetency, trEmploymentHistory},
+        },
+        {
+            Name:        "scheduledEvent.BookedCount",
+            Type:        "number",
+            Description: "Number of employees booked on the scheduled event",
+            Operators:   numOps,
+            Triggers:    []string{trSchedEvent},
+        },
+        {
+            Name:        "competency.PrerequisiteIDs",
+            Type:        "list",
+            Description: "IDs of the competency's prerequisite competencies",
+            Operators:   listOps,
+            Triggers:    []string{trCompetency, trLinkJobComp, trCompPrereq},
+        },
     }
-}
\ No newline at end of file
+}
@@ -0,0 +1,106 @@
+package metadata
+
+// GetFunctionMetadata returns the helpers available in action parameter templates.
+// Date helpers are evaluated against the rule's evaluation time, not the wall clock.
+func GetFunctionMetadata() []FunctionMetadata {
+    return []FunctionMetadata{
+        {
+            Name:        "now",
+            Signature:   "now",
+            Description: "Evaluation time of the rule",
+            Example:     `{{ now | formatDate "long" }}`,
+            Category:    "date",
+        },
+        {
+            Name:        "formatDate",
+            Signature:   "formatDate layout date",
+            Description: "Formats a date. Layout is iso, long, short, datetime, time, rfc3339 or a Go layout; empty dates render as an empty string",
+            Example:     `{{ .employeeCompetency.expiry_date | formatDate "long" }} → 12 March 2026`,
+            Category:    "date",
+        },
+        {
+            Name:        "addDays",
+            Signature:   "addDays n date",
+            Description: "Adds n days (negative to subtract)",
+            Example:     `{{ now | addDays 7 | formatDate "iso" }}`,
+            Category:    "date",
+        },
+        {
+            Name:        "addMonths",
+            Signature:   "addMonths n date",
+            Description: "Adds n calendar months",
+            Example:     `{{ .employmentHistory.start_date | addMonths 3 | formatDate "long" }}`,
+            Category:    "date",
+        },
+        {
+            Name:        "daysUntil",
+            Signature:   "daysUntil date",
+            Description: "Whole calendar days from the evaluation date to date (negative if past)",
+            Example:     `{{ daysUntil .employeeCompetency.expiry_date }}`,
+            Category:    "date",
+        },
+        {
+            Name:        "daysSince",
+            Signature:   "daysSince date",
+            Description: "Whole calendar days from date to the evaluation date",
+            Example:     `{{ daysSince .employmentHistory.start_date }}`,
+            Category:    "date",
+        },
+        {
+            Name:        "upper",
+            Signature:   "upper text",
+            Description: "Upper-cases text",
+            Example:     `{{ upper .employee.Lastname }}`,
+            Category:    "string",
+        },
+        {
+            Name:        "lower",
+            Signature:   "lower text",
+            Description: "Lower-cases text",
+            Example:     `{{ lower .employee.Useraccountemail }}`,
+            Category:    "string",
+        },
+        {
+            Name:        "title",
+            Signature:   "title text",
+            Description: "Capitalises the first letter of each word",
+            Example:     `{{ title .jobPosition.JobTitle }}`,
+            Category:    "string",
+        },
+        {
+            Name:        "trim",
+            Signature:   "trim text",
+            Description: "Removes leading and trailing whitespace",
+            Example:     `{{ trim .competency.CompetencyName }}`,
+            Category:    "string",
+        },
+        {
+            Name:        "default",
+            Signature:   "default fallback value",
+            Description: "Returns fallback when value is missing, empty or zero",
+            Example:     `{{ .employee.PhoneNumber | default "n/a" }}`,
+            Category:    "value",
+        },
+        {
+            Name:        "pluralize",
+            Signature:   "pluralize count singular plural",
+            Description: "Chooses the singular or plural word for count",
+            Example:     `{{ $d := daysUntil .employeeCompetency.expiry_date }}{{ $d }} {{ pluralize $d "day" "days" }}`,
+            Category:    "value",
+        },
+        {
+            Name:        "formatNumber",
+            Signature:   "formatNumber decimals number",
+            Description: "Formats a number with thousands separators and fixed decimals",
+            Example:     `{{ 1250 | formatNumber 2 }} → 1,250.00`,
+            Category:    "value",
+        },
+        {
+            Name:        "join",
+            Signature:   "join separator list",
+            Description: "Joins the items of a list",
+            Example:     `{{ join ", " .names }}`,
+            Category:    "value",
+        },
+    }
+}
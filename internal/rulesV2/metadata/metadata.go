@@ -1,11 +1,12 @@
 package metadata
 
-// GetRulesMetadata returns metadata about all available triggers, actions, facts, and operators
+// GetRulesMetadata returns metadata about all available triggers, actions, facts, operators and template functions
 func GetRulesMetadata() RulesMetadata {
     return RulesMetadata{
         Triggers:  GetTriggerMetadata(),
         Actions:   GetActionMetadata(),
         Facts:     GetFactMetadata(),
         Operators: GetOperatorMetadata(),
+        Functions: GetFunctionMetadata(),
     }
 }
\ No newline at end of file
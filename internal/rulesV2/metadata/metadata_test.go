@@ -15,6 +15,7 @@ func TestGetRulesMetadata(t *testing.T) {
     assert.NotEmpty(t, metadata.Actions)
     assert.NotEmpty(t, metadata.Facts)
     assert.NotEmpty(t, metadata.Operators)
+    assert.NotEmpty(t, metadata.Functions)
 
     // Check expected triggers
     triggerTypes := make(map[string]bool)
@@ -317,7 +318,7 @@ func TestScheduledTimeTriggerMetadata(t *testing.T) {
             assert.Len(t, freq.Options, 6)
         }
         if assert.NotNil(t, tz, "timezone parameter required") {
-            assert.Equal(t, "string", tz.Type)
+            assert.Equal(t, "timezone", tz.Type)
             assert.False(t, tz.Required)
             // Range -12..14 inclusive -> 27 options, including UTC+0
             assert.GreaterOrEqual(t, len(tz.Options), 27)
@@ -328,6 +329,7 @@ func TestScheduledTimeTriggerMetadata(t *testing.T) {
             assert.True(t, seen["UTC+0"])
             assert.True(t, seen["UTC+2"])
             assert.True(t, seen["UTC-5"])
+            assert.True(t, seen["Africa/Johannesburg"])
         }
     }
 }
@@ -371,6 +373,9 @@ func TestRelativeTimeTriggerMetadata(t *testing.T) {
             assert.True(t, opts["employee"])
             assert.True(t, opts["employee_competency"])
             assert.True(t, opts["employment_history"])
+            assert.True(t, opts["event_attendance"])
+            assert.True(t, opts["job_matrix"])
+            assert.True(t, opts["user"])
         }
         if assert.NotNil(t, dateField, "date_field parameter required") {
             assert.Equal(t, "string", dateField.Type)
@@ -384,6 +389,9 @@ func TestRelativeTimeTriggerMetadata(t *testing.T) {
             assert.True(t, opts["expiry_date"])
             assert.True(t, opts["termination_date"])
             assert.True(t, opts["start_date"])
+            assert.Equal(t, "entity_type", dateField.DependsOn)
+            assert.Equal(t, []any{"start_date", "end_date"}, dateField.OptionsBy["employment_history"])
+            assert.Equal(t, []any{"check_in_time"}, dateField.OptionsBy["event_attendance"])
         }
         if assert.NotNil(t, dir, "offset_direction parameter required") {
             assert.Equal(t, "string", dir.Type)
@@ -409,6 +417,7 @@ func TestRelativeTimeTriggerMetadata(t *testing.T) {
             assert.True(t, opts["minutes"])
             assert.True(t, opts["hours"])
             assert.True(t, opts["days"])
+            assert.True(t, opts["business_days"])
             assert.True(t, opts["weeks"])
             assert.True(t, opts["months"])
         }
@@ -69,5 +69,113 @@ func GetOperatorMetadata() []OperatorMetadata {
             Description: "Date is after the specified date",
             Types:       []string{"date"},
         },
+        {
+            Name:        "greaterThanOrEqual",
+            Symbol:      ">=",
+            Description: "Left value is greater than or equal to right value",
+            Types:       []string{"number", "date"},
+        },
+        {
+            Name:        "lessThanOrEqual",
+            Symbol:      "<=",
+            Description: "Left value is less than or equal to right value",
+            Types:       []string{"number", "date"},
+        },
+        {
+            Name:        "between",
+            Symbol:      "between",
+            Description: "Value is within an inclusive [min, max] range",
+            Types:       []string{"number", "date", "string"},
+        },
+        {
+            Name:        "notBetween",
+            Symbol:      "not between",
+            Description: "Value is outside an inclusive [min, max] range",
+            Types:       []string{"number", "date", "string"},
+        },
+        {
+            Name:        "in",
+            Symbol:      "in",
+            Description: "Value is one of the listed values",
+            Types:       []string{"string", "number"},
+        },
+        {
+            Name:        "notIn",
+            Symbol:      "not in",
+            Description: "Value is none of the listed values",
+            Types:       []string{"string", "number"},
+        },
+        {
+            Name:        "notContains",
+            Symbol:      "not contains",
+            Description: "String does not contain substring",
+            Types:       []string{"string"},
+        },
+        {
+            Name:        "startsWith",
+            Symbol:      "starts with",
+            Description: "String starts with the given prefix",
+            Types:       []string{"string"},
+        },
+        {
+            Name:        "endsWith",
+            Symbol:      "ends with",
+            Description: "String ends with the given suffix",
+            Types:       []string{"string"},
+        },
+        {
+            Name:        "matches",
+            Symbol:      "matches",
+            Description: "String matches the regular expression",
+            Types:       []string{"string"},
+        },
+        {
+            Name:        "isEmpty",
+            Symbol:      "is empty",
+            Description: "Value is missing, blank or an empty list",
+            Types:       []string{"string", "list"},
+        },
+        {
+            Name:        "isNotEmpty",
+            Symbol:      "is not empty",
+            Description: "Value is present and not blank or an empty list",
+            Types:       []string{"string", "list"},
+        },
+        {
+            Name:        "isNull",
+            Symbol:      "is null",
+            Description: "Value is null",
+            Types:       []string{"string", "number", "boolean", "date"},
+        },
+        {
+            Name:        "isNotNull",
+            Symbol:      "is not null",
+            Description: "Value is not null",
+            Types:       []string{"string", "number", "boolean", "date"},
+        },
+        {
+            Name:        "withinNextDays",
+            Symbol:      "within next N days",
+            Description: "Date falls between now and N days from now",
+            Types:       []string{"date"},
+        },
+        {
+            Name:        "withinLastDays",
+            Symbol:      "within last N days",
+            Description: "Date falls between N days ago and now",
+            Types:       []string{"date"},
+        },
+        {
+            Name:        "olderThanDays",
+            Symbol:      "older than N days",
+            Description: "Date is more than N days in the past",
+            Types:       []string{"date"},
+        },
+        {
+            Name:        "sameDayAs",
+            Symbol:      "same day as",
+            Description: "Date is on the same calendar day as the value (a date, today, tomorrow or yesterday)",
+            Types:       []string{"date"},
+        },
     }
 }
\ No newline at end of file
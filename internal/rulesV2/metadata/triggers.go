@@ -1,6 +1,10 @@
 package metadata
 
-import "fmt"
+import (
+    "fmt"
+
+    rsched "Automated-Scheduling-Project/internal/rulesV2/scheduler"
+)
 
 // GetTriggerMetadata returns metadata for all available triggers
 func GetTriggerMetadata() []TriggerMetadata {
@@ -209,28 +213,20 @@ func GetTriggerMetadata() []TriggerMetadata {
                 },
                 {
                     Name:        "timezone",
-                    Type:        "string",
+                    Type:        "timezone",
                     Required:    false,
-                    Description: "UTC offset for local-time evaluation (e.g., 'UTC+2', 'UTC-5').",
-                    Options: func() []any {
-                        vals := []any{}
-                        for i := -12; i <= 14; i++ {
-                            if i == 0 {
-                                vals = append(vals, "UTC+0")
-                                continue
-                            }
-                            sign := "+"
-                            v := i
-                            if i < 0 {
-                                sign = "-"
-                                v = -i
-                            }
-                            vals = append(vals, fmt.Sprintf("UTC%s%d", sign, v))
-                        }
-                        return vals
-                    }(),
+                    Description: "Zone for local-time evaluation: an IANA name (e.g., 'Africa/Johannesburg', follows DST) or a fixed UTC offset (e.g., 'UTC+2').",
+                    Options:     timezoneOptions(),
                     Example:     "UTC+2",
                 },
+                {
+                    Name:        "misfire_policy",
+                    Type:        "string",
+                    Required:    false,
+                    Description: "What to do on startup with fires missed while the scheduler was down (default fire_once)",
+                    Options:     []any{"skip", "fire_once", "fire_all"},
+                    Example:     "fire_once",
+                },
             },
         },
         {
@@ -243,7 +239,7 @@ func GetTriggerMetadata() []TriggerMetadata {
                     Type:        "string",
                     Required:    true,
                     Description: "Entity that contains the date field",
-                    Options:     []any{"scheduled_event", "employee_competency", "employee", "employment_history"},
+                    Options:     relativeEntityOptions(),
                     Example:     "scheduled_event",
                 },
                 {
@@ -251,8 +247,10 @@ func GetTriggerMetadata() []TriggerMetadata {
                     Type:        "string",
                     Required:    true,
                     Description: "Date/time field on the selected entity",
-                    // Union of supported fields; UI will filter based on entity_type.
-                    Options:     []any{"event_start_date", "event_end_date", "expiry_date", "termination_date", "start_date"},
+                    // Union of supported fields; OptionsBy narrows them per entity_type.
+                    Options:     relativeDateFieldOptions(),
+                    DependsOn:   "entity_type",
+                    OptionsBy:   relativeDateFieldsByEntity(),
                     Example:     "event_start_date",
                 },
                 {
@@ -275,10 +273,118 @@ func GetTriggerMetadata() []TriggerMetadata {
                     Type:        "string",
                     Required:    true,
                     Description: "Unit of the offset",
-                    Options:     []any{"minutes", "hours", "days", "weeks", "months"},
+                    Options:     []any{"minutes", "hours", "days", "business_days", "weeks", "months", "years"},
                     Example:     "days",
                 },
+                {
+                    Name:        "holiday_region",
+                    Type:        "string",
+                    Required:    false,
+                    Description: "Holiday calendar region for business_days offsets; weekends are always skipped",
+                    Example:     "ZA",
+                },
+                {
+                    Name:        "timezone",
+                    Type:        "timezone",
+                    Required:    false,
+                    Description: "Zone the offset is counted in; days and larger step the local calendar (default UTC)",
+                    Options:     timezoneOptions(),
+                    Example:     "Africa/Johannesburg",
+                },
+                {
+                    Name:        "time_of_day",
+                    Type:        "time", // "HH:MM" 24h
+                    Required:    false,
+                    Description: "Local time to fire at when the offset is in days or larger, format 'HH:MM'",
+                    Example:     "09:00",
+                },
+                {
+                    Name:        "misfire_policy",
+                    Type:        "string",
+                    Required:    false,
+                    Description: "What to do on startup with reminders that came due while the scheduler was down (default fire_once)",
+                    Options:     []any{"skip", "fire_once", "fire_all"},
+                    Example:     "fire_once",
+                },
             },
         },
     }
-}
\ No newline at end of file
+}
+// Trigger returns the metadata of the trigger type, if it is described.
+func Trigger(triggerType string) (TriggerMetadata, bool) {
+    for _, t := range GetTriggerMetadata() {
+        if t.Type == triggerType {
+            return t, true
+        }
+    }
+    return TriggerMetadata{}, false
+}
+
+// commonTimezones are the IANA zones offered in the timezone dropdown; any
+// other zone known to tzdata is accepted as well.
+var commonTimezones = []string{
+    "Africa/Johannesburg", "Africa/Cairo", "Africa/Lagos", "Africa/Nairobi",
+    "Europe/London", "Europe/Berlin", "Europe/Paris", "Europe/Moscow",
+    "America/New_York", "America/Chicago", "America/Denver", "America/Los_Angeles", "America/Sao_Paulo",
+    "Asia/Dubai", "Asia/Kolkata", "Asia/Singapore", "Asia/Shanghai", "Asia/Tokyo",
+    "Australia/Sydney", "Pacific/Auckland",
+}
+
+// timezoneOptions lists UTC+0, the fixed offsets UTC-12..UTC+14 and then
+// the common IANA zones.
+func timezoneOptions() []any {
+    vals := []any{"UTC+0"}
+    for i := -12; i <= 14; i++ {
+        if i == 0 {
+            continue
+        }
+        sign := "+"
+        v := i
+        if i < 0 {
+            sign = "-"
+            v = -i
+        }
+        vals = append(vals, fmt.Sprintf("UTC%s%d", sign, v))
+    }
+    for _, tz := range commonTimezones {
+        vals = append(vals, tz)
+    }
+    return vals
+}
+
+// relativeEntityOptions lists the entity types registered with the scheduler.
+func relativeEntityOptions() []any {
+    vals := []any{}
+    for _, e := range rsched.Entities() {
+        vals = append(vals, e.Type)
+    }
+    return vals
+}
+
+// relativeDateFieldOptions is the union of the registered entities' date
+// fields, without duplicates.
+func relativeDateFieldOptions() []any {
+    vals := []any{}
+    seen := map[string]bool{}
+    for _, e := range rsched.Entities() {
+        for _, f := range e.DateFields {
+            if !seen[f.Name] {
+                seen[f.Name] = true
+                vals = append(vals, f.Name)
+            }
+        }
+    }
+    return vals
+}
+
+// relativeDateFieldsByEntity maps each registered entity type to its date
+// fields.
+func relativeDateFieldsByEntity() map[string][]any {
+    out := map[string][]any{}
+    for _, e := range rsched.Entities() {
+        for _, f := range e.DateFields {
+            out[e.Type] = append(out[e.Type], f.Name)
+        }
+    }
+    return out
+}
@@ -3,13 +3,18 @@ package metadata
 // Parameter represents a parameter definition for triggers and actions
 type Parameter struct {
     Name        string `json:"name"`
-    Type        string `json:"type"` // "string", "text_area", "employees", "event_type", "job_positions", "number", "boolean", "date", "array", "object"
+    Type        string `json:"type"` // "string", "text_area", "employees", "event_type", "job_positions", "number", "boolean", "date", "time", "timezone", "array", "object"
     Required    bool   `json:"required"`
     Description string `json:"description"`
     Example     any    `json:"example,omitempty"`
     // Options is an optional fixed set of allowed values.
-    // Frontend can render a dropdown if present.
+    // Frontend can render a dropdown if present. For "timezone"
+    // parameters the options are suggestions only.
     Options []any `json:"options,omitempty"`
+    // DependsOn names another parameter whose value narrows Options;
+    // OptionsBy maps each of its values to the allowed subset.
+    DependsOn string           `json:"dependsOn,omitempty"`
+    OptionsBy map[string][]any `json:"optionsBy,omitempty"`
 }
 
 // TriggerMetadata represents metadata about a trigger type
@@ -37,6 +42,8 @@ type FactMetadata struct {
     // Triggers indicates which triggers supply this fact in their context.
     // A fact may be available for multiple triggers.
     Triggers []string `json:"triggers,omitempty"`
+    // Options is an optional fixed set of values the fact can take.
+    Options []any `json:"options,omitempty"`
 }
 
 // OperatorMetadata represents metadata about available operators
@@ -47,10 +54,20 @@ type OperatorMetadata struct {
     Types       []string `json:"types"` // Compatible data types
 }
 
+// FunctionMetadata describes a helper usable inside action parameter templates
+type FunctionMetadata struct {
+    Name        string `json:"name"`
+    Signature   string `json:"signature"`
+    Description string `json:"description"`
+    Example     string `json:"example"`
+    Category    string `json:"category"` // date, string, value
+}
+
 // RulesMetadata contains all metadata for the rules engine
 type RulesMetadata struct {
     Triggers  []TriggerMetadata  `json:"triggers"`
     Actions   []ActionMetadata   `json:"actions"`
     Facts     []FactMetadata     `json:"facts"`
     Operators []OperatorMetadata `json:"operators"`
+    Functions []FunctionMetadata `json:"functions"`
 }
\ No newline at end of file
@@ -0,0 +1,398 @@
+package rulesv2
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RelativeOperatorFunc is an operator that also needs the evaluation time,
+// e.g. "date is within the next N days". Registered separately from
+// OperatorFunc so existing operators keep their two-argument signature.
+type RelativeOperatorFunc func(now time.Time, lhs any, rhs any) (bool, error)
+
+// ValueValidator checks an operator's right-hand value when a rule is
+// validated, so problems such as a bad regex are reported before saving.
+type ValueValidator func(rhs any) error
+
+// operatorValueValidators maps operator name to its rule-time value check.
+var operatorValueValidators = map[string]ValueValidator{
+	"between":        validateRange,
+	"notBetween":     validateRange,
+	"matches":        validateRegex,
+	"in":             validateList,
+	"notIn":          validateList,
+	"withinNextDays": validateDays,
+	"withinLastDays": validateDays,
+	"olderThanDays":  validateDays,
+}
+
+// registerExtendedOperators adds the range, string, emptiness and date
+// operators on top of the original compact set.
+func (r *Registry) registerExtendedOperators() {
+	r.UseOperator("notIn", opNotIn)
+	r.UseOperator("notContains", opNotContains)
+	r.UseOperator("between", opBetween)
+	r.UseOperator("notBetween", opNotBetween)
+	r.UseOperator("startsWith", opStartsWith)
+	r.UseOperator("endsWith", opEndsWith)
+	r.UseOperator("matches", opMatches)
+	r.UseOperator("isEmpty", opIsEmpty)
+	r.UseOperator("isNotEmpty", func(lhs, rhs any) (bool, error) {
+		ok, err := opIsEmpty(lhs, rhs)
+		return !ok, err
+	})
+
+	// Date comparisons that accept date strings on either side. The metadata
+	// has always advertised these names.
+	r.UseOperator("before", opBefore)
+	r.UseOperator("after", opAfter)
+	// Aliases used by the fact/operator metadata.
+	r.UseOperator("greaterThanEqual", opGreaterThanOrEqual)
+	r.UseOperator("lessThanEqual", opLessThanOrEqual)
+
+	r.UseRelativeOperator("withinNextDays", opWithinNextDays)
+	r.UseRelativeOperator("withinLastDays", opWithinLastDays)
+	r.UseRelativeOperator("olderThanDays", opOlderThanDays)
+	r.UseRelativeOperator("sameDayAs", opSameDayAs)
+}
+
+// hasOperator reports whether name is registered as either kind of operator.
+func (r *Registry) hasOperator(name string) bool {
+	if _, ok := r.Operators[name]; ok {
+		return true
+	}
+	_, ok := r.RelativeOperators[name]
+	return ok
+}
+
+// validateOperatorValue runs the rule-time check for op, if it has one.
+func validateOperatorValue(op string, rhs any) error {
+	if v, ok := operatorValueValidators[op]; ok {
+		return v(rhs)
+	}
+	return nil
+}
+
+/* ------------------------------ Validators ------------------------------- */
+
+func validateRange(rhs any) error {
+	_, _, err := rangeBounds(rhs)
+	return err
+}
+
+func validateList(rhs any) error {
+	rv := reflect.ValueOf(rhs)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return errors.New("value must be a list")
+	}
+	return nil
+}
+
+func validateRegex(rhs any) error {
+	_, err := compileRegex(rhs)
+	return err
+}
+
+func validateDays(rhs any) error {
+	_, err := daysArg(rhs)
+	return err
+}
+
+/* ------------------------------ Operators -------------------------------- */
+
+func opNotIn(lhs, rhs any) (bool, error) {
+	ok, err := opIn(lhs, rhs)
+	return !ok && err == nil, err
+}
+
+func opNotContains(lhs, rhs any) (bool, error) {
+	ok, err := opContains(lhs, rhs)
+	return !ok && err == nil, err
+}
+
+// between: rhs is [min, max], inclusive. Works for numbers, dates and strings.
+// A missing lhs, or one that is not a date when the bounds are, is not in
+// the range.
+func opBetween(lhs, rhs any) (bool, error) {
+	if isNilish(lhs) {
+		return false, nil
+	}
+	lo, hi, err := rangeBounds(rhs)
+	if err != nil {
+		return false, fmt.Errorf("between: %w", err)
+	}
+	if !comparableToBounds(lhs, lo, hi) {
+		return false, nil
+	}
+	c1, err := compareLoose(lhs, lo)
+	if err != nil {
+		return false, fmt.Errorf("between: %w", err)
+	}
+	c2, err := compareLoose(lhs, hi)
+	if err != nil {
+		return false, fmt.Errorf("between: %w", err)
+	}
+	return c1 >= 0 && c2 <= 0, nil
+}
+
+// notBetween: lhs is outside [min, max]. Like between, a missing or
+// unreadable lhs does not match.
+func opNotBetween(lhs, rhs any) (bool, error) {
+	if isNilish(lhs) {
+		return false, nil
+	}
+	lo, hi, err := rangeBounds(rhs)
+	if err != nil {
+		return false, fmt.Errorf("notBetween: %w", err)
+	}
+	if !comparableToBounds(lhs, lo, hi) {
+		return false, nil
+	}
+	ok, err := opBetween(lhs, rhs)
+	return !ok && err == nil, err
+}
+
+// comparableToBounds reports whether lhs can be read as a date when both
+// bounds are dates; a string such as "n/a" would otherwise be compared
+// with date strings as text.
+func comparableToBounds(lhs, lo, hi any) bool {
+	if _, ok := lo.(string); !ok {
+		return true
+	}
+	if _, _, ok := timePair(lo, hi); !ok {
+		return true
+	}
+	_, ok := looseTime(lhs)
+	return ok
+}
+
+func rangeBounds(rhs any) (any, any, error) {
+	rv := reflect.ValueOf(rhs)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Len() != 2 {
+		return nil, nil, errors.New("value must be a [min, max] pair")
+	}
+	return rv.Index(0).Interface(), rv.Index(1).Interface(), nil
+}
+
+func opStartsWith(lhs, rhs any) (bool, error) {
+	l, r, ok, err := stringPair("startsWith", lhs, rhs)
+	if !ok {
+		return false, err
+	}
+	return strings.HasPrefix(l, r), nil
+}
+
+func opEndsWith(lhs, rhs any) (bool, error) {
+	l, r, ok, err := stringPair("endsWith", lhs, rhs)
+	if !ok {
+		return false, err
+	}
+	return strings.HasSuffix(l, r), nil
+}
+
+func opMatches(lhs, rhs any) (bool, error) {
+	re, err := compileRegex(rhs)
+	if err != nil {
+		return false, fmt.Errorf("matches: %w", err)
+	}
+	l, ok := lhs.(string)
+	if !ok {
+		if isNilish(lhs) {
+			return false, nil
+		}
+		l = fmt.Sprint(lhs)
+	}
+	return re.MatchString(l), nil
+}
+
+// isEmpty: nil, blank string, or zero-length slice/map.
+func opIsEmpty(lhs, _ any) (bool, error) {
+	if isNilish(lhs) {
+		return true, nil
+	}
+	if s, ok := lhs.(string); ok {
+		return strings.TrimSpace(s) == "", nil
+	}
+	rv := reflect.ValueOf(lhs)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() == 0, nil
+	}
+	return false, nil
+}
+
+func opBefore(lhs, rhs any) (bool, error) {
+	lt, rt, ok := timePair(lhs, rhs)
+	if !ok {
+		return false, nil
+	}
+	return lt.Before(rt), nil
+}
+
+func opAfter(lhs, rhs any) (bool, error) {
+	lt, rt, ok := timePair(lhs, rhs)
+	if !ok {
+		return false, nil
+	}
+	return lt.After(rt), nil
+}
+
+// withinNextDays: lhs falls on today or one of the next N calendar days,
+// in now's location.
+func opWithinNextDays(now time.Time, lhs, rhs any) (bool, error) {
+	t, n, ok, err := dateAndDays("withinNextDays", lhs, rhs)
+	if !ok {
+		return false, err
+	}
+	day, today := calendarDate(t, now.Location()), calendarDate(now, now.Location())
+	return !day.Before(today) && !day.After(today.AddDate(0, 0, n)), nil
+}
+
+// withinLastDays: lhs falls on today or one of the last N calendar days,
+// in now's location.
+func opWithinLastDays(now time.Time, lhs, rhs any) (bool, error) {
+	t, n, ok, err := dateAndDays("withinLastDays", lhs, rhs)
+	if !ok {
+		return false, err
+	}
+	day, today := calendarDate(t, now.Location()), calendarDate(now, now.Location())
+	return !day.After(today) && !day.Before(today.AddDate(0, 0, -n)), nil
+}
+
+// olderThanDays: lhs < now - N days.
+func opOlderThanDays(now time.Time, lhs, rhs any) (bool, error) {
+	t, n, ok, err := dateAndDays("olderThanDays", lhs, rhs)
+	if !ok {
+		return false, err
+	}
+	return t.Before(now.AddDate(0, 0, -n)), nil
+}
+
+// sameDayAs: lhs falls on the same calendar date as rhs. rhs may be a date
+// or one of "today", "tomorrow", "yesterday" (relative to now).
+func opSameDayAs(now time.Time, lhs, rhs any) (bool, error) {
+	lt, ok := looseTime(lhs)
+	if !ok {
+		return false, nil
+	}
+	var rt time.Time
+	switch s, _ := rhs.(string); strings.ToLower(strings.TrimSpace(s)) {
+	case "today":
+		rt = now
+	case "tomorrow":
+		rt = now.AddDate(0, 0, 1)
+	case "yesterday":
+		rt = now.AddDate(0, 0, -1)
+	default:
+		if rt, ok = looseTime(rhs); !ok {
+			return false, fmt.Errorf("sameDayAs: cannot use %v (%T) as a date", rhs, rhs)
+		}
+	}
+	ly, lm, ld := lt.In(now.Location()).Date()
+	ry, rm, rd := rt.In(now.Location()).Date()
+	return ly == ry && lm == rm && ld == rd, nil
+}
+
+/* ------------------------------- Helpers --------------------------------- */
+
+var regexCache sync.Map // pattern -> *regexp.Regexp
+
+func compileRegex(rhs any) (*regexp.Regexp, error) {
+	pat, ok := rhs.(string)
+	if !ok {
+		return nil, fmt.Errorf("pattern must be a string, got %T", rhs)
+	}
+	if re, ok := regexCache.Load(pat); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pat)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	regexCache.Store(pat, re)
+	return re, nil
+}
+
+func stringPair(op string, lhs, rhs any) (string, string, bool, error) {
+	if isNilish(lhs) {
+		return "", "", false, nil
+	}
+	l, ok := lhs.(string)
+	if !ok {
+		return "", "", false, fmt.Errorf("%s: lhs must be string, got %T", op, lhs)
+	}
+	r, ok := rhs.(string)
+	if !ok {
+		return "", "", false, fmt.Errorf("%s: rhs must be string, got %T", op, rhs)
+	}
+	return l, r, true, nil
+}
+
+// looseTime accepts time.Time, *time.Time and the string/number shapes of asTime.
+func looseTime(v any) (time.Time, bool) {
+	if isNilish(v) {
+		return time.Time{}, false
+	}
+	if p, ok := v.(*time.Time); ok {
+		return *p, true
+	}
+	return asTime(v)
+}
+
+// calendarDate is midnight of t's date in loc.
+func calendarDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+func timePair(lhs, rhs any) (time.Time, time.Time, bool) {
+	lt, ok1 := looseTime(lhs)
+	rt, ok2 := looseTime(rhs)
+	return lt, rt, ok1 && ok2
+}
+
+// compareLoose is tryCompare with date strings coerced when the other side is a time.
+func compareLoose(lhs, rhs any) (int, error) {
+	if c, ok := tryCompare(lhs, rhs); ok {
+		return c, nil
+	}
+	if lt, rt, ok := timePair(lhs, rhs); ok {
+		return compareTimes(lt, rt), nil
+	}
+	return 0, fmt.Errorf("cannot compare values of types %T and %T", lhs, rhs)
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func daysArg(rhs any) (int, error) {
+	f, ok := asFloat(rhs)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return 0, fmt.Errorf("value must be a non-negative whole number of days, got %v", rhs)
+	}
+	return int(f), nil
+}
+
+func dateAndDays(op string, lhs, rhs any) (time.Time, int, bool, error) {
+	n, err := daysArg(rhs)
+	if err != nil {
+		return time.Time{}, 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	t, ok := looseTime(lhs)
+	if !ok {
+		return time.Time{}, 0, false, nil
+	}
+	return t, n, true, nil
+}
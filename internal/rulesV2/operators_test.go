@@ -0,0 +1,188 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"testing"
+	"time"
+
+	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperators_Extended(t *testing.T) {
+	reg := NewRegistryWithDefaults()
+	d := func(s string) time.Time { tt, _ := time.Parse("2006-01-02", s); return tt }
+
+	cases := []struct {
+		op       string
+		lhs, rhs any
+		want     bool
+	}{
+		{"between", 5, []any{1, 10}, true},
+		{"between", 10, []any{1, 10}, true},
+		{"between", 11, []any{1, 10}, false},
+		{"between", d("2025-03-01"), []any{"2025-01-01", "2025-12-31"}, true},
+		{"between", nil, []any{1, 10}, false},
+		{"notBetween", 11, []any{1, 10}, true},
+		{"notBetween", nil, []any{1, 10}, false},
+		{"notBetween", "n/a", []any{"2025-01-01", "2025-12-31"}, false},
+		{"between", "n/a", []any{"2025-01-01", "2025-12-31"}, false},
+		{"notBetween", "2026-02-01", []any{"2025-01-01", "2025-12-31"}, true},
+		{"notIn", "D", []any{"A", "B"}, true},
+		{"notIn", "A", []any{"A", "B"}, false},
+		{"notContains", "hello", "xyz", true},
+		{"startsWith", "EMP-001", "EMP-", true},
+		{"startsWith", "X-001", "EMP-", false},
+		{"endsWith", "ann@example.com", "@example.com", true},
+		{"matches", "EMP-001", `^EMP-\d{3}$`, true},
+		{"matches", "EMP-01", `^EMP-\d{3}$`, false},
+		{"isEmpty", "  ", nil, true},
+		{"isEmpty", []any{}, nil, true},
+		{"isEmpty", nil, nil, true},
+		{"isEmpty", "x", nil, false},
+		{"isNotEmpty", []string{"a"}, nil, true},
+		{"before", "2025-01-01", d("2025-02-01"), true},
+		{"after", d("2025-02-01"), "2025-01-01", true},
+		{"greaterThanEqual", 3, 3, true},
+		{"lessThanEqual", 4, 3, false},
+	}
+	for _, tc := range cases {
+		op, ok := reg.Operators[tc.op]
+		require.True(t, ok, "operator %s not registered", tc.op)
+		got, err := op(tc.lhs, tc.rhs)
+		require.NoError(t, err, "%s(%v, %v)", tc.op, tc.lhs, tc.rhs)
+		assert.Equal(t, tc.want, got, "%s(%v, %v)", tc.op, tc.lhs, tc.rhs)
+	}
+
+	_, err := reg.Operators["matches"]("x", "([")
+	assert.Error(t, err)
+	_, err = reg.Operators["between"](1, []any{1})
+	assert.Error(t, err)
+}
+
+func TestOperators_Relative(t *testing.T) {
+	reg := NewRegistryWithDefaults()
+	now := fixedNow() // 2025-01-01
+
+	cases := []struct {
+		op       string
+		lhs, rhs any
+		want     bool
+	}{
+		{"withinNextDays", "2025-01-20", 30, true},
+		{"withinNextDays", "2025-03-01", 30, false},
+		{"withinNextDays", "2024-12-31", 30, false},
+		{"withinLastDays", "2024-12-20", float64(14), true},
+		{"withinLastDays", "2024-11-01", 14, false},
+		// Whole calendar days in now's location, whatever the time of day.
+		{"withinNextDays", now.Add(-time.Hour + 24*time.Hour), 0, true},
+		{"withinNextDays", now.AddDate(0, 0, 30).Add(23 * time.Hour), 30, true},
+		{"withinNextDays", now.Add(-time.Minute), 30, false},
+		{"withinLastDays", now.AddDate(0, 0, -14), 14, true},
+		{"withinLastDays", now.Add(23 * time.Hour), 0, true},
+		{"olderThanDays", "2024-01-01", 90, true},
+		{"olderThanDays", "2024-12-01", 90, false},
+		{"sameDayAs", "2025-01-01", "today", true},
+		{"sameDayAs", now.Add(30 * time.Hour), "tomorrow", true},
+		{"sameDayAs", "2024-12-31", "yesterday", true},
+		{"sameDayAs", "2025-01-05", "2025-01-05", true},
+		{"sameDayAs", nil, "today", false},
+	}
+	for _, tc := range cases {
+		op, ok := reg.RelativeOperators[tc.op]
+		require.True(t, ok, "operator %s not registered", tc.op)
+		got, err := op(now, tc.lhs, tc.rhs)
+		require.NoError(t, err, "%s(%v, %v)", tc.op, tc.lhs, tc.rhs)
+		assert.Equal(t, tc.want, got, "%s(%v, %v)", tc.op, tc.lhs, tc.rhs)
+	}
+
+	_, err := reg.RelativeOperators["withinNextDays"](now, "2025-01-02", -1)
+	assert.Error(t, err)
+
+	// Calendar dates are taken in now's location: 22:30 UTC is earlier
+	// today at UTC+2, so still within the next 0 days.
+	local := time.Date(2025, 1, 1, 1, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	got, err := reg.RelativeOperators["withinNextDays"](local, time.Date(2024, 12, 31, 22, 30, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	assert.True(t, got)
+}
+
+func TestEngine_RelativeOperatorUsesEvalNow(t *testing.T) {
+	ca := &capturingAction{}
+	eng := newTestEngine(map[string]ActionHandler{"STUB": ca})
+	rule := Rulev2{
+		Name:    "expiring soon",
+		Trigger: TriggerSpec{Type: "ANY"},
+		Conditions: []Condition{
+			{Fact: "employeeCompetency.expiry_date", Operator: "withinNextDays", Value: 30},
+			{Fact: "employee.Email", Operator: "endsWith", Value: "@example.com"},
+		},
+		Actions: []ActionSpec{{Type: "STUB"}},
+	}
+	data := map[string]any{
+		"employeeCompetency": map[string]any{"expiry_date": "2025-01-15"},
+		"employee":           map[string]any{"Email": "ann@example.com"},
+	}
+
+	require.NoError(t, eng.EvaluateOnce(EvalContext{Now: fixedNow(), Data: data}, rule))
+	assert.Len(t, ca.Calls, 1)
+
+	// Same data a year later is no longer "within the next 30 days".
+	require.NoError(t, eng.EvaluateOnce(EvalContext{Now: fixedNow().AddDate(1, 0, 0), Data: data}, rule))
+	assert.Len(t, ca.Calls, 1)
+}
+
+func TestValidateRule_OperatorValues(t *testing.T) {
+	reg := NewRegistryWithDefaults().UseAction("STUB", &capturingAction{})
+	base := Rulev2{Name: "v", Trigger: TriggerSpec{Type: "T"}, Actions: []ActionSpec{{Type: "STUB"}}}
+
+	bad := map[string]Condition{
+		"bad regex":     {Fact: "a", Operator: "matches", Value: "(["},
+		"bad range":     {Fact: "a", Operator: "between", Value: []any{1}},
+		"bad list":      {Fact: "a", Operator: "notIn", Value: "x"},
+		"negative days": {Fact: "a", Operator: "withinNextDays", Value: -3},
+	}
+	for name, c := range bad {
+		r := base
+		r.Conditions = []Condition{c}
+		assert.Error(t, ValidateRule(reg, r), name)
+	}
+
+	r := base
+	r.Conditions = []Condition{{Fact: "a", Operator: "sameDayAs", Value: "today"}}
+	assert.NoError(t, ValidateRule(reg, r))
+
+	res := ValidateRuleParameters(Rulev2{
+		Name:       "bad regex",
+		Trigger:    TriggerSpec{Type: "scheduled_event"},
+		Conditions: []Condition{{All: []Condition{{Fact: "employee.Email", Operator: "matches", Value: "(["}}}},
+	})
+	require.False(t, res.Valid)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "conditions[0].all[0].value", res.Errors[0].Parameter)
+	assert.Contains(t, res.Errors[0].Message, "invalid regular expression")
+}
+
+// Every operator the rule builder offers must be evaluable, and vice versa.
+func TestOperatorMetadata_MatchesRegistry(t *testing.T) {
+	reg := NewRegistryWithDefaults()
+	described := map[string]bool{}
+	for _, m := range meta.GetOperatorMetadata() {
+		described[m.Name] = true
+		assert.True(t, reg.hasOperator(m.Name), "metadata operator %q is not registered", m.Name)
+	}
+	for name := range reg.Operators {
+		assert.True(t, described[name], "operator %q has no metadata", name)
+	}
+	for name := range reg.RelativeOperators {
+		assert.True(t, described[name], "operator %q has no metadata", name)
+	}
+	for _, f := range meta.GetFactMetadata() {
+		for _, op := range f.Operators {
+			assert.True(t, reg.hasOperator(op), "fact %q lists unknown operator %q", f.Name, op)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+
+	"Automated-Scheduling-Project/internal/database/models"
+)
+
+// ruleExecutionOrder is the SQL ordering for rules sharing a trigger type:
+// highest priority first, then oldest first.
+const ruleExecutionOrder = "priority DESC, id ASC"
+
+// sortByPriority applies the same ordering to rules from any RuleStore. The
+// sort is stable, so stores that already return creation order keep it for ties.
+func sortByPriority(rs []Rulev2) {
+	sort.SliceStable(rs, func(i, j int) bool { return rs[i].Priority > rs[j].Priority })
+}
+
+// dispatchGate tracks which of the remaining rules for one event may still run.
+type dispatchGate struct {
+	stoppedBy string            // rule that set stopProcessing
+	claimed   map[string]string // exclusive group -> rule that fired
+}
+
+// skip reports why r must not run, or "" if it may.
+func (g *dispatchGate) skip(r Rulev2) string {
+	if g.stoppedBy != "" {
+		return "stopped by rule " + g.stoppedBy
+	}
+	if r.ExclusiveGroup != "" {
+		if by, ok := g.claimed[r.ExclusiveGroup]; ok {
+			return "exclusive group " + r.ExclusiveGroup + " already handled by rule " + by
+		}
+	}
+	return ""
+}
+
+// matched records that r fired.
+func (g *dispatchGate) matched(r Rulev2) {
+	if r.ExclusiveGroup != "" {
+		if g.claimed == nil {
+			g.claimed = map[string]string{}
+		}
+		g.claimed[r.ExclusiveGroup] = r.Name
+	}
+	if r.StopProcessing {
+		g.stoppedBy = r.Name
+	}
+}
+
+// RuleOrderEntry is one rule in the effective execution order of a trigger type.
+type RuleOrderEntry struct {
+	ID             uint   `json:"id"`
+	Name           string `json:"name"`
+	Priority       int    `json:"priority"`
+	StopProcessing bool   `json:"stopProcessing,omitempty"`
+	ExclusiveGroup string `json:"exclusiveGroup,omitempty"`
+}
+
+// ExecutionOrder returns, per trigger type, the enabled rules in the order an
+// event dispatch evaluates them.
+func (s *DbRuleStore) ExecutionOrder(ctx context.Context) (map[string][]RuleOrderEntry, error) {
+	var rows []models.Rule
+	if err := s.DB.WithContext(ctx).
+		Where("enabled = ?", true).
+		Order("trigger_type ASC, " + ruleExecutionOrder).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := map[string][]RuleOrderEntry{}
+	for _, r := range rows {
+		var spec Rulev2
+		if err := json.Unmarshal(r.Spec, &spec); err != nil {
+			log.Printf("Failed to unmarshal rule id=%d: %v", r.ID, err)
+			continue
+		}
+		out[r.TriggerType] = append(out[r.TriggerType], RuleOrderEntry{
+			ID:             r.ID,
+			Name:           r.Name,
+			Priority:       r.Priority,
+			StopProcessing: spec.StopProcessing,
+			ExclusiveGroup: spec.ExclusiveGroup,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,65 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatchEvent_PriorityStopAndExclusiveGroups(t *testing.T) {
+	stub := &capturingAction{}
+	eng := newTestEngine(map[string]ActionHandler{"STUB": stub})
+
+	rule := func(name string, priority int, cond []Condition) Rulev2 {
+		return Rulev2{
+			Name:       name,
+			Trigger:    TriggerSpec{Type: "EV"},
+			Conditions: cond,
+			Priority:   priority,
+			Actions:    []ActionSpec{{Type: "STUB", Parameters: map[string]any{"id": name}}},
+		}
+	}
+	completed := []Condition{{Fact: "eventSchedule.StatusName", Operator: "equals", Value: "Completed"}}
+	cancelled := []Condition{{Fact: "eventSchedule.StatusName", Operator: "equals", Value: "Cancelled"}}
+
+	fallback := rule("generic", 0, nil)
+	fallback.ExclusiveGroup = "notify"
+	specific := rule("specific", 10, completed)
+	specific.ExclusiveGroup = "notify"
+	unmatched := rule("cancelled-only", 20, cancelled)
+	unmatched.ExclusiveGroup = "notify"
+	audit := rule("audit", 5, nil)
+	late := rule("late", -1, nil)
+
+	fired := func(rs ...Rulev2) []any {
+		stub.Calls = nil
+		store := memStore{ByTrig: map[string][]Rulev2{"EV": rs}}
+		data := map[string]any{"eventSchedule": map[string]any{"StatusName": "Completed"}}
+		require.NoError(t, DispatchEvent(context.Background(), eng, store, "EV", data))
+		ids := []any{}
+		for _, c := range stub.Calls {
+			ids = append(ids, c["id"])
+		}
+		return ids
+	}
+
+	// Priority order regardless of store order; a non-matching group member
+	// does not claim the group, the generic fallback is suppressed.
+	assert.Equal(t, []any{"specific", "audit", "late"}, fired(late, fallback, audit, specific, unmatched))
+
+	// Fallback fires when nothing more specific matched.
+	specific.Conditions = cancelled
+	assert.Equal(t, []any{"audit", "generic", "late"}, fired(late, fallback, audit, specific))
+
+	// stopProcessing ends the dispatch after a match.
+	audit.StopProcessing = true
+	assert.Equal(t, []any{"audit"}, fired(late, fallback, audit, specific))
+
+	// Equal priorities keep store order.
+	a, b := rule("a", 1, nil), rule("b", 1, nil)
+	assert.Equal(t, []any{"a", "b"}, fired(a, b))
+}
@@ -0,0 +1,743 @@
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Job statuses stored on models.RuleJob.
+const (
+	JobStatusWaiting   = "waiting" // chained behind an earlier action of the same rule
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+	JobStatusDiscarded = "discarded"
+)
+
+// QueuedAction is a rendered action handed from the engine to the queue.
+type QueuedAction struct {
+	RuleID      string
+	RuleName    string
+	TriggerType string
+	ActionType  string
+	Parameters  map[string]any
+	Data        map[string]any
+	Now         time.Time
+	Chain       []Cause
+	MaxAttempts int
+	// ContinueOnError lets the actions after this one in the chain run even
+	// if it fails for good.
+	ContinueOnError bool
+}
+
+// ActionEnqueuer accepts a rule's actions for asynchronous execution, to run
+// one after another in order, and returns their job ids.
+type ActionEnqueuer interface {
+	EnqueueChain(ctx context.Context, actions []QueuedAction) ([]uint, error)
+}
+
+// queuedStep is an action rendered for the queue, with its place in results.
+type queuedStep struct {
+	index  int
+	spec   ActionSpec
+	params map[string]any
+}
+
+// enqueueActions renders the rule's actions and hands them to e.Queue as one
+// chain, so they keep their order and ContinueActionsOnError still applies.
+// ok is false when nothing was enqueued and the actions should run inline.
+func (e *Engine) enqueueActions(evCtx EvalContext, r Rulev2) (results []ActionResult, ok bool, err error) {
+	var agg MultiError
+	var steps []queuedStep
+	for _, a := range r.Actions {
+		res := ActionResult{Type: a.Type}
+		params, perr := e.prepareAction(evCtx, a)
+		if perr != nil {
+			res.Error = perr.Error()
+			results = append(results, res)
+			agg.Append(perr)
+			if !e.ContinueActionsOnError {
+				break
+			}
+			continue
+		}
+		res.Parameters = params
+		steps = append(steps, queuedStep{index: len(results), spec: a, params: params})
+		results = append(results, res)
+	}
+	if len(steps) == 0 {
+		return results, true, agg.Err()
+	}
+
+	actions := make([]QueuedAction, len(steps))
+	for i, st := range steps {
+		actions[i] = QueuedAction{
+			RuleID:          r.ID,
+			RuleName:        r.Name,
+			TriggerType:     r.Trigger.Type,
+			ActionType:      st.spec.Type,
+			Parameters:      st.params,
+			Data:            evCtx.Data,
+			Now:             evCtx.Now,
+			Chain:           evCtx.Chain,
+			MaxAttempts:     st.spec.MaxAttempts,
+			ContinueOnError: e.ContinueActionsOnError,
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ids, qerr := e.Queue.EnqueueChain(ctx, actions)
+	if qerr != nil {
+		log.Printf("rules: enqueue actions for rule %q failed, running inline: %v", r.Name, qerr)
+		return nil, false, nil
+	}
+	for i, st := range steps {
+		// The run is settled as the job finishes; see settleRun.
+		res := &results[st.index]
+		res.Queued, res.JobID, res.JobStatus = true, ids[i], JobStatusPending
+		if i > 0 {
+			res.JobStatus = JobStatusWaiting
+		}
+	}
+	return results, true, agg.Err()
+}
+
+// ActionQueue is a DB-backed job queue for rule actions (table rule_jobs).
+// Workers poll for due jobs, claim them with a conditional UPDATE so several
+// processes can share the table, and retry failures with exponential backoff
+// until the job's MaxAttempts is exhausted, at which point it is marked dead.
+// While the kill switch or a maintenance window covering a job's trigger is
+// on, the job stays pending.
+type ActionQueue struct {
+	DB       *gorm.DB
+	Registry *Registry
+	Limiter  *DbLimiter // kill switch and maintenance; optional
+
+	Workers            int           // default 4
+	PollInterval       time.Duration // default 2s
+	BaseBackoff        time.Duration // default 30s, doubled per attempt
+	MaxBackoff         time.Duration // default 1h
+	DefaultMaxAttempts int           // default 5
+	LockTimeout        time.Duration // running jobs whose lock is not renewed for this long are re-queued; default 10m
+
+	workerID string
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewActionQueue builds a queue with default settings.
+func NewActionQueue(db *gorm.DB, reg *Registry) *ActionQueue {
+	host, _ := os.Hostname()
+	return &ActionQueue{
+		DB:                 db,
+		Registry:           reg,
+		Workers:            4,
+		PollInterval:       2 * time.Second,
+		BaseBackoff:        30 * time.Second,
+		MaxBackoff:         time.Hour,
+		DefaultMaxAttempts: 5,
+		LockTimeout:        10 * time.Minute,
+		workerID:           fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}
+}
+
+// Enqueue stores a pending job due immediately.
+func (q *ActionQueue) Enqueue(ctx context.Context, a QueuedAction) (uint, error) {
+	ids, err := q.EnqueueChain(ctx, []QueuedAction{a})
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// EnqueueChain stores actions as one chain of jobs: the first is due
+// immediately and each later one waits for the job before it.
+func (q *ActionQueue) EnqueueChain(ctx context.Context, actions []QueuedAction) ([]uint, error) {
+	jobs := make([]models.RuleJob, len(actions))
+	for i, a := range actions {
+		job, err := q.newJob(a)
+		if err != nil {
+			return nil, err
+		}
+		jobs[i] = job
+	}
+	ids := make([]uint, 0, len(jobs))
+	err := q.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range jobs {
+			if i > 0 {
+				jobs[i].Status = JobStatusWaiting
+				jobs[i].AfterJobID = jobs[i-1].ID
+			}
+			if err := tx.Create(&jobs[i]).Error; err != nil {
+				return err
+			}
+			ids = append(ids, jobs[i].ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// newJob builds the pending job row for a, due immediately.
+func (q *ActionQueue) newJob(a QueuedAction) (models.RuleJob, error) {
+	params, err := json.Marshal(a.Parameters)
+	if err != nil {
+		return models.RuleJob{}, fmt.Errorf("marshal parameters: %w", err)
+	}
+	data, err := json.Marshal(a.Data)
+	if err != nil {
+		return models.RuleJob{}, fmt.Errorf("marshal context data: %w", err)
+	}
+	maxAttempts := a.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.DefaultMaxAttempts
+	}
+	var causation datatypes.JSON
+	if len(a.Chain) > 0 {
+		b, err := json.Marshal(a.Chain)
+		if err != nil {
+			return models.RuleJob{}, fmt.Errorf("marshal causation: %w", err)
+		}
+		causation = datatypes.JSON(b)
+	}
+	ruleID, _ := strconv.ParseUint(a.RuleID, 10, 64)
+	return models.RuleJob{
+		RuleID:          uint(ruleID),
+		RuleName:        a.RuleName,
+		TriggerType:     a.TriggerType,
+		ActionType:      a.ActionType,
+		Parameters:      datatypes.JSON(params),
+		Data:            datatypes.JSON(data),
+		EvalNow:         a.Now,
+		Causation:       causation,
+		ContinueOnError: a.ContinueOnError,
+		Status:          JobStatusPending,
+		MaxAttempts:     maxAttempts,
+		NextAttemptAt:   time.Now().UTC(),
+	}, nil
+}
+
+// Start launches the worker goroutines. Calling Start twice is a no-op.
+func (q *ActionQueue) Start(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.cancel != nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	q.cancel = cancel
+
+	n := q.Workers
+	if n <= 0 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		q.wg.Add(1)
+		go q.worker(ctx, fmt.Sprintf("%s/%d", q.workerID, i))
+	}
+	log.Printf("rules: action queue started with %d worker(s)", n)
+	return nil
+}
+
+// Stop signals the workers and waits for in-flight jobs (or ctx) to finish.
+func (q *ActionQueue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	cancel := q.cancel
+	q.cancel = nil
+	q.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() { q.wg.Wait(); close(done) }()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *ActionQueue) worker(ctx context.Context, id string) {
+	defer q.wg.Done()
+	t := time.NewTicker(q.PollInterval)
+	defer t.Stop()
+	for {
+		// Drain everything due before sleeping again.
+		for ctx.Err() == nil {
+			processed, err := q.RunOnce(ctx, id)
+			if err != nil {
+				log.Printf("rules: queue worker %s: %v", id, err)
+				break
+			}
+			if !processed {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce claims and processes at most one due job. It reports whether a job
+// was processed. Exposed so tests and tools can drive the queue synchronously.
+func (q *ActionQueue) RunOnce(ctx context.Context, workerID string) (bool, error) {
+	if err := q.requeueStale(ctx); err != nil {
+		return false, err
+	}
+	job, err := q.claim(ctx, workerID)
+	if err != nil || job == nil {
+		return false, err
+	}
+	q.process(ctx, job)
+	return true, nil
+}
+
+// WaitIdle blocks until no job of actionType evaluated at or before `before`
+// is waiting to run or running, or until ctx is done. Jobs backing off after a
+// failure are not waited for. Any worker sharing the table may run the jobs.
+func (q *ActionQueue) WaitIdle(ctx context.Context, actionType string, before time.Time) error {
+	t := time.NewTicker(min(q.PollInterval, 250*time.Millisecond))
+	defer t.Stop()
+	for {
+		db, all := q.runnable(ctx)
+		if all {
+			return nil // nothing runs until the kill switch or maintenance ends
+		}
+		var n int64
+		err := db.Model(&models.RuleJob{}).
+			Where("action_type = ? AND eval_now <= ?", actionType, before).
+			Where("status = ? OR (status = ? AND next_attempt_at <= ?)", JobStatusRunning, JobStatusPending, time.Now().UTC()).
+			Count(&n).Error
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// requeueStale returns jobs whose worker died mid-flight to pending. A live
+// worker renews its lock while the action runs (see heartbeat), so a slow
+// action is not picked up a second time.
+func (q *ActionQueue) requeueStale(ctx context.Context) error {
+	cutoff := time.Now().UTC().Add(-q.LockTimeout)
+	return q.DB.WithContext(ctx).Model(&models.RuleJob{}).
+		Where("status = ? AND locked_at < ?", JobStatusRunning, cutoff).
+		Updates(map[string]any{"status": JobStatusPending, "locked_by": "", "locked_at": nil}).Error
+}
+
+// runnable scopes a rule_jobs query to jobs the kill switch and maintenance
+// let run now. all is true when they hold back every job. A limiter error
+// is logged and holds nothing back, as in Engine.allow.
+func (q *ActionQueue) runnable(ctx context.Context) (db *gorm.DB, all bool) {
+	db = q.DB.WithContext(ctx)
+	if q.Limiter == nil {
+		return db, false
+	}
+	all, types, err := q.Limiter.held(ctx)
+	if err != nil {
+		log.Printf("rules: queue: kill switch and maintenance check failed, running jobs anyway: %v", err)
+		return db, false
+	}
+	if len(types) > 0 {
+		db = db.Where("trigger_type NOT IN ?", types)
+	}
+	return db, all
+}
+
+// claim picks the oldest due job the kill switch and maintenance let run and
+// marks it running. The status check in the UPDATE makes the claim safe
+// against other workers racing for the row.
+func (q *ActionQueue) claim(ctx context.Context, workerID string) (*models.RuleJob, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		db, all := q.runnable(ctx)
+		if all {
+			return nil, nil
+		}
+		now := time.Now().UTC()
+		var job models.RuleJob
+		err := db.
+			Where("status = ? AND next_attempt_at <= ?", JobStatusPending, now).
+			Order("next_attempt_at ASC, id ASC").
+			First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		res := q.DB.WithContext(ctx).Model(&models.RuleJob{}).
+			Where("id = ? AND status = ?", job.ID, JobStatusPending).
+			Updates(map[string]any{
+				"status":    JobStatusRunning,
+				"locked_by": workerID,
+				"locked_at": now,
+				"attempts":  gorm.Expr("attempts + 1"),
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			job.Status = JobStatusRunning
+			job.LockedBy = workerID
+			job.Attempts++
+			return &job, nil
+		}
+		// Lost the race; try the next due job.
+	}
+	return nil, nil
+}
+
+func (q *ActionQueue) process(ctx context.Context, job *models.RuleJob) {
+	stop := q.heartbeat(ctx, job)
+	err := q.executeRecovered(job)
+	stop()
+	now := time.Now().UTC()
+	updates := map[string]any{"locked_by": "", "locked_at": nil}
+	switch {
+	case err == nil:
+		updates["status"] = JobStatusSucceeded
+		updates["last_error"] = ""
+		updates["finished_at"] = now
+	case job.Attempts >= job.MaxAttempts:
+		updates["status"] = JobStatusDead
+		updates["last_error"] = err.Error()
+		updates["finished_at"] = now
+		log.Printf("rules: job %d (%s, rule %q) dead after %d attempt(s): %v", job.ID, job.ActionType, job.RuleName, job.Attempts, err)
+	default:
+		updates["status"] = JobStatusPending
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = now.Add(q.backoff(job.Attempts))
+	}
+	// The action already ran: save its result even if Stop cancelled ctx,
+	// or requeueStale would run it again.
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	res := q.DB.WithContext(saveCtx).Model(&models.RuleJob{}).
+		Where("id = ? AND locked_by = ?", job.ID, job.LockedBy).
+		Updates(updates)
+	if res.Error != nil {
+		log.Printf("rules: job %d: failed to save result: %v", job.ID, res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		log.Printf("rules: job %d: lock lost while running, result dropped", job.ID)
+		return
+	}
+	switch updates["status"] {
+	case JobStatusSucceeded:
+		q.release(saveCtx, job.ID, true)
+	case JobStatusDead:
+		q.release(saveCtx, job.ID, job.ContinueOnError)
+	default:
+		return
+	}
+	q.settle(saveCtx, job.ID)
+}
+
+// heartbeat renews the job's lock every third of LockTimeout until the
+// returned stop is called. It keeps going after Stop cancels ctx, since the
+// action is still running.
+func (q *ActionQueue) heartbeat(ctx context.Context, job *models.RuleJob) (stop func()) {
+	every := q.LockTimeout / 3
+	if every <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				err := q.DB.WithContext(ctx).Model(&models.RuleJob{}).
+					Where("id = ? AND locked_by = ? AND status = ?", job.ID, job.LockedBy, JobStatusRunning).
+					Update("locked_at", time.Now().UTC()).Error
+				if err != nil && ctx.Err() == nil {
+					log.Printf("rules: job %d: failed to renew lock: %v", job.ID, err)
+				}
+			}
+		}
+	}()
+	return func() { cancel(); <-done }
+}
+
+// release lets the jobs chained after a finished job go ahead. When run is
+// false, the rest of the chain is discarded instead.
+func (q *ActionQueue) release(ctx context.Context, jobID uint, run bool) {
+	db := q.DB.WithContext(ctx)
+	if run {
+		err := db.Model(&models.RuleJob{}).
+			Where("after_job_id = ? AND status = ?", jobID, JobStatusWaiting).
+			Updates(map[string]any{"status": JobStatusPending, "next_attempt_at": time.Now().UTC()}).Error
+		if err != nil {
+			log.Printf("rules: job %d: failed to release next action: %v", jobID, err)
+		}
+		return
+	}
+	for ids := []uint{jobID}; len(ids) > 0; {
+		var next []uint
+		if err := db.Model(&models.RuleJob{}).Where("after_job_id IN ? AND status = ?", ids, JobStatusWaiting).Pluck("id", &next).Error; err != nil {
+			log.Printf("rules: job %d: failed to discard the rest of its chain: %v", jobID, err)
+			return
+		}
+		if len(next) == 0 {
+			return
+		}
+		err := db.Model(&models.RuleJob{}).Where("id IN ?", next).Updates(map[string]any{
+			"status":      JobStatusDiscarded,
+			"last_error":  "an earlier action of the rule failed",
+			"finished_at": time.Now().UTC(),
+		}).Error
+		if err != nil {
+			log.Printf("rules: job %d: failed to discard the rest of its chain: %v", jobID, err)
+			return
+		}
+		for _, id := range next {
+			q.settle(ctx, id)
+		}
+		ids = next
+	}
+}
+
+// settle updates the run that recorded the job, if it has been recorded
+// yet, with the job's outcome. Otherwise DbRunStore.RecordRun picks it up.
+func (q *ActionQueue) settle(ctx context.Context, jobID uint) {
+	var job models.RuleJob
+	if err := q.DB.WithContext(ctx).Select("id", "run_id").First(&job, jobID).Error; err != nil || job.RunID == 0 {
+		return
+	}
+	if err := settleRun(q.DB.WithContext(ctx), job.RunID); err != nil {
+		log.Printf("rules: job %d: failed to update run %d: %v", jobID, job.RunID, err)
+	}
+}
+
+// executeRecovered runs the job and turns a panicking action into a job
+// error, so it is retried or dead-lettered like any other failure instead of
+// taking the worker (and the API process) down.
+func (q *ActionQueue) executeRecovered(job *models.RuleJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("rules: job %d (%s, rule %q) panicked: %v\n%s", job.ID, job.ActionType, job.RuleName, r, debug.Stack())
+			err = fmt.Errorf("action panicked: %v", r)
+		}
+	}()
+	return q.execute(job)
+}
+
+func (q *ActionQueue) execute(job *models.RuleJob) error {
+	ah, ok := q.Registry.Actions[job.ActionType]
+	if !ok || ah == nil {
+		return fmt.Errorf("unknown action %q", job.ActionType)
+	}
+	var params map[string]any
+	if len(job.Parameters) > 0 {
+		if err := json.Unmarshal(job.Parameters, &params); err != nil {
+			return fmt.Errorf("decode parameters: %w", err)
+		}
+	}
+	var data map[string]any
+	if len(job.Data) > 0 {
+		if err := json.Unmarshal(job.Data, &data); err != nil {
+			return fmt.Errorf("decode context data: %w", err)
+		}
+	}
+	var chain []Cause
+	if len(job.Causation) > 0 {
+		if err := json.Unmarshal(job.Causation, &chain); err != nil {
+			return fmt.Errorf("decode causation: %w", err)
+		}
+	}
+	steps, err := q.loadSteps(job)
+	if err != nil {
+		return err
+	}
+	return ah.Execute(EvalContext{Now: job.EvalNow, Data: data, Chain: chain, steps: steps}, params)
+}
+
+// jobSteps records the parts of a queued action that have completed, e.g.
+// the recipients a notification reached, so a retry does not repeat them.
+type jobSteps struct {
+	db    *gorm.DB
+	jobID uint
+	mu    sync.Mutex
+	done  []string
+}
+
+func (q *ActionQueue) loadSteps(job *models.RuleJob) (*jobSteps, error) {
+	st := &jobSteps{db: q.DB, jobID: job.ID}
+	if len(job.CompletedSteps) > 0 {
+		if err := json.Unmarshal(job.CompletedSteps, &st.done); err != nil {
+			return nil, fmt.Errorf("decode completed steps: %w", err)
+		}
+	}
+	return st, nil
+}
+
+// Done reports whether an earlier attempt completed step. Always false
+// outside the queue.
+func (s *jobSteps) Done(step string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.done, step)
+}
+
+// Mark records step as completed. A failure to save is logged only: the
+// step already happened, and at worst a retry repeats it.
+func (s *jobSteps) Mark(step string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = append(s.done, step)
+	b, _ := json.Marshal(s.done)
+	if err := s.db.Model(&models.RuleJob{}).Where("id = ?", s.jobID).Update("completed_steps", datatypes.JSON(b)).Error; err != nil {
+		log.Printf("rules: job %d: failed to record completed step %q: %v", s.jobID, step, err)
+	}
+}
+
+// backoff returns BaseBackoff * 2^(attempts-1), capped at MaxBackoff.
+func (q *ActionQueue) backoff(attempts int) time.Duration {
+	d := q.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= q.MaxBackoff {
+			return q.MaxBackoff
+		}
+	}
+	return d
+}
+
+/* ------------------------------ Admin helpers ---------------------------- */
+
+// JobFilter narrows ListJobs. Zero values are ignored.
+type JobFilter struct {
+	Status     string
+	RuleID     uint
+	ActionType string
+	Limit      int
+	Offset     int
+}
+
+// ListJobs returns jobs newest first together with the total matching count.
+func (q *ActionQueue) ListJobs(ctx context.Context, f JobFilter) ([]models.RuleJob, int64, error) {
+	db := q.DB.WithContext(ctx).Model(&models.RuleJob{})
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	if f.RuleID != 0 {
+		db = db.Where("rule_id = ?", f.RuleID)
+	}
+	if f.ActionType != "" {
+		db = db.Where("action_type = ?", f.ActionType)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	limit := f.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	var jobs []models.RuleJob
+	if err := db.Order("id DESC").Limit(limit).Offset(f.Offset).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// GetJob returns a single job.
+func (q *ActionQueue) GetJob(ctx context.Context, id uint) (*models.RuleJob, error) {
+	var job models.RuleJob
+	if err := q.DB.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ErrJobState is returned when a retry/discard is not allowed for the job's status.
+var ErrJobState = errors.New("job is not in a state that allows this operation")
+
+// RetryJob puts a dead or discarded job back in the queue with a fresh attempt budget.
+func (q *ActionQueue) RetryJob(ctx context.Context, id uint) error {
+	return q.transition(ctx, id, []string{JobStatusDead, JobStatusDiscarded}, map[string]any{
+		"status":          JobStatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now().UTC(),
+		"finished_at":     nil,
+	})
+}
+
+// DiscardJob abandons a pending or dead job. The actions chained after it
+// go ahead only if it has ContinueOnError.
+func (q *ActionQueue) DiscardJob(ctx context.Context, id uint) error {
+	err := q.transition(ctx, id, []string{JobStatusPending, JobStatusDead}, map[string]any{
+		"status":      JobStatusDiscarded,
+		"finished_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	job, err := q.GetJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	q.release(ctx, id, job.ContinueOnError)
+	return nil
+}
+
+func (q *ActionQueue) transition(ctx context.Context, id uint, from []string, updates map[string]any) error {
+	if _, err := q.GetJob(ctx, id); err != nil {
+		return err
+	}
+	res := q.DB.WithContext(ctx).Model(&models.RuleJob{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrJobState
+	}
+	q.settle(ctx, id)
+	return nil
+}
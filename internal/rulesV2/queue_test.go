@@ -0,0 +1,330 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/gen_models"
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestQueue(t *testing.T, actions map[string]ActionHandler) *ActionQueue {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.RuleJob{}))
+
+	reg := NewRegistry()
+	for k, v := range actions {
+		reg.UseAction(k, v)
+	}
+	q := NewActionQueue(db, reg)
+	q.BaseBackoff = time.Millisecond
+	q.MaxBackoff = 4 * time.Millisecond
+	return q
+}
+
+func TestEngine_EnqueuesActionsWhenQueueSet(t *testing.T) {
+	stub := &capturingAction{}
+	eng := newTestEngine(map[string]ActionHandler{"STUB": stub})
+	q := newTestQueue(t, map[string]ActionHandler{"STUB": stub})
+	eng.Queue = q
+	rec := &memRecorder{}
+	eng.Recorder = rec
+
+	rule := Rulev2{
+		ID:      "3",
+		Name:    "queued",
+		Trigger: TriggerSpec{Type: "T"},
+		Actions: []ActionSpec{{Type: "STUB", MaxAttempts: 2, Parameters: map[string]any{"to": "{{.employee.Email}}"}}},
+	}
+	data := map[string]any{"employee": map[string]any{"Email": "a@b.c"}}
+	require.NoError(t, eng.EvaluateOnce(EvalContext{Now: fixedNow(), Data: data}, rule))
+
+	require.Empty(t, stub.Calls, "action must not run inline when queued")
+	require.True(t, rec.Runs[0].Actions[0].Queued)
+
+	job, err := q.GetJob(t.Context(), rec.Runs[0].Actions[0].JobID)
+	require.NoError(t, err)
+	require.Equal(t, JobStatusPending, job.Status)
+	require.Equal(t, 2, job.MaxAttempts)
+	require.EqualValues(t, 3, job.RuleID)
+
+	processed, err := q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	require.True(t, processed)
+	require.Len(t, stub.Calls, 1)
+	require.Equal(t, "a@b.c", stub.Calls[0]["to"])
+
+	job, _ = q.GetJob(t.Context(), job.ID)
+	require.Equal(t, JobStatusSucceeded, job.Status)
+	require.Equal(t, 1, job.Attempts)
+}
+
+func TestActionQueue_SettlesRecordedRun(t *testing.T) {
+	ok := &capturingAction{}
+	failing := &capturingAction{Err: errors.New("smtp down")}
+	actions := map[string]ActionHandler{"OK": ok, "MAIL": failing}
+	eng := newTestEngine(actions)
+	q := newTestQueue(t, actions)
+	require.NoError(t, q.DB.AutoMigrate(&models.RuleRun{}))
+	eng.Queue = q
+	eng.Recorder = &DbRunStore{DB: q.DB}
+	runs := func() models.RuleRun {
+		var run models.RuleRun
+		require.NoError(t, q.DB.First(&run).Error)
+		return run
+	}
+
+	rule := Rulev2{
+		ID:      "3",
+		Name:    "queued",
+		Trigger: TriggerSpec{Type: "T"},
+		Actions: []ActionSpec{{Type: "OK"}, {Type: "MAIL", MaxAttempts: 1}},
+	}
+	require.NoError(t, eng.EvaluateOnce(EvalContext{Now: fixedNow(), Data: map[string]any{}}, rule))
+	run := runs()
+	require.Equal(t, RunStatusQueued, run.Status, "enqueued actions have not run yet")
+	require.NotContains(t, string(run.Actions), `"ok":true`)
+
+	for i := 0; i < 2; i++ {
+		processed, err := q.RunOnce(t.Context(), "w")
+		require.NoError(t, err)
+		require.True(t, processed)
+	}
+	run = runs()
+	require.Equal(t, RunStatusPartial, run.Status)
+	require.Contains(t, run.Error, "smtp down")
+	var results []ActionResult
+	require.NoError(t, json.Unmarshal(run.Actions, &results))
+	require.True(t, results[0].OK)
+	require.Equal(t, JobStatusSucceeded, results[0].JobStatus)
+	require.False(t, results[1].OK)
+	require.Equal(t, JobStatusDead, results[1].JobStatus)
+
+	// An admin retry puts the run back to queued until the job settles again.
+	require.NoError(t, q.RetryJob(t.Context(), results[1].JobID))
+	require.Equal(t, RunStatusQueued, runs().Status)
+	failing.Err = nil
+	_, err := q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	run = runs()
+	require.Equal(t, RunStatusSuccess, run.Status)
+	require.Empty(t, run.Error)
+}
+
+func TestActionQueue_RetriesThenDeadLetters(t *testing.T) {
+	failing := &capturingAction{Err: errors.New("smtp down")}
+	q := newTestQueue(t, map[string]ActionHandler{"MAIL": failing})
+
+	id, err := q.Enqueue(t.Context(), QueuedAction{ActionType: "MAIL", MaxAttempts: 3})
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		// wait out the backoff of the previous attempt
+		time.Sleep(5 * time.Millisecond)
+		processed, err := q.RunOnce(t.Context(), "w")
+		require.NoError(t, err)
+		require.True(t, processed, "attempt %d", i)
+
+		job, _ := q.GetJob(t.Context(), id)
+		require.Equal(t, i, job.Attempts)
+		require.Equal(t, "smtp down", job.LastError)
+		if i < 3 {
+			require.Equal(t, JobStatusPending, job.Status)
+			require.True(t, job.NextAttemptAt.After(time.Now().UTC().Add(-time.Second)))
+		} else {
+			require.Equal(t, JobStatusDead, job.Status)
+			require.NotNil(t, job.FinishedAt)
+		}
+	}
+	require.Len(t, failing.Calls, 3)
+
+	processed, err := q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	require.False(t, processed, "dead jobs are not picked up")
+
+	require.NoError(t, q.RetryJob(t.Context(), id))
+	job, _ := q.GetJob(t.Context(), id)
+	require.Equal(t, JobStatusPending, job.Status)
+	require.Equal(t, 0, job.Attempts)
+	require.ErrorIs(t, q.RetryJob(t.Context(), id), ErrJobState)
+}
+
+func TestActionQueue_RecoversPanickingAction(t *testing.T) {
+	panicking := testActionFunc(func(EvalContext, map[string]any) error { panic("nil map") })
+	q := newTestQueue(t, map[string]ActionHandler{"BOOM": panicking})
+
+	id, err := q.Enqueue(t.Context(), QueuedAction{ActionType: "BOOM", MaxAttempts: 2})
+	require.NoError(t, err)
+
+	processed, err := q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	require.True(t, processed)
+	job, _ := q.GetJob(t.Context(), id)
+	require.Equal(t, JobStatusPending, job.Status, "a panic is retried like an error")
+	require.Equal(t, "action panicked: nil map", job.LastError)
+	require.Empty(t, job.LockedBy)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	job, _ = q.GetJob(t.Context(), id)
+	require.Equal(t, JobStatusDead, job.Status)
+}
+
+func TestActionQueue_Backoff(t *testing.T) {
+	q := &ActionQueue{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	require.Equal(t, time.Second, q.backoff(1))
+	require.Equal(t, 2*time.Second, q.backoff(2))
+	require.Equal(t, 4*time.Second, q.backoff(3))
+	require.Equal(t, 5*time.Second, q.backoff(4))
+}
+
+func TestActionQueue_RequeuesStaleRunningJobs(t *testing.T) {
+	stub := &capturingAction{}
+	q := newTestQueue(t, map[string]ActionHandler{"STUB": stub})
+	id, err := q.Enqueue(t.Context(), QueuedAction{ActionType: "STUB"})
+	require.NoError(t, err)
+
+	stale := time.Now().UTC().Add(-time.Hour)
+	require.NoError(t, q.DB.Model(&models.RuleJob{}).Where("id = ?", id).
+		Updates(map[string]any{"status": JobStatusRunning, "locked_at": stale, "locked_by": "crashed"}).Error)
+
+	processed, err := q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	require.True(t, processed)
+	require.Len(t, stub.Calls, 1)
+}
+
+func TestActionQueue_SavesResultAfterStop(t *testing.T) {
+	stub := &capturingAction{}
+	q := newTestQueue(t, map[string]ActionHandler{"STUB": stub})
+	id, err := q.Enqueue(t.Context(), QueuedAction{ActionType: "STUB"})
+	require.NoError(t, err)
+
+	job, err := q.claim(t.Context(), "w")
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	// Stop cancels the worker context while the action is running.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	q.process(ctx, job)
+
+	saved, err := q.GetJob(t.Context(), id)
+	require.NoError(t, err)
+	require.Equal(t, JobStatusSucceeded, saved.Status, "a finished job must not be left running")
+	require.Len(t, stub.Calls, 1)
+}
+
+func TestActionQueue_RunsRuleActionsInOrder(t *testing.T) {
+	for _, cont := range []bool{false, true} {
+		failing := &capturingAction{Err: errors.New("smtp down")}
+		next := &capturingAction{}
+		actions := map[string]ActionHandler{"MAIL": failing, "NEXT": next}
+		eng := newTestEngine(actions)
+		eng.ContinueActionsOnError = cont
+		q := newTestQueue(t, actions)
+		eng.Queue = q
+		rec := &memRecorder{}
+		eng.Recorder = rec
+
+		rule := Rulev2{ID: "3", Name: "chain", Trigger: TriggerSpec{Type: "T"},
+			Actions: []ActionSpec{{Type: "MAIL", MaxAttempts: 1}, {Type: "NEXT"}}}
+		require.NoError(t, eng.EvaluateOnce(EvalContext{Now: fixedNow(), Data: map[string]any{}}, rule))
+		results := rec.Runs[0].Actions
+		require.Len(t, results, 2)
+		second, err := q.GetJob(t.Context(), results[1].JobID)
+		require.NoError(t, err)
+		require.Equal(t, JobStatusWaiting, second.Status, "later actions wait for earlier ones")
+		require.Equal(t, results[0].JobID, second.AfterJobID)
+
+		processed, err := q.RunOnce(t.Context(), "w")
+		require.NoError(t, err)
+		require.True(t, processed)
+		require.Len(t, failing.Calls, 1)
+		require.Empty(t, next.Calls)
+
+		processed, err = q.RunOnce(t.Context(), "w")
+		require.NoError(t, err)
+		second, _ = q.GetJob(t.Context(), second.ID)
+		if cont {
+			require.True(t, processed)
+			require.Len(t, next.Calls, 1, "ContinueActionsOnError runs the next action after a failure")
+			require.Equal(t, JobStatusSucceeded, second.Status)
+		} else {
+			require.False(t, processed)
+			require.Empty(t, next.Calls, "a failed action stops the rest of the rule")
+			require.Equal(t, JobStatusDiscarded, second.Status)
+		}
+	}
+}
+
+func TestActionQueue_RetrySkipsReachedRecipients(t *testing.T) {
+	q := newTestQueue(t, nil)
+	require.NoError(t, q.DB.AutoMigrate(&gen_models.Employee{}))
+	require.NoError(t, q.DB.Create(&gen_models.Employee{Employeenumber: "EMP001", Firstname: "Test", Lastname: "User"}).Error)
+	q.Registry.UseAction("notification", &NotificationAction{DB: q.DB})
+
+	id, err := q.Enqueue(t.Context(), QueuedAction{ActionType: "notification", MaxAttempts: 2, Parameters: map[string]any{
+		"type": "push", "recipients": `["EMP001","EMP002"]`, "subject": "s", "message": "m",
+	}})
+	require.NoError(t, err)
+	_, err = q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	job, _ := q.GetJob(t.Context(), id)
+	require.Equal(t, JobStatusPending, job.Status)
+	require.Contains(t, job.LastError, "EMP002")
+	require.JSONEq(t, `["EMP001"]`, string(job.CompletedSteps))
+
+	// EMP001 was notified already; looking them up again would now fail.
+	require.NoError(t, q.DB.Where("employeenumber = ?", "EMP001").Delete(&gen_models.Employee{}).Error)
+	require.NoError(t, q.DB.Create(&gen_models.Employee{Employeenumber: "EMP002", Firstname: "Other", Lastname: "User"}).Error)
+	time.Sleep(5 * time.Millisecond) // wait out the backoff
+	_, err = q.RunOnce(t.Context(), "w")
+	require.NoError(t, err)
+	job, _ = q.GetJob(t.Context(), id)
+	require.Equal(t, JobStatusSucceeded, job.Status, job.LastError)
+}
+
+func TestActionQueue_HeartbeatKeepsSlowJobLocked(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	calls := 0
+	slow := testActionFunc(func(EvalContext, map[string]any) error {
+		calls++
+		close(started)
+		<-release
+		return nil
+	})
+	q := newTestQueue(t, map[string]ActionHandler{"SLOW": slow})
+	q.LockTimeout = 60 * time.Millisecond
+	_, err := q.Enqueue(t.Context(), QueuedAction{ActionType: "SLOW"})
+	require.NoError(t, err)
+
+	job, err := q.claim(t.Context(), "w1")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() { q.process(t.Context(), job); close(done) }()
+	<-started
+
+	// Outlive the lock timeout while the action is still running.
+	time.Sleep(3 * q.LockTimeout)
+	processed, err := q.RunOnce(t.Context(), "w2")
+	require.NoError(t, err)
+	require.False(t, processed, "a running job with a live worker is not re-queued")
+
+	close(release)
+	<-done
+	require.Equal(t, 1, calls)
+}
@@ -7,6 +7,8 @@ import (
 	"reflect"
 	"strings"
 	"time"
+
+	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
 )
 
 type TriggerHandler interface {
@@ -21,12 +23,41 @@ type ActionHandler interface {
 	Execute(ctx EvalContext, params map[string]any) error
 }
 
+// RawParamsAction is implemented by actions whose listed parameters are
+// templates of their own (rendered later, against other data) and must
+// reach Execute unrendered.
+type RawParamsAction interface {
+	RawParams() []string
+}
+
+// DescribedTrigger, DescribedAction and DescribedFactResolver are
+// implemented by handlers that describe themselves for /api/rules/metadata
+// and rule validation. Handlers that do not are listed by name only.
+type DescribedTrigger interface {
+	Describe() meta.TriggerMetadata
+}
+
+type DescribedAction interface {
+	Describe() meta.ActionMetadata
+}
+
+type DescribedFactResolver interface {
+	DescribeFacts() []meta.FactMetadata
+}
+
 type OperatorFunc func(lhs any, rhs any) (bool, error)
 
 type EvalContext struct {
 	Now  time.Time
 	Data map[string]any //merged data like employee, competency, evenschedule, etc
 	// Can extend here if needed
+
+	// Chain lists the rules whose actions led to this evaluation, oldest
+	// first; empty for events that did not come from a rule.
+	Chain []Cause
+
+	facts *factCache // per-evaluation cache for DbFacts; set by the engine
+	steps *jobSteps  // completed parts of a queued action; set by the queue
 }
 
 type Registry struct {
@@ -34,6 +65,12 @@ type Registry struct {
 	Facts     []FactResolver
 	Operators map[string]OperatorFunc
 	Actions   map[string]ActionHandler
+
+	// RelativeOperators are evaluated against EvalContext.Now.
+	RelativeOperators map[string]RelativeOperatorFunc
+
+	// OperatorInfo describes registered operators, keyed by name.
+	OperatorInfo map[string]meta.OperatorMetadata
 }
 
 // NewRegistry returns an empty registry you can populate manually.
@@ -43,6 +80,9 @@ func NewRegistry() *Registry {
 		Facts:     []FactResolver{},
 		Operators: map[string]OperatorFunc{},
 		Actions:   map[string]ActionHandler{},
+
+		RelativeOperators: map[string]RelativeOperatorFunc{},
+		OperatorInfo:      map[string]meta.OperatorMetadata{},
 	}
 }
 
@@ -71,6 +111,23 @@ func (r *Registry) UseOperator(name string, op OperatorFunc) *Registry {
 	r.Operators[name] = op
 	return r
 }
+func (r *Registry) UseRelativeOperator(name string, op RelativeOperatorFunc) *Registry {
+	if r.RelativeOperators == nil {
+		r.RelativeOperators = map[string]RelativeOperatorFunc{}
+	}
+	r.RelativeOperators[name] = op
+	return r
+}
+
+// DescribeOperator attaches metadata to the operator registered as name.
+func (r *Registry) DescribeOperator(name string, m meta.OperatorMetadata) *Registry {
+	if r.OperatorInfo == nil {
+		r.OperatorInfo = map[string]meta.OperatorMetadata{}
+	}
+	m.Name = name
+	r.OperatorInfo[name] = m
+	return r
+}
 
 /* ------------------------------ Operators -------------------------------- */
 
@@ -89,6 +146,12 @@ func (r *Registry) RegisterDefaultOperators() {
 	r.UseOperator("lessThanOrEqual", opLessThanOrEqual)
 	r.UseOperator("contains", opContains) // strings & slices
 	r.UseOperator("in", opIn)             // membership
+	r.registerExtendedOperators()
+	for _, m := range meta.GetOperatorMetadata() {
+		if r.hasOperator(m.Name) {
+			r.DescribeOperator(m.Name, m)
+		}
+	}
 }
 
 /* ------------------------------ Op Helpers -------------------------------- */
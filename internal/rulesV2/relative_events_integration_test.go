@@ -50,6 +50,8 @@ func testDirectExecution(t *testing.T) {
 	// Migrate tables
 	err = db.AutoMigrate(
 		&models.Rule{},
+		&models.RuleRevision{},
+		&models.RuleRun{},
 		&models.CustomEventDefinition{},
 		&models.CustomEventSchedule{},
 		&models.EventScheduleEmployee{},
@@ -173,6 +175,8 @@ func testSchedulerExecution(t *testing.T) {
 
 	err = db.AutoMigrate(
 		&models.Rule{},
+		&models.RuleRevision{},
+		&models.RuleRun{},
 		&models.CustomEventDefinition{},
 		&models.CustomEventSchedule{},
 		&models.EventScheduleEmployee{},
@@ -0,0 +1,250 @@
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type authorCtxKey struct{}
+
+// WithAuthor tags ctx with the user making a rule change so the store can
+// stamp the revision it writes.
+func WithAuthor(ctx context.Context, author string) context.Context {
+	return context.WithValue(ctx, authorCtxKey{}, author)
+}
+
+func authorFromContext(ctx context.Context) string {
+	a, _ := ctx.Value(authorCtxKey{}).(string)
+	return a
+}
+
+// writeRevision appends the next revision for a rule inside tx.
+func writeRevision(tx *gorm.DB, row models.Rule, author, note string) (int, error) {
+	var last int
+	if err := tx.Model(&models.RuleRevision{}).
+		Where("rule_id = ?", row.ID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&last).Error; err != nil {
+		return 0, err
+	}
+	rev := models.RuleRevision{
+		RuleID:      row.ID,
+		Revision:    last + 1,
+		Name:        row.Name,
+		TriggerType: row.TriggerType,
+		Spec:        row.Spec,
+		Author:      author,
+		Note:        note,
+	}
+	if err := tx.Create(&rev).Error; err != nil {
+		return 0, fmt.Errorf("write revision: %w", err)
+	}
+	return rev.Revision, nil
+}
+
+// replaceSpec overwrites a rule row and records the new revision. Rules that
+// predate revision history get their current spec saved as a baseline first,
+// so the version being replaced is never lost.
+func (s *DbRuleStore) replaceSpec(ctx context.Context, ruleID string, rule Rulev2, note string) error {
+	id, err := strconv.ParseUint(ruleID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid rule id: %w", err)
+	}
+	body, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rule: %w", err)
+	}
+	author := authorFromContext(ctx)
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var row models.Rule
+		if err := tx.First(&row, uint(id)).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.RuleRevision{}).Where("rule_id = ?", row.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if _, err := writeRevision(tx, row, "", "baseline"); err != nil {
+				return err
+			}
+		}
+
+		row.Name = rule.Name
+		row.TriggerType = rule.Trigger.Type
+		row.Spec = datatypes.JSON(body)
+		row.Priority = rule.Priority
+		if err := tx.Model(&models.Rule{}).
+			Where("id = ?", row.ID).
+			Updates(map[string]any{
+				"name":         row.Name,
+				"trigger_type": row.TriggerType,
+				"spec":         row.Spec,
+				"priority":     row.Priority,
+			}).Error; err != nil {
+			return err
+		}
+		_, err := writeRevision(tx, row, author, note)
+		return err
+	})
+}
+
+// ListRevisions returns a rule's revisions, newest first.
+func (s *DbRuleStore) ListRevisions(ctx context.Context, ruleID string) ([]models.RuleRevision, error) {
+	id, err := strconv.ParseUint(ruleID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule id: %w", err)
+	}
+	var revs []models.RuleRevision
+	if err := s.DB.WithContext(ctx).
+		Where("rule_id = ?", uint(id)).
+		Order("revision DESC").
+		Find(&revs).Error; err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
+
+// GetRevision returns one revision of a rule.
+func (s *DbRuleStore) GetRevision(ctx context.Context, ruleID string, revision int) (*models.RuleRevision, error) {
+	id, err := strconv.ParseUint(ruleID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule id: %w", err)
+	}
+	var rev models.RuleRevision
+	if err := s.DB.WithContext(ctx).
+		Where("rule_id = ? AND revision = ?", uint(id), revision).
+		First(&rev).Error; err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// SpecChange is one difference between two revisions. Path uses the same
+// dotted/bracket form as validation errors, e.g. "conditions[0].value".
+type SpecChange struct {
+	Path string `json:"path"`
+	Op   string `json:"op"` // added|removed|changed
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// DiffRevisions compares the specs of two revisions of the same rule.
+func (s *DbRuleStore) DiffRevisions(ctx context.Context, ruleID string, from, to int) ([]SpecChange, error) {
+	a, err := s.GetRevision(ctx, ruleID, from)
+	if err != nil {
+		return nil, fmt.Errorf("revision %d: %w", from, err)
+	}
+	b, err := s.GetRevision(ctx, ruleID, to)
+	if err != nil {
+		return nil, fmt.Errorf("revision %d: %w", to, err)
+	}
+	var av, bv any
+	if err := json.Unmarshal(a.Spec, &av); err != nil {
+		return nil, fmt.Errorf("decode revision %d: %w", from, err)
+	}
+	if err := json.Unmarshal(b.Spec, &bv); err != nil {
+		return nil, fmt.Errorf("decode revision %d: %w", to, err)
+	}
+	changes := []SpecChange{}
+	diffValues("", av, bv, &changes)
+	return changes, nil
+}
+
+// diffValues walks two decoded JSON values and appends the differences.
+func diffValues(path string, a, b any, out *[]SpecChange) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, seen := av[k]; !seen {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			x, inA := av[k]
+			y, inB := bv[k]
+			switch {
+			case !inA:
+				*out = append(*out, SpecChange{Path: p, Op: "added", To: y})
+			case !inB:
+				*out = append(*out, SpecChange{Path: p, Op: "removed", From: x})
+			default:
+				diffValues(p, x, y, out)
+			}
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		n := len(av)
+		if len(bv) > n {
+			n = len(bv)
+		}
+		for i := 0; i < n; i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(av):
+				*out = append(*out, SpecChange{Path: p, Op: "added", To: bv[i]})
+			case i >= len(bv):
+				*out = append(*out, SpecChange{Path: p, Op: "removed", From: av[i]})
+			default:
+				diffValues(p, av[i], bv[i], out)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*out = append(*out, SpecChange{Path: path, Op: "changed", From: a, To: b})
+	}
+}
+
+// RollbackRule restores the spec of an earlier revision. The rollback is saved
+// as a new revision (history stays append-only) and the scheduler is re-synced
+// exactly as it is after an update. A revision that no longer passes
+// validation, e.g. one naming a since-removed action, is refused with an
+// InvalidRuleError.
+func (s *RuleBackEndService) RollbackRule(ctx context.Context, ruleID string, revision int) (*Rulev2, error) {
+	rev, err := s.Store.GetRevision(ctx, ruleID, revision)
+	if err != nil {
+		return nil, err
+	}
+	var spec Rulev2
+	if err := json.Unmarshal(rev.Spec, &spec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal revision: %w", err)
+	}
+	if err := s.validateSpec(spec); err != nil {
+		return nil, err
+	}
+	if err := s.Store.replaceSpec(ctx, ruleID, spec, fmt.Sprintf("rollback to revision %d", revision)); err != nil {
+		return nil, err
+	}
+	spec.ID = ruleID
+	s.syncSchedule(ruleID, spec)
+	return &spec, nil
+}
@@ -0,0 +1,53 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"testing"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestUpdateRule_SnapshotsLegacyRuleAsBaseline(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Rule{}, &models.RuleRevision{}))
+
+	// A rule saved before revision history existed
+	legacy := models.Rule{Name: "old", TriggerType: "roles", Spec: datatypes.JSON(`{"name":"old","trigger":{"type":"roles"},"actions":[]}`), Enabled: true}
+	require.NoError(t, db.Create(&legacy).Error)
+
+	store := &DbRuleStore{DB: db}
+	ctx := WithAuthor(t.Context(), "admin@example.com")
+	require.NoError(t, store.UpdateRule(ctx, "1", Rulev2{Name: "new", Trigger: TriggerSpec{Type: "roles"}}))
+
+	revs, err := store.ListRevisions(ctx, "1")
+	require.NoError(t, err)
+	require.Len(t, revs, 2)
+	require.Equal(t, "new", revs[0].Name)
+	require.Equal(t, "admin@example.com", revs[0].Author)
+	require.Equal(t, "old", revs[1].Name)
+	require.Equal(t, "baseline", revs[1].Note)
+
+	require.Error(t, store.UpdateRule(ctx, "42", Rulev2{Name: "missing"}))
+}
+
+func TestDiffValues(t *testing.T) {
+	a := map[string]any{"name": "a", "conditions": []any{map[string]any{"value": 1.0}}, "gone": true}
+	b := map[string]any{"name": "a", "conditions": []any{map[string]any{"value": 2.0}, map[string]any{"fact": "x"}}, "new": "y"}
+
+	var changes []SpecChange
+	diffValues("", a, b, &changes)
+
+	require.Equal(t, []SpecChange{
+		{Path: "conditions[0].value", Op: "changed", From: 1.0, To: 2.0},
+		{Path: "conditions[1]", Op: "added", To: map[string]any{"fact": "x"}},
+		{Path: "gone", Op: "removed", From: true},
+		{Path: "new", Op: "added", To: "y"},
+	}, changes)
+}
@@ -4,70 +4,196 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterRulesRoutes registers all rules engine HTTP endpoints
-func RegisterRulesRoutes(router *gin.Engine, service *RuleBackEndService) {
+// RegisterRulesRoutes registers all rules engine HTTP endpoints. Users need
+// PermissionRulesView for the group and PermissionRulesEdit or
+// PermissionRulesAdmin for changes; trigger endpoints take API keys instead.
+func RegisterRulesRoutes(router *gin.Engine, service *RuleBackEndService, access RouteAccess) {
+	if access.Authenticate == nil || access.RequirePermission == nil || access.HasPermission == nil {
+		panic("rulesv2: RegisterRulesRoutes needs Authenticate, RequirePermission and HasPermission")
+	}
+
+	// Trigger endpoints for external systems to notify the rules engine,
+	// authenticated with an API key scoped to the trigger type
+	triggerGroup := router.Group("/api/rules/trigger")
+	{
+		triggerGroup.POST("/job-position", requireAPIKey(service, "job_position"), func(c *gin.Context) { TriggerJobPosition(c, service) })
+		triggerGroup.POST("/competency-type", requireAPIKey(service, "competency_type"), func(c *gin.Context) { TriggerCompetencyType(c, service) })
+		triggerGroup.POST("/competency", requireAPIKey(service, "competency"), func(c *gin.Context) { TriggerCompetency(c, service) })
+		triggerGroup.POST("/event-definition", requireAPIKey(service, "event_definition"), func(c *gin.Context) { TriggerEventDefinition(c, service) })
+		triggerGroup.POST("/scheduled-event", requireAPIKey(service, "scheduled_event"), func(c *gin.Context) { TriggerScheduledEvent(c, service) })
+		triggerGroup.POST("/roles", requireAPIKey(service, "roles"), func(c *gin.Context) { TriggerRoles(c, service) })
+		triggerGroup.POST("/link-job-to-competency", requireAPIKey(service, "link_job_to_competency"), func(c *gin.Context) { TriggerLinkJobToCompetency(c, service) })
+		triggerGroup.POST("/competency-prerequisite", requireAPIKey(service, "competency_prerequisite"), func(c *gin.Context) { TriggerCompetencyPrerequisite(c, service) })
+	}
+
+	edit := access.RequirePermission(PermissionRulesEdit)
+	admin := access.RequirePermission(PermissionRulesAdmin)
+
 	rulesGroup := router.Group("/api/rules")
+	rulesGroup.Use(access.Authenticate, access.RequirePermission(PermissionRulesView), func(c *gin.Context) {
+		c.Set(hasPermissionKey, access.HasPermission)
+		c.Next()
+	})
 	{
 		// Metadata endpoints for frontend integration
 		rulesGroup.GET("/metadata", func(c *gin.Context) {
-			GetRulesMetadataHandler(c)
+			GetRulesMetadataHandler(c, service)
 		})
 		rulesGroup.GET("/metadata/triggers", func(c *gin.Context) {
-			GetTriggersMetadataHandler(c)
+			GetTriggersMetadataHandler(c, service)
 		})
 		rulesGroup.GET("/metadata/actions", func(c *gin.Context) {
-			GetActionsMetadataHandler(c)
+			GetActionsMetadataHandler(c, service)
 		})
 		rulesGroup.GET("/metadata/facts", func(c *gin.Context) {
-			GetFactsMetadataHandler(c)
+			GetFactsMetadataHandler(c, service)
 		})
 		rulesGroup.GET("/metadata/operators", func(c *gin.Context) {
-			GetOperatorsMetadataHandler(c)
+			GetOperatorsMetadataHandler(c, service)
+		})
+		rulesGroup.GET("/metadata/functions", func(c *gin.Context) {
+			GetFunctionsMetadataHandler(c)
 		})
 
 		// Validation endpoint
 		rulesGroup.POST("/validate", func(c *gin.Context) {
-			ValidateRuleHandler(c)
+			ValidateRuleHandler(c, service)
 		})
 
-		// Trigger endpoints for external systems to notify the rules engine
-		rulesGroup.POST("/trigger/job-position", func(c *gin.Context) { TriggerJobPosition(c, service) })
-		rulesGroup.POST("/trigger/competency-type", func(c *gin.Context) { TriggerCompetencyType(c, service) })
-		rulesGroup.POST("/trigger/competency", func(c *gin.Context) { TriggerCompetency(c, service) })
-		rulesGroup.POST("/trigger/event-definition", func(c *gin.Context) { TriggerEventDefinition(c, service) })
-		rulesGroup.POST("/trigger/scheduled-event", func(c *gin.Context) { TriggerScheduledEvent(c, service) })
-		rulesGroup.POST("/trigger/roles", func(c *gin.Context) { TriggerRoles(c, service) })
-		rulesGroup.POST("/trigger/link-job-to-competency", func(c *gin.Context) { TriggerLinkJobToCompetency(c, service) })
-        rulesGroup.POST("/trigger/competency-prerequisite", func(c *gin.Context) { TriggerCompetencyPrerequisite(c, service) })
+		// Dry-run an unsaved rule and return its evaluation trace
+		rulesGroup.POST("/simulate", func(c *gin.Context) {
+			SimulateAdHocRule(c, service)
+		})
 
 		// Status and monitoring endpoints
 		rulesGroup.GET("/status", func(c *gin.Context) {
 			GetRulesStatus(c, service)
 		})
+		rulesGroup.GET("/runs", func(c *gin.Context) {
+			ListRuns(c, service)
+		})
+		rulesGroup.GET("/schedule", func(c *gin.Context) {
+			GetSchedule(c, service)
+		})
+
+		// Engine-wide kill switch: suppress every rule while engaged
+		rulesGroup.GET("/kill-switch", func(c *gin.Context) {
+			GetKillSwitch(c, service)
+		})
+		rulesGroup.PUT("/kill-switch", edit, func(c *gin.Context) {
+			SetKillSwitch(c, service)
+		})
+
+		// Maintenance mode: silence all or some trigger types for a while
+		rulesGroup.GET("/maintenance", func(c *gin.Context) {
+			ListMaintenance(c, service)
+		})
+		rulesGroup.POST("/maintenance", edit, func(c *gin.Context) {
+			StartMaintenance(c, service)
+		})
+		rulesGroup.DELETE("/maintenance/:id", edit, func(c *gin.Context) {
+			EndMaintenance(c, service)
+		})
+
+		// Action queue administration
+		rulesGroup.GET("/jobs", func(c *gin.Context) {
+			ListJobs(c, service)
+		})
+		rulesGroup.GET("/jobs/:id", func(c *gin.Context) {
+			GetJob(c, service)
+		})
+		rulesGroup.POST("/jobs/:id/retry", edit, func(c *gin.Context) {
+			RetryJob(c, service)
+		})
+		rulesGroup.POST("/jobs/:id/discard", edit, func(c *gin.Context) {
+			DiscardJob(c, service)
+		})
+
+		// Bundle export/import for copying rules between environments
+		rulesGroup.GET("/bundle/export", func(c *gin.Context) {
+			ExportRulesBundle(c, service)
+		})
+		rulesGroup.POST("/bundle/import", edit, func(c *gin.Context) {
+			ImportRulesBundle(c, service)
+		})
+
+		// Public holiday calendars for business-day offsets
+		rulesGroup.GET("/holidays", func(c *gin.Context) {
+			ListHolidays(c, service)
+		})
+		rulesGroup.POST("/holidays", edit, func(c *gin.Context) {
+			CreateHoliday(c, service)
+		})
+		rulesGroup.POST("/holidays/import", edit, func(c *gin.Context) {
+			ImportHolidays(c, service)
+		})
+		rulesGroup.DELETE("/holidays/:id", edit, func(c *gin.Context) {
+			DeleteHoliday(c, service)
+		})
 
 		// Rule management endpoints
 		rulesGroup.GET("/rules", func(c *gin.Context) {
 			ListRules(c, service)
 		})
-		rulesGroup.POST("/rules", func(c *gin.Context) {
+		rulesGroup.POST("/rules", edit, func(c *gin.Context) {
 			CreateRule(c, service)
 		})
 		rulesGroup.GET("/rules/:id", func(c *gin.Context) {
 			GetRule(c, service)
 		})
-		rulesGroup.PUT("/rules/:id", func(c *gin.Context) {
+		rulesGroup.PUT("/rules/:id", edit, func(c *gin.Context) {
 			UpdateRule(c, service)
 		})
-		rulesGroup.DELETE("/rules/:id", func(c *gin.Context) {
+		rulesGroup.DELETE("/rules/:id", edit, func(c *gin.Context) {
 			DeleteRule(c, service)
 		})
 
 		// Rule state management endpoints
-		rulesGroup.POST("/rules/:id/enable", func(c *gin.Context) {
+		rulesGroup.POST("/rules/:id/enable", edit, func(c *gin.Context) {
 			EnableRule(c, service)
 		})
-		rulesGroup.POST("/rules/:id/disable", func(c *gin.Context) {
+		rulesGroup.POST("/rules/:id/disable", edit, func(c *gin.Context) {
 			DisableRule(c, service)
 		})
+
+		// Revision history, diff and rollback
+		rulesGroup.GET("/rules/:id/revisions", func(c *gin.Context) {
+			ListRuleRevisions(c, service)
+		})
+		rulesGroup.GET("/rules/:id/revisions/diff", func(c *gin.Context) {
+			DiffRuleRevisions(c, service)
+		})
+		rulesGroup.GET("/rules/:id/revisions/:rev", func(c *gin.Context) {
+			GetRuleRevision(c, service)
+		})
+		rulesGroup.POST("/rules/:id/revisions/:rev/rollback", edit, func(c *gin.Context) {
+			RollbackRuleRevision(c, service)
+		})
+
+		// Execution history for a single rule
+		rulesGroup.GET("/rules/:id/runs", func(c *gin.Context) {
+			ListRuleRuns(c, service)
+		})
+
+		// Dry-run a stored rule (no actions executed)
+		rulesGroup.POST("/rules/:id/simulate", func(c *gin.Context) {
+			SimulateRule(c, service)
+		})
+
+		// Fire a time-based rule now (actions executed)
+		rulesGroup.POST("/rules/:id/run", edit, func(c *gin.Context) {
+			RunRuleNow(c, service)
+		})
+
+		// API keys for the trigger endpoints
+		rulesGroup.GET("/api-keys", admin, func(c *gin.Context) {
+			ListAPIKeys(c, service)
+		})
+		rulesGroup.POST("/api-keys", admin, func(c *gin.Context) {
+			CreateAPIKey(c, service)
+		})
+		rulesGroup.DELETE("/api-keys/:id", admin, func(c *gin.Context) {
+			RevokeAPIKey(c, service)
+		})
 	}
 }
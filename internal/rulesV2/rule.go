@@ -5,16 +5,26 @@ type TriggerSpec struct {
     Parameters map[string]any `json:"parameters,omitempty"`
 }
 
+// Condition is a node in the condition tree. A node is either a leaf
+// (Fact/Operator/Value) or exactly one of the All/Any/Not groups.
+// Rulev2.Conditions itself is an implicit "all", so flat specs keep working.
 type Condition struct {
-    Fact     string         `json:"fact"`
-    Operator string         `json:"operator"`
+    Fact     string         `json:"fact,omitempty"`
+    Operator string         `json:"operator,omitempty"`
     Value    any            `json:"value,omitempty"`
     Extras   map[string]any `json:"-"`
+
+    All []Condition `json:"all,omitempty"`
+    Any []Condition `json:"any,omitempty"`
+    Not *Condition  `json:"not,omitempty"`
 }
 
 type ActionSpec struct {
     Type       string         `json:"type"`
     Parameters map[string]any `json:"parameters,omitempty"`
+    // MaxAttempts caps delivery attempts when actions run through the queue.
+    // Zero uses the queue default.
+    MaxAttempts int `json:"maxAttempts,omitempty"`
 }
 
 // UI snapshot to persist canvas positions and edges
@@ -37,9 +47,33 @@ type UISnapshot struct {
 }
 
 type Rulev2 struct {
+    // ID is the DB row id, filled in by the stores when a rule is loaded.
+    // It is not part of the persisted spec.
+    ID         string       `json:"-"`
     Name       string       `json:"name"`
     Trigger    TriggerSpec  `json:"trigger"`
     Conditions []Condition  `json:"conditions,omitempty"`
     Actions    []ActionSpec `json:"actions"`
     UI         *UISnapshot  `json:"_ui,omitempty"`
+
+    // Priority orders rules sharing a trigger type: higher runs first, ties
+    // by creation order. StopProcessing skips the remaining lower-priority
+    // rules for the same event once this rule matches. Within an
+    // ExclusiveGroup only the first matching rule fires.
+    Priority       int    `json:"priority,omitempty"`
+    StopProcessing bool   `json:"stopProcessing,omitempty"`
+    ExclusiveGroup string `json:"exclusiveGroup,omitempty"`
+
+    // Limits optionally throttle the rule; see RuleLimits.
+    Limits *RuleLimits `json:"limits,omitempty"`
+
+    // ActiveFrom and ActiveUntil bound the period the rule may fire in
+    // ("2026-09-01" or RFC3339; a date-only ActiveUntil includes that day).
+    // ActiveWindows further restrict it to days and hours, e.g. weekdays
+    // 08:00-18:00. Dates and windows are read in ActiveTimezone, UTC by
+    // default. Outside them a matched rule is suppressed.
+    ActiveFrom     string         `json:"activeFrom,omitempty"`
+    ActiveUntil    string         `json:"activeUntil,omitempty"`
+    ActiveWindows  []ActiveWindow `json:"activeWindows,omitempty"`
+    ActiveTimezone string         `json:"activeTimezone,omitempty"`
 }
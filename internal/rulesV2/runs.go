@@ -0,0 +1,383 @@
+package rulesv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Run statuses stored on models.RuleRun.
+const (
+	RunStatusNotMatched = "not_matched"
+	RunStatusQueued     = "queued" // matched, with actions still waiting in the action queue
+	RunStatusSuccess    = "success"
+	RunStatusPartial    = "partial"
+	RunStatusFailed     = "failed"
+	RunStatusError      = "error"
+	RunStatusSuppressed = "suppressed" // matched but held back, e.g. by the kill switch or rule limits
+)
+
+// ActionResult is the outcome of a single action within a run.
+type ActionResult struct {
+	Type       string         `json:"type"`
+	Parameters map[string]any `json:"parameters,omitempty"` // rendered
+	OK         bool           `json:"ok"`
+	Queued     bool           `json:"queued,omitempty"` // handed to the action queue
+	JobID      uint           `json:"jobId,omitempty"`
+	JobStatus  string         `json:"jobStatus,omitempty"` // status of the queued job when last settled
+	Error      string         `json:"error,omitempty"`
+}
+
+// waiting reports whether the action was queued and its job has not finished.
+func (r ActionResult) waiting() bool {
+	if !r.Queued {
+		return false
+	}
+	switch r.JobStatus {
+	case JobStatusSucceeded, JobStatusDead, JobStatusDiscarded:
+		return false
+	}
+	return true
+}
+
+// RunRecord is what the engine hands to a RunRecorder after each evaluation.
+type RunRecord struct {
+	RuleID      string
+	RuleName    string
+	TriggerType string
+	Source      string
+	EntityType  string
+	EntityID    string
+	Matched     bool
+	Status      string
+	Error       string
+	Actions     []ActionResult
+	StartedAt   time.Time
+	Duration    time.Duration
+	Depth       int    // cascade depth, see EvalContext.Chain
+	CausedBy    string // name of the rule whose action led here, if any
+	Reason      string // why a suppressed run did not fire
+}
+
+// RunRecorder persists evaluation history. Implementations must be safe for
+// concurrent use; errors are logged by the engine and never fail a rule.
+type RunRecorder interface {
+	RecordRun(ctx context.Context, run RunRecord) error
+}
+
+// record builds a RunRecord for one evaluation and hands it to the recorder.
+func (e *Engine) record(evCtx EvalContext, r Rulev2, started time.Time, matched bool, results []ActionResult, err error) {
+	if e.Recorder == nil {
+		return
+	}
+	run := newRunRecord(evCtx, r, started)
+	run.Matched = matched
+	run.Status = runStatus(matched, results, err)
+	run.Actions = results
+	if err != nil {
+		run.Error = err.Error()
+	}
+	e.saveRun(r, run)
+}
+
+// recordSuppressed records a matched evaluation that the limiter held back.
+func (e *Engine) recordSuppressed(evCtx EvalContext, r Rulev2, started time.Time, reason string) {
+	if e.Recorder == nil {
+		return
+	}
+	run := newRunRecord(evCtx, r, started)
+	run.Matched = true
+	run.Status = RunStatusSuppressed
+	run.Reason = reason
+	e.saveRun(r, run)
+}
+
+func newRunRecord(evCtx EvalContext, r Rulev2, started time.Time) RunRecord {
+	entityType, entityID := entityRef(evCtx.Data)
+	run := RunRecord{
+		RuleID:      r.ID,
+		RuleName:    r.Name,
+		TriggerType: r.Trigger.Type,
+		Source:      runSource(r.Trigger.Type),
+		EntityType:  entityType,
+		EntityID:    entityID,
+		StartedAt:   started.UTC(),
+		Duration:    time.Since(started),
+		Depth:       evCtx.Depth(),
+	}
+	if n := len(evCtx.Chain); n > 0 {
+		run.CausedBy = evCtx.Chain[n-1].RuleName
+	}
+	return run
+}
+
+func (e *Engine) saveRun(r Rulev2, run RunRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if rerr := e.Recorder.RecordRun(ctx, run); rerr != nil {
+		log.Printf("rules: failed to record run for rule %q: %v", r.Name, rerr)
+	}
+}
+
+func runSource(triggerType string) string {
+	switch triggerType {
+	case "scheduled_time":
+		return "schedule"
+	case "relative_time":
+		return "relative"
+	default:
+		return "event"
+	}
+}
+
+func runStatus(matched bool, results []ActionResult, err error) string {
+	if !matched {
+		if err != nil {
+			return RunStatusError
+		}
+		return RunStatusNotMatched
+	}
+	ok, waiting := 0, 0
+	for _, r := range results {
+		switch {
+		case r.OK:
+			ok++
+		case r.waiting():
+			waiting++
+		}
+	}
+	switch {
+	case err == nil && waiting > 0:
+		return RunStatusQueued
+	case err == nil:
+		return RunStatusSuccess
+	case ok == 0 && waiting == 0:
+		return RunStatusFailed
+	default:
+		return RunStatusPartial
+	}
+}
+
+// entityRefKeys lists the Data keys that identify the primary entity of an
+// evaluation, most specific first, with the id field to read from each.
+var entityRefKeys = []struct {
+	key, entityType string
+	idFields        []string
+}{
+	{"employeeCompetency", "employee_competency", []string{"EmployeeCompetencyID", "employee_competency_id"}},
+	{"employmentHistory", "employment_history", []string{"EmploymentID", "employment_id"}},
+	{"scheduledEvent", "scheduled_event", []string{"CustomEventScheduleID", "custom_event_schedule_id"}},
+	{"eventAttendance", "event_attendance", []string{"ID", "id"}},
+	{"jobMatrix", "job_matrix", []string{"CustomMatrixID", "custom_matrix_id"}},
+	{"user", "user", []string{"ID", "id"}},
+	{"employee", "employee", []string{"EmployeeNumber", "employeenumber"}},
+	{"prerequisite", "competency_prerequisite", []string{"PrerequisiteCompetencyID", "prerequisite_competency_id"}},
+	{"link", "link_job_to_competency", []string{"CustomMatrixID", "custom_matrix_id"}},
+	{"competency", "competency", []string{"CompetencyID", "competency_id"}},
+	{"competencyType", "competency_type", []string{"TypeName", "type_name"}},
+	{"eventDefinition", "event_definition", []string{"CustomEventID", "custom_event_id"}},
+	{"jobPosition", "job_position", []string{"PositionMatrixCode", "position_matrix_code"}},
+	{"role", "role", []string{"RoleID", "role_id"}},
+}
+
+// entityRef picks the entity an evaluation was about, e.g.
+// ("employee_competency", "42"). Returns empty strings when unknown.
+func entityRef(data map[string]any) (string, string) {
+	for _, k := range entityRefKeys {
+		v, ok := data[k.key]
+		if !ok || v == nil {
+			continue
+		}
+		for _, f := range k.idFields {
+			if id, ok := resolveFromMapOrStruct(v, []string{f}); ok && id != nil {
+				return k.entityType, fmt.Sprint(id)
+			}
+		}
+		return k.entityType, ""
+	}
+	return "", ""
+}
+
+/* ------------------------------ DB store --------------------------------- */
+
+// DbRunStore persists rule runs in the rule_runs table.
+type DbRunStore struct {
+	DB *gorm.DB
+}
+
+func (s *DbRunStore) RecordRun(ctx context.Context, run RunRecord) error {
+	var actions datatypes.JSON
+	if len(run.Actions) > 0 {
+		b, err := json.Marshal(run.Actions)
+		if err != nil {
+			return fmt.Errorf("marshal action results: %w", err)
+		}
+		actions = datatypes.JSON(b)
+	}
+	var jobIDs []uint
+	for _, a := range run.Actions {
+		if a.Queued && a.JobID != 0 {
+			jobIDs = append(jobIDs, a.JobID)
+		}
+	}
+	ruleID, _ := strconv.ParseUint(run.RuleID, 10, 64)
+	row := models.RuleRun{
+		RuleID:      uint(ruleID),
+		RuleName:    run.RuleName,
+		TriggerType: run.TriggerType,
+		Source:      run.Source,
+		EntityType:  run.EntityType,
+		EntityID:    run.EntityID,
+		Matched:     run.Matched,
+		Status:      run.Status,
+		Error:       run.Error,
+		Actions:     actions,
+		DurationMs:  run.Duration.Milliseconds(),
+		StartedAt:   run.StartedAt,
+		Depth:       run.Depth,
+		CausedBy:    run.CausedBy,
+		Reason:      run.Reason,
+	}
+	if len(jobIDs) == 0 {
+		return s.DB.WithContext(ctx).Create(&row).Error
+	}
+	// Link the queued jobs so the queue settles this run as they finish,
+	// and pick up any that finished before the run was recorded.
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RuleJob{}).Where("id IN ?", jobIDs).Update("run_id", row.ID).Error; err != nil {
+			return err
+		}
+		return settleRun(tx, row.ID)
+	})
+}
+
+// settleRun copies the state of a run's queued jobs into its action results
+// and recomputes the run's status and error. The run row is locked so jobs
+// finishing together do not overwrite each other's results.
+func settleRun(db *gorm.DB, runID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var row models.RuleRun
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&row, runID).Error; err != nil {
+			return err
+		}
+		var results []ActionResult
+		if len(row.Actions) > 0 {
+			if err := json.Unmarshal(row.Actions, &results); err != nil {
+				return fmt.Errorf("decode action results: %w", err)
+			}
+		}
+		var jobs []models.RuleJob
+		if err := tx.Select("id", "status", "last_error").Where("run_id = ?", runID).Find(&jobs).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]models.RuleJob, len(jobs))
+		for _, j := range jobs {
+			byID[j.ID] = j
+		}
+		var agg MultiError
+		for i := range results {
+			res := &results[i]
+			if j, ok := byID[res.JobID]; ok && res.Queued {
+				res.JobStatus = j.Status
+				res.OK = j.Status == JobStatusSucceeded
+				res.Error = ""
+				switch j.Status {
+				case JobStatusDead:
+					res.Error = fmt.Sprintf("action %q failed: %s", res.Type, j.LastError)
+				case JobStatusDiscarded:
+					res.Error = fmt.Sprintf("action %q discarded", res.Type)
+				}
+			}
+			if res.Error != "" {
+				agg.Append(errors.New(res.Error))
+			}
+		}
+		b, err := json.Marshal(results)
+		if err != nil {
+			return fmt.Errorf("marshal action results: %w", err)
+		}
+		errText := ""
+		if err := agg.Err(); err != nil {
+			errText = err.Error()
+		}
+		return tx.Model(&models.RuleRun{}).Where("id = ?", runID).Updates(map[string]any{
+			"actions": datatypes.JSON(b),
+			"status":  runStatus(row.Matched, results, agg.Err()),
+			"error":   errText,
+		}).Error
+	})
+}
+
+// RunFilter narrows ListRuns. Zero values are ignored.
+type RunFilter struct {
+	RuleID      uint
+	TriggerType string
+	Status      string
+	EntityType  string
+	EntityID    string
+	Matched     *bool
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+	Offset      int
+}
+
+// ListRuns returns runs newest first together with the total matching count.
+func (s *DbRunStore) ListRuns(ctx context.Context, f RunFilter) ([]models.RuleRun, int64, error) {
+	q := s.DB.WithContext(ctx).Model(&models.RuleRun{})
+	if f.RuleID != 0 {
+		q = q.Where("rule_id = ?", f.RuleID)
+	}
+	if f.TriggerType != "" {
+		q = q.Where("trigger_type = ?", f.TriggerType)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if f.EntityType != "" {
+		q = q.Where("entity_type = ?", f.EntityType)
+	}
+	if f.EntityID != "" {
+		q = q.Where("entity_id = ?", f.EntityID)
+	}
+	if f.Matched != nil {
+		q = q.Where("matched = ?", *f.Matched)
+	}
+	if !f.Since.IsZero() {
+		q = q.Where("started_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		q = q.Where("started_at < ?", f.Until)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	var rows []models.RuleRun
+	if err := q.Order("started_at DESC, id DESC").Limit(limit).Offset(f.Offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
@@ -0,0 +1,80 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type memRecorder struct{ Runs []RunRecord }
+
+func (m *memRecorder) RecordRun(_ context.Context, run RunRecord) error {
+	m.Runs = append(m.Runs, run)
+	return nil
+}
+
+func TestEngine_RecordsRuns(t *testing.T) {
+	rec := &memRecorder{}
+	eng := newTestEngine(map[string]ActionHandler{
+		"OK":   &capturingAction{},
+		"FAIL": &capturingAction{Err: errors.New("boom")},
+	})
+	eng.Recorder = rec
+
+	rule := Rulev2{
+		ID:         "7",
+		Name:       "expiry reminder",
+		Trigger:    TriggerSpec{Type: "relative_time"},
+		Conditions: []Condition{{Fact: "employee.EmployeeStatus", Operator: "equals", Value: "Active"}},
+		Actions:    []ActionSpec{{Type: "OK", Parameters: map[string]any{"to": "{{.employee.EmployeeNumber}}"}}, {Type: "FAIL"}},
+	}
+	active := EvalContext{Now: fixedNow(), Data: map[string]any{
+		"employee": map[string]any{"EmployeeNumber": "E1", "EmployeeStatus": "Active"},
+	}}
+	inactive := EvalContext{Now: fixedNow(), Data: map[string]any{
+		"employee": map[string]any{"EmployeeNumber": "E2", "EmployeeStatus": "Terminated"},
+	}}
+
+	_ = eng.EvaluateOnce(active, rule)
+	_ = eng.EvaluateOnce(inactive, rule)
+
+	if len(rec.Runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(rec.Runs))
+	}
+	first := rec.Runs[0]
+	if first.RuleID != "7" || first.Source != "relative" || !first.Matched || first.Status != RunStatusPartial {
+		t.Fatalf("unexpected first run: %+v", first)
+	}
+	if first.EntityType != "employee" || first.EntityID != "E1" {
+		t.Fatalf("unexpected entity ref: %s/%s", first.EntityType, first.EntityID)
+	}
+	if len(first.Actions) != 2 || !first.Actions[0].OK || first.Actions[0].Parameters["to"] != "E1" || first.Actions[1].Error == "" {
+		t.Fatalf("unexpected action results: %+v", first.Actions)
+	}
+	if second := rec.Runs[1]; second.Matched || second.Status != RunStatusNotMatched || len(second.Actions) != 0 {
+		t.Fatalf("unexpected second run: %+v", second)
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	boom := errors.New("boom")
+	cases := []struct {
+		matched bool
+		results []ActionResult
+		err     error
+		want    string
+	}{
+		{false, nil, nil, RunStatusNotMatched},
+		{false, nil, boom, RunStatusError},
+		{true, []ActionResult{{OK: true}}, nil, RunStatusSuccess},
+		{true, []ActionResult{{OK: false}}, boom, RunStatusFailed},
+		{true, []ActionResult{{OK: true}, {OK: false}}, boom, RunStatusPartial},
+	}
+	for _, tc := range cases {
+		if got := runStatus(tc.matched, tc.results, tc.err); got != tc.want {
+			t.Errorf("runStatus(%v, %v, %v) = %q, want %q", tc.matched, tc.results, tc.err, got, tc.want)
+		}
+	}
+}
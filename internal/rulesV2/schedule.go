@@ -0,0 +1,97 @@
+package rulesv2
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+	rsched "Automated-Scheduling-Project/internal/rulesV2/scheduler"
+)
+
+// ScheduledRule is a scheduled_time rule's timing plus its latest run.
+type ScheduledRule struct {
+	rsched.FixedSchedule
+	LastRun *models.RuleRun `json:"lastRun,omitempty"`
+}
+
+// ScheduleOverview is what GET /api/rules/schedule returns.
+type ScheduleOverview struct {
+	GeneratedAt time.Time                `json:"generatedAt"`
+	HorizonDays int                      `json:"horizonDays"`
+	Scheduled   []ScheduledRule          `json:"scheduled"`
+	Relative    []rsched.RelativePreview `json:"relative"`
+}
+
+// ManualRun is the outcome of running a time-based rule on demand.
+type ManualRun struct {
+	RuleID  string         `json:"ruleId"`
+	Trigger map[string]any `json:"trigger"`
+	Matched bool           `json:"matched"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// ErrNotTimeBased is returned by RunRuleNow for rules whose trigger is
+// neither scheduled_time nor relative_time.
+var ErrNotTimeBased = errors.New("rule does not have a time-based trigger")
+
+// Schedule lists the scheduled_time rules with their next/previous fire
+// times and last run, and the relative_time fires due in the next days.
+func (s *RuleBackEndService) Schedule(ctx context.Context, days int) (*ScheduleOverview, error) {
+	now := time.Now().UTC()
+	fixed, err := s.Scheduler.FixedSchedules(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	out := &ScheduleOverview{GeneratedAt: now, HorizonDays: days, Scheduled: make([]ScheduledRule, 0, len(fixed))}
+	for _, fs := range fixed {
+		sr := ScheduledRule{FixedSchedule: fs}
+		if id, err := strconv.ParseUint(fs.RuleID, 10, 64); err == nil {
+			runs, _, err := s.Runs.ListRuns(ctx, RunFilter{RuleID: uint(id), Limit: 1})
+			if err != nil {
+				return nil, err
+			}
+			if len(runs) > 0 {
+				sr.LastRun = &runs[0]
+			}
+		}
+		out.Scheduled = append(out.Scheduled, sr)
+	}
+	out.Relative, err = s.Scheduler.PreviewRelative(ctx, now, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RunRuleNow evaluates a stored time-based rule immediately with a synthetic
+// trigger payload, on top of the caller's data (e.g. an "employee" to act
+// on). Actions run for real and the run is recorded like a scheduled one;
+// the scheduler's fire bookkeeping is left untouched.
+func (s *RuleBackEndService) RunRuleNow(ctx context.Context, ruleID string, data map[string]any, now time.Time) (*ManualRun, error) {
+	rule, err := s.Store.GetRuleByID(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Trigger.Type != "scheduled_time" && rule.Trigger.Type != "relative_time" {
+		return nil, ErrNotTimeBased
+	}
+	trig, err := rsched.ManualTrigger(rsched.TriggerSpec{Type: rule.Trigger.Type, Parameters: rule.Trigger.Parameters})
+	if err != nil {
+		return nil, err
+	}
+
+	ev := EvalContext{Now: now, Data: make(map[string]any, len(data)+1)}
+	for k, v := range data {
+		ev.Data[k] = v
+	}
+	ev.Data["trigger"] = trig
+
+	matched, err := s.Engine.evaluate(ev, *rule)
+	res := &ManualRun{RuleID: ruleID, Trigger: trig, Matched: matched}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res, nil
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "Automated-Scheduling-Project/internal/rulesV2/holidays"
+
+    // Embed the zone database so IANA names resolve on hosts without tzdata.
+    _ "time/tzdata"
+)
+
+// candidateSlack widens the entity-date range queried for a relative_time
+// rule. Calendar offsets are not fixed durations, so the range is a superset
+// (month-end clamping, DST, time_of_day, date columns read in the rule's zone)
+// and each candidate is kept only if its exact fire time is due.
+const candidateSlack = 4 * 24 * time.Hour
+
+// businessDaySlack replaces candidateSlack for business-day offsets, whose
+// inverse can also be off by a weekend plus a run of holidays.
+const businessDaySlack = 10 * 24 * time.Hour
+
+// LoadTimezone resolves a rule's timezone parameter. Empty and "UTC" mean
+// UTC, "UTC+2"/"UTC-5" are fixed offsets, and anything else must be an IANA
+// zone name known to tzdata (e.g. "Africa/Johannesburg").
+func LoadTimezone(tz string) (*time.Location, error) {
+    tz = strings.TrimSpace(tz)
+    if tz == "" || strings.EqualFold(tz, "utc") {
+        return time.UTC, nil
+    }
+    if strings.HasPrefix(strings.ToUpper(tz), "UTC") {
+        n, err := utcOffsetHours(tz)
+        if err != nil {
+            return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+        }
+        return time.FixedZone(strings.ToUpper(tz), n*3600), nil
+    }
+    if tz == "Local" {
+        return nil, fmt.Errorf("invalid timezone %q: use an IANA zone name", tz)
+    }
+    loc, err := time.LoadLocation(tz)
+    if err != nil {
+        return nil, fmt.Errorf("unknown timezone %q", tz)
+    }
+    return loc, nil
+}
+
+// cronTZPrefix is the CRON_TZ= prefix for a timezone parameter, "" for UTC.
+func cronTZPrefix(tz string) (string, error) {
+    tz = strings.TrimSpace(tz)
+    if _, err := LoadTimezone(tz); err != nil {
+        return "", err
+    }
+    if tz == "" || strings.EqualFold(tz, "utc") {
+        return "", nil
+    }
+    if strings.HasPrefix(strings.ToUpper(tz), "UTC") {
+        return tzPrefixFromUTCOffset(tz)
+    }
+    return "CRON_TZ=" + tz, nil
+}
+
+// offsetUnit normalizes a relative_time offset_unit.
+func offsetUnit(unit string) (string, error) {
+    switch strings.ToLower(strings.TrimSpace(unit)) {
+    case "minutes", "minute", "min":
+        return "minutes", nil
+    case "hours", "hour", "hr":
+        return "hours", nil
+    case "days", "day":
+        return "days", nil
+    case "business_days", "business_day", "working_days", "working_day":
+        return "business_days", nil
+    case "weeks", "week":
+        return "weeks", nil
+    case "months", "month":
+        return "months", nil
+    case "years", "year":
+        return "years", nil
+    default:
+        return "", fmt.Errorf("unknown offset_unit %q", unit)
+    }
+}
+
+// calendarUnit reports whether offsets in unit step the calendar rather
+// than the clock.
+func calendarUnit(unit string) bool {
+    return unit != "minutes" && unit != "hours"
+}
+
+// shift moves t by n units. Minutes and hours are exact durations; days and
+// larger step the calendar in t's zone, so the wall-clock time survives DST
+// changes and months keep their day, clamped to the last day of shorter
+// months (31 March minus one month is 28 February). Business days step over
+// weekends and the holidays of cal.
+func shift(t time.Time, n int, unit string, cal *holidays.Calendar) time.Time {
+    switch unit {
+    case "business_days":
+        return cal.AddBusinessDays(t, n)
+    case "days":
+        return t.AddDate(0, 0, n)
+    case "weeks":
+        return t.AddDate(0, 0, 7*n)
+    case "months":
+        return addMonths(t, n)
+    case "years":
+        return addMonths(t, 12*n)
+    case "hours":
+        return t.Add(time.Duration(n) * time.Hour)
+    case "minutes":
+        return t.Add(time.Duration(n) * time.Minute)
+    default:
+        return t // offsetUnit rejects anything else
+    }
+}
+
+func addMonths(t time.Time, n int) time.Time {
+    y, m, d := t.Date()
+    first := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+    if last := first.AddDate(0, 1, -1).Day(); d > last {
+        d = last
+    }
+    return first.AddDate(0, 0, d-1)
+}
@@ -0,0 +1,138 @@
+//go:build unit
+
+package scheduler
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+    "Automated-Scheduling-Project/internal/rulesV2/holidays"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestLoadTimezone(t *testing.T) {
+    loc, err := LoadTimezone("")
+    require.NoError(t, err)
+    assert.Equal(t, time.UTC, loc)
+
+    loc, err = LoadTimezone("UTC+2")
+    require.NoError(t, err)
+    _, off := time.Date(2025, 1, 1, 0, 0, 0, 0, loc).Zone()
+    assert.Equal(t, 2*3600, off)
+
+    loc, err = LoadTimezone("America/New_York")
+    require.NoError(t, err)
+    assert.Equal(t, "America/New_York", loc.String())
+
+    for _, bad := range []string{"Mars/Olympus", "UTC+15", "UTC-13", "Local"} {
+        _, err = LoadTimezone(bad)
+        assert.Error(t, err, bad)
+    }
+}
+
+func TestShift_CalendarUnits(t *testing.T) {
+    mar31 := time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC)
+    assert.Equal(t, time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC), shift(mar31, -1, "months", nil))
+    assert.Equal(t, time.Date(2025, 4, 30, 9, 0, 0, 0, time.UTC), shift(mar31, 1, "months", nil))
+
+    leap := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+    assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), shift(leap, 1, "years", nil))
+
+    // A day keeps the wall clock across the spring-forward night (23 hours).
+    london, err := time.LoadLocation("Europe/London")
+    require.NoError(t, err)
+    sat := time.Date(2025, 3, 29, 9, 0, 0, 0, london)
+    assert.Equal(t, 23*time.Hour, shift(sat, 1, "days", nil).Sub(sat))
+    assert.Equal(t, 24*time.Hour, shift(sat, 24, "hours", nil).Sub(sat))
+    assert.Equal(t, -90*time.Minute, shift(sat, -90, "minutes", nil).Sub(sat))
+}
+
+func TestRelativeFireTime_LocalClockAcrossDST(t *testing.T) {
+    sp, err := parseRelative(map[string]any{
+        "entity_type": "employee_competency", "date_field": "expiry_date",
+        "offset_direction": "before", "offset_value": 1, "offset_unit": "months",
+        "timezone": "Europe/London", "time_of_day": "09:00",
+    })
+    require.NoError(t, err)
+
+    // 09:00 London is 08:00 UTC in summer time and 09:00 UTC in winter.
+    assert.Equal(t, time.Date(2025, 10, 15, 8, 0, 0, 0, time.UTC), sp.fireTime(time.Date(2025, 11, 15, 0, 0, 0, 0, time.UTC), Date))
+    assert.Equal(t, time.Date(2025, 3, 15, 9, 0, 0, 0, time.UTC), sp.fireTime(time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC), Date))
+    // Month lengths differ: 31 March minus a month is 28 February.
+    assert.Equal(t, time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC), sp.fireTime(time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), Date))
+
+    // A timestamp that happens to be midnight UTC is an instant, not a day.
+    sp, err = parseRelative(map[string]any{
+        "entity_type": "scheduled_event", "date_field": "event_start_date",
+        "offset_direction": "before", "offset_value": 1, "offset_unit": "hours",
+        "timezone": "America/New_York",
+    })
+    require.NoError(t, err)
+    start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+    assert.Equal(t, start.Add(-time.Hour), sp.fireTime(start, Timestamp))
+    assert.Equal(t, time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC), sp.fireTime(start, Date), "midnight New York, less an hour")
+
+    _, err = parseRelative(map[string]any{
+        "entity_type": "employee", "date_field": "termination_date",
+        "offset_direction": "before", "offset_value": 1, "offset_unit": "fortnights",
+    })
+    assert.ErrorContains(t, err, "unknown offset_unit")
+}
+
+func TestTickRelative_CalendarMonthInZone(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+    require.NoError(t, db.Create(&models.EmploymentHistory{
+        EmploymentID: 7, EmployeeNumber: "E1", PositionMatrixCode: "P", StartDate: time.Date(2025, 11, 15, 0, 0, 0, 0, time.UTC),
+    }).Error)
+
+    rule := relativeRule("employment_history", "start_date", "before", 1, "months")
+    rule.Trigger.Parameters["timezone"] = "Europe/London"
+    rule.Trigger.Parameters["time_of_day"] = "09:00"
+    calls := 0
+    s := New(db, &fakeStore{rules: []Rule{rule}}, func(ev EvalContext, _ any) error {
+        calls++
+        assert.Equal(t, "Europe/London", ev.Data["trigger"].(map[string]any)["timezone"])
+        return nil
+    })
+
+    // Due at 2025-10-15 08:00 UTC (09:00 BST), not a 30-day offset.
+    s.tickRelative(context.Background(), time.Date(2025, 10, 15, 7, 59, 0, 0, time.UTC), s.lookback)
+    assert.Equal(t, 0, calls)
+    s.tickRelative(context.Background(), time.Date(2025, 10, 15, 8, 0, 0, 0, time.UTC), s.lookback)
+    assert.Equal(t, 1, calls)
+}
+
+func TestTickRelative_BusinessDaysSkipHolidays(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}, &models.PublicHoliday{}))
+    _, err := holidays.Save(context.Background(), db, []holidays.Holiday{
+        {Region: "ZA", Date: time.Date(2025, 12, 16, 0, 0, 0, 0, time.UTC), Name: "Day of Reconciliation"},
+    }, holidays.SourceManual)
+    require.NoError(t, err)
+    require.NoError(t, db.Create(&models.EmploymentHistory{
+        EmploymentID: 8, EmployeeNumber: "E1", PositionMatrixCode: "P", StartDate: time.Date(2025, 12, 18, 0, 0, 0, 0, time.UTC),
+    }).Error)
+
+    rule := relativeRule("employment_history", "start_date", "before", 3, "business_days")
+    rule.Trigger.Parameters["timezone"] = "Africa/Johannesburg"
+    rule.Trigger.Parameters["time_of_day"] = "09:00"
+    rule.Trigger.Parameters["holiday_region"] = "ZA"
+    calls := 0
+    s := New(db, &fakeStore{rules: []Rule{rule}}, func(ev EvalContext, _ any) error {
+        calls++
+        assert.Equal(t, "ZA", ev.Data["trigger"].(map[string]any)["holiday_region"])
+        return nil
+    })
+
+    // Thursday 18th minus 3 business days, stepping over the weekend and
+    // Tuesday's holiday, is Friday 12th at 09:00 SAST (07:00 UTC).
+    s.tickRelative(context.Background(), time.Date(2025, 12, 12, 6, 59, 0, 0, time.UTC), s.lookback)
+    assert.Equal(t, 0, calls)
+    s.tickRelative(context.Background(), time.Date(2025, 12, 12, 7, 0, 0, 0, time.UTC), s.lookback)
+    assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,231 @@
+package scheduler
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+
+    "Automated-Scheduling-Project/internal/database/models"
+)
+
+// DateKind is the SQL type of an entity date column.
+type DateKind int
+
+const (
+    // Timestamp columns hold an instant; offsets are applied to it as is.
+    Timestamp DateKind = iota
+    // Date columns hold a calendar day with no time of day or zone, which
+    // relative_time rules take as that day in the rule's timezone.
+    Date
+)
+
+// EntityDateField is a date column relative_time rules can count from.
+type EntityDateField struct {
+    Name    string   // date_field value, also sent in the trigger payload
+    Column  string   // column on the entity's table
+    Kind    DateKind // Date or Timestamp (the default)
+    Aliases []string // other accepted spellings of Name
+}
+
+// EntityJoin pulls related columns into each row, e.g. the event title of an
+// attendance record.
+type EntityJoin struct {
+    Clause string   // e.g. "LEFT JOIN employee ON employee.employeenumber = users.employee_number"
+    Select []string // e.g. "employee.firstname AS employee_first_name"
+}
+
+// RelativeEntity describes a table relative_time rules can target. Adding an
+// entity is a matter of registering one of these.
+type RelativeEntity struct {
+    Type       string // entity_type parameter value
+    Table      string
+    Key        string // primary key column
+    DataKey    string // EvalContext.Data key the row is bound to
+    DateFields []EntityDateField
+    Columns    []string // columns of Table to load; empty loads them all
+    Joins      []EntityJoin
+    // Model, when set, scans rows into this struct type rather than maps so
+    // conditions keep addressing them by Go field name. It cannot be
+    // combined with Columns or Joins.
+    Model any
+}
+
+// DateField looks up a date field by name or alias, case-insensitively.
+func (e RelativeEntity) DateField(name string) (EntityDateField, bool) {
+    name = strings.ToLower(strings.TrimSpace(name))
+    for _, f := range e.DateFields {
+        if name == strings.ToLower(f.Name) {
+            return f, true
+        }
+        for _, a := range f.Aliases {
+            if name == strings.ToLower(a) {
+                return f, true
+            }
+        }
+    }
+    return EntityDateField{}, false
+}
+
+// selects is the SELECT list for map-scanned rows.
+func (e RelativeEntity) selects() []string {
+    out := []string{e.Table + ".*"}
+    if len(e.Columns) > 0 {
+        out = out[:0]
+        for _, c := range e.Columns {
+            out = append(out, e.Table+"."+c)
+        }
+    }
+    for _, j := range e.Joins {
+        out = append(out, j.Select...)
+    }
+    return out
+}
+
+func (e RelativeEntity) validate() error {
+    if e.Type == "" || e.Table == "" || e.Key == "" || e.DataKey == "" {
+        return fmt.Errorf("relative entity needs type, table, key and data key")
+    }
+    if len(e.DateFields) == 0 {
+        return fmt.Errorf("relative entity %q has no date fields", e.Type)
+    }
+    for _, f := range e.DateFields {
+        if f.Name == "" || f.Column == "" {
+            return fmt.Errorf("relative entity %q has a date field without name or column", e.Type)
+        }
+    }
+    if e.Model != nil && (len(e.Columns) > 0 || len(e.Joins) > 0) {
+        return fmt.Errorf("relative entity %q: Model cannot be combined with Columns or Joins", e.Type)
+    }
+    return nil
+}
+
+// defaultEntities are the entities relative_time rules can target out of the
+// box, in the order the trigger metadata lists them.
+var defaultEntities = []RelativeEntity{
+    {
+        Type:    "scheduled_event",
+        Table:   "custom_event_schedules",
+        Key:     "custom_event_schedule_id",
+        DataKey: "scheduledEvent",
+        DateFields: []EntityDateField{
+            {Name: "event_start_date", Column: "event_start_date", Aliases: []string{"eventstartdate"}},
+            {Name: "event_end_date", Column: "event_end_date", Aliases: []string{"eventenddate"}},
+        },
+        Model: models.CustomEventSchedule{},
+    },
+    {
+        Type:       "employee_competency",
+        Table:      "employee_competencies",
+        Key:        "employee_competency_id",
+        DataKey:    "employeeCompetency",
+        DateFields: []EntityDateField{{Name: "expiry_date", Column: "expiry_date", Kind: Date}},
+    },
+    {
+        Type:       "employee",
+        Table:      "employee",
+        Key:        "employeenumber",
+        DataKey:    "employee",
+        DateFields: []EntityDateField{{Name: "termination_date", Column: "terminationdate", Kind: Date, Aliases: []string{"terminationdate"}}},
+    },
+    {
+        Type:    "employment_history",
+        Table:   "employment_history",
+        Key:     "employment_id",
+        DataKey: "employmentHistory",
+        DateFields: []EntityDateField{
+            {Name: "start_date", Column: "start_date", Kind: Date},
+            {Name: "end_date", Column: "end_date", Kind: Date},
+        },
+    },
+    {
+        Type:       "event_attendance",
+        Table:      "event_attendance",
+        Key:        "id",
+        DataKey:    "eventAttendance",
+        DateFields: []EntityDateField{{Name: "check_in_time", Column: "check_in_time", Aliases: []string{"check_in"}}},
+        Joins: []EntityJoin{{
+            Clause: "LEFT JOIN custom_event_schedules ON custom_event_schedules.custom_event_schedule_id = event_attendance.custom_event_schedule_id",
+            Select: []string{"custom_event_schedules.title AS event_title", "custom_event_schedules.event_start_date AS event_start_date"},
+        }},
+    },
+    {
+        Type:       "job_matrix",
+        Table:      "custom_job_matrix",
+        Key:        "custom_matrix_id",
+        DataKey:    "jobMatrix",
+        DateFields: []EntityDateField{{Name: "creation_date", Column: "creation_date"}},
+        Joins: []EntityJoin{{
+            Clause: "LEFT JOIN job_positions ON job_positions.position_matrix_code = custom_job_matrix.position_matrix_code",
+            Select: []string{"job_positions.job_title AS job_title"},
+        }},
+    },
+    {
+        Type:       "user",
+        Table:      "users",
+        Key:        "id",
+        DataKey:    "user",
+        DateFields: []EntityDateField{{Name: "created_at", Column: "created_at"}},
+        // Never load the password hash or reset link into rule data.
+        Columns: []string{"id", "username", "role", "employee_number", "created_at"},
+        Joins: []EntityJoin{{
+            Clause: "LEFT JOIN employee ON employee.employeenumber = users.employee_number",
+            Select: []string{
+                "employee.firstname AS employee_first_name",
+                "employee.lastname AS employee_last_name",
+                "employee.useraccountemail AS employee_email",
+            },
+        }},
+    },
+}
+
+type entityRegistry struct {
+    mu     sync.RWMutex
+    byType map[string]RelativeEntity
+    order  []string
+}
+
+var entities = func() *entityRegistry {
+    r := &entityRegistry{byType: map[string]RelativeEntity{}}
+    for _, e := range defaultEntities {
+        if err := r.register(e); err != nil {
+            panic(err)
+        }
+    }
+    return r
+}()
+
+func (r *entityRegistry) register(e RelativeEntity) error {
+    if err := e.validate(); err != nil {
+        return err
+    }
+    e.Type = strings.ToLower(e.Type)
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if _, ok := r.byType[e.Type]; !ok {
+        r.order = append(r.order, e.Type)
+    }
+    r.byType[e.Type] = e
+    return nil
+}
+
+// RegisterEntity adds or replaces a relative_time entity.
+func RegisterEntity(e RelativeEntity) error { return entities.register(e) }
+
+// LookupEntity returns the registered entity for an entity_type value.
+func LookupEntity(entityType string) (RelativeEntity, bool) {
+    entities.mu.RLock()
+    defer entities.mu.RUnlock()
+    e, ok := entities.byType[strings.ToLower(strings.TrimSpace(entityType))]
+    return e, ok
+}
+
+// Entities lists the registered entities in registration order.
+func Entities() []RelativeEntity {
+    entities.mu.RLock()
+    defer entities.mu.RUnlock()
+    out := make([]RelativeEntity, 0, len(entities.order))
+    for _, t := range entities.order {
+        out = append(out, entities.byType[t])
+    }
+    return out
+}
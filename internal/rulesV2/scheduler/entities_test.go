@@ -0,0 +1,94 @@
+//go:build unit
+
+package scheduler
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestEntities_DefaultsAndLookup(t *testing.T) {
+    var types []string
+    for _, e := range Entities() {
+        types = append(types, e.Type)
+    }
+    assert.Equal(t, []string{"scheduled_event", "employee_competency", "employee", "employment_history", "event_attendance", "job_matrix", "user"}, types)
+
+    e, ok := LookupEntity("Employee")
+    require.True(t, ok)
+    f, ok := e.DateField("TerminationDate")
+    require.True(t, ok)
+    assert.Equal(t, "terminationdate", f.Column)
+
+    assert.Error(t, RegisterEntity(RelativeEntity{Type: "x", Table: "x", Key: "id", DataKey: "x"}))
+    assert.Error(t, RegisterEntity(RelativeEntity{
+        Type: "x", Table: "x", Key: "id", DataKey: "x",
+        DateFields: []EntityDateField{{Name: "d", Column: "d"}},
+        Model:      models.EventAttendance{}, Columns: []string{"id"},
+    }))
+}
+
+func TestTickRelative_JoinedEntity(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.EventAttendance{}))
+    now := time.Now().UTC().Truncate(time.Second)
+    ev := models.CustomEventSchedule{CustomEventID: 1, Title: "Induction", EventStartDate: now.Add(-3 * time.Hour), EventEndDate: now}
+    require.NoError(t, db.Create(&ev).Error)
+    checkIn := now.Add(-90 * time.Minute)
+    require.NoError(t, db.Create(&models.EventAttendance{CustomEventScheduleID: ev.CustomEventScheduleID, EmployeeNumber: "E1", Attended: true, CheckInTime: &checkIn}).Error)
+
+    var rows []map[string]any
+    s := New(db, &fakeStore{rules: []Rule{relativeRule("event_attendance", "check_in_time", "after", 1, "hours")}},
+        func(ev EvalContext, _ any) error {
+            rows = append(rows, ev.Data["eventAttendance"].(map[string]any))
+            return nil
+        })
+    s.tickRelative(context.Background(), now, s.lookback)
+    require.Len(t, rows, 1)
+    assert.Equal(t, "E1", rows[0]["employee_number"])
+    assert.Equal(t, "Induction", rows[0]["event_title"])
+}
+
+func TestTickRelative_RegisteredEntityWithColumns(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.Exec("CREATE TABLE gadgets (gadget_id INTEGER PRIMARY KEY, name TEXT, secret TEXT, due_at DATETIME)").Error)
+    now := time.Now().UTC().Truncate(time.Second)
+    require.NoError(t, db.Exec("INSERT INTO gadgets VALUES (1, 'widget', 'hush', ?)", now.Add(30*time.Minute)).Error)
+
+    require.NoError(t, RegisterEntity(RelativeEntity{
+        Type:       "gadget",
+        Table:      "gadgets",
+        Key:        "gadget_id",
+        DataKey:    "gadget",
+        DateFields: []EntityDateField{{Name: "due_at", Column: "due_at"}},
+        Columns:    []string{"gadget_id", "name", "due_at"},
+    }))
+    t.Cleanup(func() {
+        entities.mu.Lock()
+        defer entities.mu.Unlock()
+        delete(entities.byType, "gadget")
+        entities.order = entities.order[:len(entities.order)-1]
+    })
+
+    var got map[string]any
+    s := New(db, &fakeStore{rules: []Rule{relativeRule("gadget", "due_at", "before", 1, "hours")}},
+        func(ev EvalContext, _ any) error {
+            got = ev.Data["gadget"].(map[string]any)
+            return nil
+        })
+    s.tickRelative(context.Background(), now, s.lookback)
+    require.NotNil(t, got)
+    assert.Equal(t, "widget", got["name"])
+    assert.NotContains(t, got, "secret")
+
+    var f models.RuleFiring
+    require.NoError(t, db.First(&f).Error)
+    assert.Equal(t, "gadget", f.EntityType)
+    assert.Equal(t, "1", f.EntityKey)
+}
@@ -2,6 +2,8 @@ package scheduler
 
 import (
     "context"
+    "encoding/json"
+    "errors"
     "fmt"
     "log"
     "strconv"
@@ -9,25 +11,114 @@ import (
     "time"
 )
 
+// scheduleFixedTimeRules brings the cron entries in line with the enabled
+// scheduled_time rules: new or changed rules are (re)scheduled, entries of
+// rules that are gone or disabled are removed, unchanged ones are left alone.
 func (s *Service) scheduleFixedTimeRules(ctx context.Context) error {
     ruleset, err := s.store.ListByTrigger(ctx, "scheduled_time")
     if err != nil {
         return err
     }
     s.debugf("Scheduling %d scheduled_time rule(s)", len(ruleset))
+    now := time.Now().UTC()
+    seen := make(map[string]bool, len(ruleset))
     for _, r := range ruleset {
-        key := fmt.Sprint(r.ID)
-        if key == "" || key == "<nil>" {
-            // Fallback to name if ID is not available (not ideal but avoids duplicate jobs)
-            key = "name:" + r.Name
+        key := fixedKey(r)
+        // A once-off rule that fired or whose time has passed is left
+        // unscheduled; its cron entry would otherwise repeat every year.
+        if s.onceDone(ctx, key, r.Trigger.Parameters, now) {
+            continue
+        }
+        seen[key] = true
+        s.mu.Lock()
+        unchanged := s.fixedPrints[key] == fixedFingerprint(r.Name, r.Trigger.Parameters, r.Obj)
+        s.mu.Unlock()
+        if unchanged {
+            continue
         }
         if err := s.ScheduleFixedRule(key, r.Name, r.Trigger.Parameters, r.Obj); err != nil {
             log.Printf("failed to schedule rule %q: %v", r.Name, err)
         }
     }
+
+    s.mu.Lock()
+    var stale []string
+    for key := range s.fixedIDs {
+        if !seen[key] {
+            stale = append(stale, key)
+        }
+    }
+    s.mu.Unlock()
+    for _, key := range stale {
+        s.UnscheduleFixedRule(key)
+    }
     return nil
 }
 
+// onceAt returns when a once-off rule fires: its date and time_of_day in
+// its timezone. ok is false for other frequencies and invalid parameters.
+func onceAt(params map[string]any) (time.Time, bool) {
+    freq := strings.ToLower(fmt.Sprint(params["frequency"]))
+    if freq != "once" && freq != "once_off" {
+        return time.Time{}, false
+    }
+    loc, err := LoadTimezone(strFromParams(params, "timezone"))
+    if err != nil {
+        return time.Time{}, false
+    }
+    d, err := time.Parse("2006-01-02", strFromParams(params, "date"))
+    if err != nil {
+        return time.Time{}, false
+    }
+    var h, m int
+    if tod := strFromParams(params, "time_of_day"); tod != "" {
+        tm, err := time.Parse("15:04", tod)
+        if err != nil {
+            return time.Time{}, false
+        }
+        h, m = tm.Hour(), tm.Minute()
+    }
+    return time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, loc).UTC(), true
+}
+
+// onceDone reports whether a once-off rule has nothing left to fire: its
+// time has passed (reconcile catches up a fire missed during downtime) or
+// its recorded last fire is at or after it.
+func (s *Service) onceDone(ctx context.Context, key string, params map[string]any, now time.Time) bool {
+    at, ok := onceAt(params)
+    if !ok {
+        return false
+    }
+    if !at.After(now) {
+        return true
+    }
+    if s.db == nil {
+        return false
+    }
+    last, ok, err := s.lastFired(ctx, key, "scheduled_time")
+    return err == nil && ok && !last.Before(at)
+}
+
+// fixedFingerprint identifies what a cron entry was built from, so reloads
+// only replace entries whose rule changed.
+func fixedFingerprint(name string, params map[string]any, obj any) string {
+    b, err := json.Marshal([]any{name, params, obj})
+    if err != nil {
+        return fmt.Sprintf("%s|%v|%v", name, params, obj)
+    }
+    return string(b)
+}
+
+// fixedKey is the cron bookkeeping key of a scheduled_time rule.
+func fixedKey(r Rule) string {
+    key := fmt.Sprint(r.ID)
+    if key == "" || key == "<nil>" {
+        // Fallback to name if ID is not available (not ideal but avoids duplicate jobs)
+        key = "name:" + r.Name
+    }
+    return key
+}
+
 // ScheduleFixedRule schedules or reschedules a single scheduled_time rule by key.
 // If a job with the same key exists, it will be removed and replaced.
 func (s *Service) ScheduleFixedRule(key, name string, params map[string]any, obj any) error {
@@ -38,41 +129,21 @@ func (s *Service) ScheduleFixedRule(key, name string, params map[string]any, obj
     full := strings.TrimSpace(tzSpec + " " + spec)
     s.debugf("ScheduleFixedRule key=%q name=%q cron=%q (tzPrefix=%q)", key, name, spec, tzSpec)
 
-    // Prepare closure payload snapshot
-    payload := map[string]any{
-        "type":            "scheduled_time",
-        "frequency":       params["frequency"],
-        "minute_of_hour":  params["minute_of_hour"],
-        "time_of_day":     params["time_of_day"],
-        "day_of_week":     params["day_of_week"],
-        "day_of_month":    params["day_of_month"],
-        "cron_expression": params["cron_expression"],
-        "timezone":        params["timezone"],
-        "date":            params["date"],
-    }
-
+    payload := fixedPayload(params)
     freq := strings.ToLower(fmt.Sprint(params["frequency"]))
 
-    // Build job
+    // Build job; every instance keeps the entry but only the leader fires.
     job := func() {
-        start := time.Now()
-        ev := EvalContext{
-            Now: time.Now().UTC(),
-            Data: map[string]any{
-                "trigger": payload,
-            },
-        }
-        s.debugf("FIRE scheduled_time rule %q at %s", name, ev.Now.Format(time.RFC3339))
-        if err := s.eval(ev, obj); err != nil {
-            log.Printf("scheduled_time rule %q failed: %v", name, err)
-        }
-        // If once-off, unschedule after first run
-        if freq == "once" || freq == "once_off" {
-            s.UnscheduleFixedRule(key)
+        if !s.IsLeader() {
+            s.debugf("Skip scheduled_time rule %q: not the scheduler leader", name)
+            return
         }
-        s.debugf("DONE scheduled_time rule %q duration=%s", name, time.Since(start))
+        s.fireFixed(key, name, payload, freq, obj, time.Now().UTC(), nil)
     }
 
+    // A rule seen for the first time has nothing to catch up on.
+    s.seedFired(key, "scheduled_time", time.Now().UTC())
+
     // Swap under lock
     s.mu.Lock()
     defer s.mu.Unlock()
@@ -88,11 +159,63 @@ func (s *Service) ScheduleFixedRule(key, name string, params map[string]any, obj
         return fmt.Errorf("add cron: %w", err)
     }
     s.fixedIDs[key] = id
+    s.fixedPrints[key] = fixedFingerprint(name, params, obj)
     entry := s.cron.Entry(id)
     s.debugf("Scheduled key=%q name=%q id=%d next=%s prev=%s", key, name, id, entry.Next.Format(time.RFC3339), entry.Prev.Format(time.RFC3339))
     return nil
 }
 
+// fixedPayload snapshots the trigger parameters handed to the evaluator.
+func fixedPayload(params map[string]any) map[string]any {
+    return map[string]any{
+        "type":            "scheduled_time",
+        "frequency":       params["frequency"],
+        "minute_of_hour":  params["minute_of_hour"],
+        "time_of_day":     params["time_of_day"],
+        "day_of_week":     params["day_of_week"],
+        "day_of_month":    params["day_of_month"],
+        "cron_expression": params["cron_expression"],
+        "timezone":        params["timezone"],
+        "date":            params["date"],
+        "misfire_policy":  params["misfire_policy"],
+    }
+}
+
+// fireFixed evaluates a scheduled_time rule for the fire due at scheduledFor
+// and records it as the last fire. extra is merged into the
+// trigger payload (catch-up fires mark themselves there).
+func (s *Service) fireFixed(key, name string, payload map[string]any, freq string, obj any, scheduledFor time.Time, extra map[string]any) {
+    start := time.Now()
+    trigger := payload
+    if len(extra) > 0 {
+        trigger = make(map[string]any, len(payload)+len(extra))
+        for k, v := range payload {
+            trigger[k] = v
+        }
+        for k, v := range extra {
+            trigger[k] = v
+        }
+    }
+    ev := EvalContext{
+        Now: time.Now().UTC(),
+        Data: map[string]any{
+            "trigger": trigger,
+        },
+    }
+    s.debugf("FIRE scheduled_time rule %q at %s (scheduled for %s)", name, ev.Now.Format(time.RFC3339), scheduledFor.Format(time.RFC3339))
+    if err := s.eval(ev, obj); err != nil && !errors.Is(err, ErrHeldBack) {
+        log.Printf("scheduled_time rule %q failed: %v", name, err)
+    }
+    // The fire happened even if an action failed (run history keeps the
+    // error); leaving the last fire stale would repeat it on every start.
+    s.markFired(context.Background(), key, "scheduled_time", scheduledFor)
+    // If once-off, unschedule after first run
+    if freq == "once" || freq == "once_off" {
+        s.UnscheduleFixedRule(key)
+    }
+    s.debugf("DONE scheduled_time rule %q duration=%s", name, time.Since(start))
+}
+
 // UnscheduleFixedRule removes a scheduled_time cron entry for the given key (if present).
 func (s *Service) UnscheduleFixedRule(key string) {
     s.mu.Lock()
@@ -100,6 +223,7 @@ func (s *Service) UnscheduleFixedRule(key string) {
     if id, ok := s.fixedIDs[key]; ok {
         s.cron.Remove(id)
         delete(s.fixedIDs, key)
+        delete(s.fixedPrints, key)
         s.debugf("Unscheduled key=%q", key)
     }
 }
@@ -109,17 +233,9 @@ func cronSpecFromParams(p map[string]any) (string, string, error) {
     freq := strings.ToLower(fmt.Sprint(p["frequency"]))
     tz := strFromParams(p, "timezone")
 
-    tzPrefix := ""
-    // Prefer UTC offset forms like "UTC+2"/"UTC-5"
-    if tz != "" {
-        if strings.HasPrefix(strings.ToUpper(tz), "UTC") {
-            if pref, err := tzPrefixFromUTCOffset(tz); err == nil && pref != "" {
-                tzPrefix = pref
-            }
-        } else if !strings.EqualFold(tz, "utc") {
-            // Backward compatibility with IANA names (if any still stored)
-            tzPrefix = "CRON_TZ=" + tz
-        }
+    tzPrefix, err := cronTZPrefix(tz)
+    if err != nil {
+        return "", "", err
     }
 
     parseTimeOfDay := func() (int, int, error) {
@@ -209,41 +325,43 @@ func strFromParams(p map[string]any, key string) string {
 }
 
 func tzPrefixFromUTCOffset(s string) (string, error) {
-    // Accept forms: "UTC+0", "UTC-5", "utc+3"
-    v := strings.ToUpper(strings.TrimSpace(s))
-    if v == "UTC" || v == "UTC+0" || v == "UTC-0" {
-        return "", nil // UTC -> no prefix (we construct cron with UTC base)
+    n, err := utcOffsetHours(s)
+    if err != nil || n == 0 {
+        return "", err // UTC -> no prefix (we construct cron with UTC base)
     }
+    // IANA Etc/GMT zones invert the sign: UTC+2 => Etc/GMT-2, UTC-5 => Etc/GMT+5
+    if n > 0 {
+        return "CRON_TZ=Etc/GMT-" + strconv.Itoa(n), nil
+    }
+    return "CRON_TZ=Etc/GMT+" + strconv.Itoa(-n), nil
+}
+
+// utcOffsetHours parses "UTC", "UTC+2", "utc-5" into signed whole hours.
+func utcOffsetHours(s string) (int, error) {
+    v := strings.ToUpper(strings.TrimSpace(s))
     if !strings.HasPrefix(v, "UTC") {
-        return "", fmt.Errorf("not a UTC offset")
+        return 0, fmt.Errorf("not a UTC offset")
     }
     off := strings.TrimPrefix(v, "UTC")
     if off == "" {
-        return "", nil
+        return 0, nil
     }
-    sign := "+"
+    sign := 1
     if strings.HasPrefix(off, "+") {
-        sign = "+"
         off = strings.TrimPrefix(off, "+")
     } else if strings.HasPrefix(off, "-") {
-        sign = "-"
+        sign = -1
         off = strings.TrimPrefix(off, "-")
     } else {
-        return "", fmt.Errorf("invalid UTC offset format")
+        return 0, fmt.Errorf("invalid UTC offset format")
     }
     n, err := strconv.Atoi(off)
     if err != nil {
-        return "", fmt.Errorf("invalid UTC offset number: %w", err)
+        return 0, fmt.Errorf("invalid UTC offset number: %w", err)
     }
-    if n < 0 || n > 14 {
-        return "", fmt.Errorf("UTC offset out of range")
+    // Real-world offsets run from UTC-12 to UTC+14.
+    if n < 0 || n > 14 || (sign < 0 && n > 12) {
+        return 0, fmt.Errorf("UTC offset out of range")
     }
-    // IANA Etc/GMT zones invert the sign: UTC+2 => Etc/GMT-2, UTC-5 => Etc/GMT+5
-    etcSign := "+"
-    if sign == "+" {
-        etcSign = "-"
-    } else {
-        etcSign = "+"
-    }
-    return "CRON_TZ=Etc/GMT" + etcSign + strconv.Itoa(n), nil
-}
\ No newline at end of file
+    return sign * n, nil
+}
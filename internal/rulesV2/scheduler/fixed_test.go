@@ -4,6 +4,7 @@ package scheduler
 
 import (
     "testing"
+    "time"
 
     "github.com/stretchr/testify/assert"
 )
@@ -98,6 +99,29 @@ func TestCronSpecFromParams_Cron(t *testing.T) {
     assert.Equal(t, "CRON_TZ=Etc/GMT+3", tz)
 }
 
+func TestCronSpecFromParams_IANATimezone(t *testing.T) {
+    params := map[string]any{
+        "frequency":   "daily",
+        "time_of_day": "09:00",
+        "timezone":    "Europe/London",
+    }
+    spec, tz, err := cronSpecFromParams(params)
+    assert.NoError(t, err)
+    assert.Equal(t, "0 9 * * *", spec)
+    assert.Equal(t, "CRON_TZ=Europe/London", tz)
+
+    // The local time holds across the October DST change.
+    s := New(nil, nil, nil)
+    sched, err := s.parser.Parse(tz + " " + spec)
+    assert.NoError(t, err)
+    assert.Equal(t, time.Date(2025, 10, 25, 8, 0, 0, 0, time.UTC), sched.Next(time.Date(2025, 10, 24, 12, 0, 0, 0, time.UTC)).UTC())
+    assert.Equal(t, time.Date(2025, 10, 27, 9, 0, 0, 0, time.UTC), sched.Next(time.Date(2025, 10, 26, 12, 0, 0, 0, time.UTC)).UTC())
+
+    params["timezone"] = "Europe/Atlantis"
+    _, _, err = cronSpecFromParams(params)
+    assert.ErrorContains(t, err, "unknown timezone")
+}
+
 func TestCronSpecFromParams_Errors(t *testing.T) {
     _, _, err := cronSpecFromParams(map[string]any{
         "frequency":      "hourly",
@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+)
+
+// FixedSchedule describes when a scheduled_time rule fires. Prev is the
+// latest fire time due at or before now, whether or not it actually ran.
+type FixedSchedule struct {
+    RuleID        string     `json:"ruleId"`
+    Name          string     `json:"name"`
+    Frequency     string     `json:"frequency"`
+    Cron          string     `json:"cron,omitempty"`
+    Timezone      string     `json:"timezone"`
+    MisfirePolicy string     `json:"misfirePolicy"`
+    Next          *time.Time `json:"next,omitempty"`
+    Prev          *time.Time `json:"prev,omitempty"`
+    Error         string     `json:"error,omitempty"`
+}
+
+// UpcomingFire is one occurrence a relative_time rule will fire for.
+type UpcomingFire struct {
+    EntityType string    `json:"entityType"`
+    EntityKey  string    `json:"entityKey"`
+    TargetDate time.Time `json:"targetDate"`
+    FireAt     time.Time `json:"fireAt"`
+    Entity     any       `json:"entity"`
+}
+
+// RelativePreview lists the upcoming fires of one relative_time rule.
+type RelativePreview struct {
+    RuleID          string         `json:"ruleId"`
+    Name            string         `json:"name"`
+    EntityType      string         `json:"entityType"`
+    DateField       string         `json:"dateField"`
+    OffsetDirection string         `json:"offsetDirection"`
+    Offset          string         `json:"offset"`
+    Upcoming        []UpcomingFire `json:"upcoming"`
+    Error           string         `json:"error,omitempty"`
+}
+
+// prevLookbacks are the windows searched, smallest first, for a schedule's
+// previous fire; walking a short window keeps frequent crons cheap.
+var prevLookbacks = []time.Duration{time.Hour, 24 * time.Hour, 32 * 24 * time.Hour, 367 * 24 * time.Hour}
+
+// FixedSchedules lists every enabled scheduled_time rule with its cron spec
+// and the fire times around now. It reads the rules from the store, so the
+// answer does not depend on which instance currently leads.
+func (s *Service) FixedSchedules(ctx context.Context, now time.Time) ([]FixedSchedule, error) {
+    ruleset, err := s.store.ListByTrigger(ctx, "scheduled_time")
+    if err != nil {
+        return nil, err
+    }
+    out := make([]FixedSchedule, 0, len(ruleset))
+    for _, r := range ruleset {
+        p := r.Trigger.Parameters
+        fs := FixedSchedule{
+            RuleID:    fixedKey(r),
+            Name:      r.Name,
+            Frequency: strFromParams(p, "frequency"),
+            Timezone:  strFromParams(p, "timezone"),
+        }
+        if fs.Timezone == "" {
+            fs.Timezone = "UTC"
+        }
+        policy, err := misfirePolicy(p)
+        if err != nil {
+            fs.Error = err.Error()
+            out = append(out, fs)
+            continue
+        }
+        fs.MisfirePolicy = policy
+
+        spec, tzSpec, err := cronSpecFromParams(p)
+        if err != nil {
+            fs.Error = err.Error()
+            out = append(out, fs)
+            continue
+        }
+        fs.Cron = spec
+        sched, err := s.parser.Parse(strings.TrimSpace(tzSpec + " " + spec))
+        if err != nil {
+            fs.Error = err.Error()
+            out = append(out, fs)
+            continue
+        }
+        if next := sched.Next(now); !next.IsZero() {
+            next = next.UTC()
+            fs.Next = &next
+        }
+        for _, lb := range prevLookbacks {
+            var prev time.Time
+            for t := sched.Next(now.Add(-lb)); !t.IsZero() && !t.After(now); t = sched.Next(t) {
+                prev = t
+            }
+            if !prev.IsZero() {
+                prev = prev.UTC()
+                fs.Prev = &prev
+                break
+            }
+        }
+        out = append(out, fs)
+    }
+    return out, nil
+}
+
+// PreviewRelative lists, per enabled relative_time rule, the occurrences it
+// has not fired for yet whose fire time falls within horizon after now.
+func (s *Service) PreviewRelative(ctx context.Context, now time.Time, horizon time.Duration) ([]RelativePreview, error) {
+    ruleset, err := s.store.ListByTrigger(ctx, "relative_time")
+    if err != nil {
+        return nil, err
+    }
+    out := make([]RelativePreview, 0, len(ruleset))
+    for _, r := range ruleset {
+        p := r.Trigger.Parameters
+        rp := RelativePreview{
+            RuleID:   ruleKey(r),
+            Name:     r.Name,
+            Offset:   strings.TrimSpace(fmt.Sprint(p["offset_value"]) + " " + strFromParams(p, "offset_unit")),
+            Upcoming: []UpcomingFire{},
+        }
+        sp, err := s.loadRelative(ctx, p)
+        if err == nil {
+            rp.EntityType, rp.DateField, rp.OffsetDirection = sp.entityType, sp.dateField, sp.dir
+            var hits []relativeHit
+            if hits, err = s.dueHits(ctx, r, sp, now, now.Add(horizon), true); err == nil {
+                for _, h := range hits {
+                    rp.Upcoming = append(rp.Upcoming, UpcomingFire{
+                        EntityType: h.entityType,
+                        EntityKey:  h.entityKey,
+                        TargetDate: h.target,
+                        FireAt:     sp.fireTime(h.target, h.kind),
+                        Entity:     h.row,
+                    })
+                }
+            }
+        }
+        if err != nil {
+            rp.Error = err.Error()
+        }
+        out = append(out, rp)
+    }
+    return out, nil
+}
+
+// ManualTrigger builds the trigger payload a time-based rule sees when it is
+// run on demand rather than by the scheduler. The payload carries the rule's
+// own parameters (so trigger matching passes) and "manual": true.
+func ManualTrigger(t TriggerSpec) (map[string]any, error) {
+    var trig map[string]any
+    switch t.Type {
+    case "scheduled_time":
+        trig = fixedPayload(t.Parameters)
+    case "relative_time":
+        sp, err := parseRelative(t.Parameters)
+        if err != nil {
+            return nil, err
+        }
+        trig = relativeTrigger(t.Parameters, sp)
+    default:
+        return nil, fmt.Errorf("trigger %q is not time-based", t.Type)
+    }
+    trig["manual"] = true
+    return trig, nil
+}
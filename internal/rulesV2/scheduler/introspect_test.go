@@ -0,0 +1,85 @@
+//go:build unit
+
+package scheduler
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestFixedSchedules_NextAndPrev(t *testing.T) {
+    weekly := Rule{ID: "2", Name: "weekly", Trigger: TriggerSpec{Type: "scheduled_time", Parameters: map[string]any{
+        "frequency": "weekly", "day_of_week": 1, "time_of_day": "09:30", "timezone": "UTC+2",
+    }}}
+    broken := Rule{ID: "3", Name: "broken", Trigger: TriggerSpec{Type: "scheduled_time", Parameters: map[string]any{"frequency": "fortnightly"}}}
+    s := New(nil, &fakeStore{rules: []Rule{dailyRule(""), weekly, broken}}, nil)
+
+    now := time.Date(2025, 9, 10, 12, 0, 0, 0, time.UTC) // a Wednesday
+    out, err := s.FixedSchedules(context.Background(), now)
+    require.NoError(t, err)
+    require.Len(t, out, 3)
+
+    assert.Equal(t, "0 8 * * *", out[0].Cron)
+    assert.Equal(t, "UTC", out[0].Timezone)
+    assert.Equal(t, MisfireFireOnce, out[0].MisfirePolicy)
+    assert.Equal(t, time.Date(2025, 9, 11, 8, 0, 0, 0, time.UTC), *out[0].Next)
+    assert.Equal(t, time.Date(2025, 9, 10, 8, 0, 0, 0, time.UTC), *out[0].Prev)
+
+    // 09:30 at UTC+2 on Mondays is 07:30 UTC.
+    assert.Equal(t, time.Date(2025, 9, 15, 7, 30, 0, 0, time.UTC), *out[1].Next)
+    assert.Equal(t, time.Date(2025, 9, 8, 7, 30, 0, 0, time.UTC), *out[1].Prev)
+
+    assert.Contains(t, out[2].Error, "unknown frequency")
+    assert.Nil(t, out[2].Next)
+}
+
+func TestPreviewRelative_ListsUnfiredWithinHorizon(t *testing.T) {
+    db := newLedgerDB(t)
+    now := time.Now().UTC().Truncate(time.Second)
+    soon := models.CustomEventSchedule{CustomEventID: 1, Title: "soon", EventStartDate: now.Add(3 * 24 * time.Hour), EventEndDate: now.Add(4 * 24 * time.Hour)}
+    far := models.CustomEventSchedule{CustomEventID: 1, Title: "far", EventStartDate: now.Add(20 * 24 * time.Hour), EventEndDate: now.Add(21 * 24 * time.Hour)}
+    require.NoError(t, db.Create(&soon).Error)
+    require.NoError(t, db.Create(&far).Error)
+
+    rule := relativeRule("scheduled_event", "event_start_date", "before", 1, "days")
+    bad := Rule{ID: "10", Name: "bad", Trigger: TriggerSpec{Type: "relative_time", Parameters: map[string]any{"entity_type": "employee"}}}
+    s := New(db, &fakeStore{rules: []Rule{rule, bad}}, nil)
+
+    out, err := s.PreviewRelative(context.Background(), now, 7*24*time.Hour)
+    require.NoError(t, err)
+    require.Len(t, out, 2)
+    require.Len(t, out[0].Upcoming, 1)
+    up := out[0].Upcoming[0]
+    assert.Equal(t, "scheduled_event", up.EntityType)
+    assert.Equal(t, soon.EventStartDate.Add(-24*time.Hour), up.FireAt)
+    assert.Equal(t, "1 days", out[0].Offset)
+    assert.Contains(t, out[1].Error, "missing params")
+
+    // Already fired occurrences drop out of the preview.
+    _, err = s.claim(context.Background(), rule, "scheduled_event", up.EntityKey, up.TargetDate, "")
+    require.NoError(t, err)
+    out, err = s.PreviewRelative(context.Background(), now, 7*24*time.Hour)
+    require.NoError(t, err)
+    assert.Empty(t, out[0].Upcoming)
+}
+
+func TestManualTrigger(t *testing.T) {
+    trig, err := ManualTrigger(relativeRule("employee", "termination_date", "after", 2, "days").Trigger)
+    require.NoError(t, err)
+    assert.Equal(t, true, trig["manual"])
+    assert.Equal(t, "relative_time", trig["type"])
+    assert.Equal(t, "termination_date", trig["date_field"])
+
+    trig, err = ManualTrigger(dailyRule("skip").Trigger)
+    require.NoError(t, err)
+    assert.Equal(t, "skip", trig["misfire_policy"])
+
+    _, err = ManualTrigger(TriggerSpec{Type: "job_position"})
+    assert.Error(t, err)
+}
@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// leaseName is the scheduler_leases row all instances compete for.
+const leaseName = "rules_scheduler"
+
+// defaultLeaseTTL is how long a lease survives without renewal; the holder
+// renews every third of it, so a dead leader is replaced within one TTL.
+const defaultLeaseTTL = 30 * time.Second
+
+// LeaderStatus describes this instance's view of scheduler leadership.
+type LeaderStatus struct {
+    Instance       string    `json:"instance"`
+    Leader         bool      `json:"leader"`
+    Holder         string    `json:"holder,omitempty"`
+    AcquiredAt     time.Time `json:"acquiredAt,omitempty"`
+    LeaseExpiresAt time.Time `json:"leaseExpiresAt,omitempty"`
+}
+
+func defaultInstanceID() string {
+    host, _ := os.Hostname()
+    return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// IsLeader reports whether this instance currently runs time-based triggers.
+// Without a database there is nothing to share, so the instance always leads.
+func (s *Service) IsLeader() bool {
+    return s.db == nil || s.leader.Load()
+}
+
+// tryAcquire takes or renews the lease. It succeeds when the lease row is
+// new, already ours, or expired.
+func (s *Service) tryAcquire(ctx context.Context, now time.Time) (bool, error) {
+    lease := models.SchedulerLease{
+        Name:       leaseName,
+        Holder:     s.instance,
+        AcquiredAt: now,
+        RenewedAt:  now,
+        ExpiresAt:  now.Add(s.leaseTTL),
+    }
+    res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&lease)
+    if res.Error != nil {
+        return false, res.Error
+    }
+    if res.RowsAffected == 1 {
+        return true, nil
+    }
+
+    // Renew our own lease, or take over an expired one.
+    res = s.db.WithContext(ctx).Model(&models.SchedulerLease{}).
+        Where("name = ? AND holder = ?", leaseName, s.instance).
+        Updates(map[string]any{"renewed_at": now, "expires_at": now.Add(s.leaseTTL)})
+    if res.Error != nil {
+        return false, res.Error
+    }
+    if res.RowsAffected == 1 {
+        return true, nil
+    }
+    res = s.db.WithContext(ctx).Model(&models.SchedulerLease{}).
+        Where("name = ? AND expires_at < ?", leaseName, now).
+        Updates(map[string]any{"holder": s.instance, "acquired_at": now, "renewed_at": now, "expires_at": now.Add(s.leaseTTL)})
+    if res.Error != nil {
+        return false, res.Error
+    }
+    return res.RowsAffected == 1, nil
+}
+
+// campaign runs one election round and handles leadership changes. A new
+// leader starts catching up on what the previous one missed and loads the
+// scheduled_time rules; the leader also reloads them every round so rule
+// edits made through other instances take effect.
+func (s *Service) campaign(ctx context.Context) error {
+    now := time.Now().UTC()
+    won, err := s.tryAcquire(ctx, now)
+    if err != nil {
+        if s.leader.Swap(false) {
+            log.Printf("[rulesv2/scheduler] %s lost scheduler lease: %v", s.instance, err)
+        }
+        return err
+    }
+    was := s.leader.Swap(won)
+    switch {
+    case won && !was:
+        log.Printf("[rulesv2/scheduler] %s acquired scheduler lease", s.instance)
+        s.startCatchUp(ctx, now)
+    case !won && was:
+        log.Printf("[rulesv2/scheduler] %s lost scheduler lease", s.instance)
+    }
+    if won {
+        return s.scheduleFixedTimeRules(ctx)
+    }
+    return nil
+}
+
+func (s *Service) runLeaseLoop(ctx context.Context) {
+    t := time.NewTicker(s.leaseTTL / 3)
+    defer t.Stop()
+    defer close(s.leaseClosed)
+
+    for {
+        select {
+        case <-s.stop:
+            s.releaseLease()
+            return
+        case <-t.C:
+            if err := s.campaign(ctx); err != nil {
+                log.Printf("[rulesv2/scheduler] lease error: %v", err)
+            }
+        }
+    }
+}
+
+// releaseLease gives up leadership on shutdown so another instance can take
+// over without waiting for the lease to expire.
+func (s *Service) releaseLease() {
+    if !s.leader.Swap(false) {
+        return
+    }
+    err := s.db.Model(&models.SchedulerLease{}).
+        Where("name = ? AND holder = ?", leaseName, s.instance).
+        Update("expires_at", time.Now().UTC().Add(-time.Second)).Error
+    if err != nil {
+        log.Printf("[rulesv2/scheduler] release lease: %v", err)
+    }
+}
+
+// LeaderStatus reports whether this instance leads and who holds the lease.
+func (s *Service) LeaderStatus(ctx context.Context) (LeaderStatus, error) {
+    st := LeaderStatus{Instance: s.instance, Leader: s.IsLeader()}
+    if s.db == nil {
+        st.Holder = s.instance
+        return st, nil
+    }
+    var lease models.SchedulerLease
+    err := s.db.WithContext(ctx).Where("name = ?", leaseName).First(&lease).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return st, nil
+    }
+    if err != nil {
+        return st, err
+    }
+    if lease.ExpiresAt.After(time.Now().UTC()) {
+        st.Holder = lease.Holder
+        st.AcquiredAt = lease.AcquiredAt
+        st.LeaseExpiresAt = lease.ExpiresAt
+    }
+    return st, nil
+}
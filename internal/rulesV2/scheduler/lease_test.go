@@ -0,0 +1,110 @@
+//go:build unit
+
+package scheduler
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "gorm.io/gorm"
+)
+
+func newReplica(db *gorm.DB, instance string, store RuleStore, eval EvaluateFunc) *Service {
+    s := New(db, store, eval)
+    s.instance = instance
+    return s
+}
+
+func TestLease_SingleLeaderAndFailover(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.SchedulerLease{}, &models.RuleTriggerState{}))
+    ctx := context.Background()
+    store := &fakeStore{}
+    noop := func(EvalContext, any) error { return nil }
+
+    a := newReplica(db, "a", store, noop)
+    b := newReplica(db, "b", store, noop)
+
+    require.NoError(t, a.campaign(ctx))
+    require.NoError(t, b.campaign(ctx))
+    assert.True(t, a.IsLeader())
+    assert.False(t, b.IsLeader())
+
+    st, err := b.LeaderStatus(ctx)
+    require.NoError(t, err)
+    assert.Equal(t, "b", st.Instance)
+    assert.False(t, st.Leader)
+    assert.Equal(t, "a", st.Holder)
+
+    // a stops renewing: b takes over once the lease expires, and a cannot renew.
+    later := time.Now().UTC().Add(a.leaseTTL + time.Second)
+    ok, err := b.tryAcquire(ctx, later)
+    require.NoError(t, err)
+    assert.True(t, ok)
+    ok, err = a.tryAcquire(ctx, later.Add(time.Second))
+    require.NoError(t, err)
+    assert.False(t, ok)
+}
+
+func TestLease_ReleaseHandsOverImmediately(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.SchedulerLease{}, &models.RuleTriggerState{}))
+    ctx := context.Background()
+    noop := func(EvalContext, any) error { return nil }
+
+    a := newReplica(db, "a", &fakeStore{}, noop)
+    b := newReplica(db, "b", &fakeStore{}, noop)
+    require.NoError(t, a.campaign(ctx))
+    a.releaseLease()
+    assert.False(t, a.IsLeader())
+
+    require.NoError(t, b.campaign(ctx))
+    assert.True(t, b.IsLeader())
+}
+
+func TestLease_FollowerDoesNotFireCron(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.SchedulerLease{}, &models.RuleTriggerState{}))
+    ctx := context.Background()
+    rule := Rule{ID: "1", Name: "hourly", Trigger: TriggerSpec{Type: "scheduled_time", Parameters: map[string]any{"frequency": "hourly", "minute_of_hour": 0}}}
+    store := &fakeStore{rules: []Rule{rule}}
+
+    calls := map[string]int{}
+    eval := func(name string) EvaluateFunc {
+        return func(EvalContext, any) error { calls[name]++; return nil }
+    }
+    a := newReplica(db, "a", store, eval("a"))
+    b := newReplica(db, "b", store, eval("b"))
+    require.NoError(t, a.campaign(ctx))
+    require.NoError(t, b.campaign(ctx))
+    require.NoError(t, b.ScheduleFixedRule("1", rule.Name, rule.Trigger.Parameters, nil))
+
+    a.cron.Entry(a.fixedIDs["1"]).Job.Run()
+    b.cron.Entry(b.fixedIDs["1"]).Job.Run()
+    assert.Equal(t, map[string]int{"a": 1}, calls)
+}
+
+func TestScheduleFixedTimeRules_Resync(t *testing.T) {
+    keep := Rule{ID: "1", Name: "keep", Trigger: TriggerSpec{Type: "scheduled_time", Parameters: map[string]any{"frequency": "hourly", "minute_of_hour": 5}}}
+    gone := Rule{ID: "2", Name: "gone", Trigger: TriggerSpec{Type: "scheduled_time", Parameters: map[string]any{"frequency": "hourly", "minute_of_hour": 10}}}
+    store := &fakeStore{rules: []Rule{keep, gone}}
+    s := New(nil, store, func(EvalContext, any) error { return nil })
+
+    require.NoError(t, s.scheduleFixedTimeRules(context.Background()))
+    keepID := s.fixedIDs["1"]
+
+    store.rules = []Rule{keep}
+    require.NoError(t, s.scheduleFixedTimeRules(context.Background()))
+    assert.Equal(t, keepID, s.fixedIDs["1"], "unchanged rule keeps its cron entry")
+    assert.NotContains(t, s.fixedIDs, "2")
+
+    keep.Trigger.Parameters = map[string]any{"frequency": "hourly", "minute_of_hour": 20}
+    store.rules = []Rule{keep}
+    require.NoError(t, s.scheduleFixedTimeRules(context.Background()))
+    assert.NotEqual(t, keepID, s.fixedIDs["1"], "changed rule is rescheduled")
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// defaultLookback is how far back the relative poller picks up occurrences
+// that became due but never fired, e.g. while no replica was running.
+const defaultLookback = 24 * time.Hour
+
+// notFired restricts q to rows rule r has no ledger entry for. keyExpr and
+// dateCol are qualified column names of the queried table.
+func (s *Service) notFired(q *gorm.DB, r Rule, entityType, keyExpr, dateCol string) *gorm.DB {
+    return q.Where("NOT EXISTS (SELECT 1 FROM rule_firings f WHERE f.rule_id = ? AND f.entity_type = ?"+
+        " AND f.entity_key = CAST("+keyExpr+" AS TEXT) AND f.target_date = "+dateCol+")",
+        ruleKey(r), entityType)
+}
+
+// claim inserts the ledger row for one occurrence. It returns the row id, or
+// 0 when the occurrence was already claimed (by this or another replica).
+// note is stored as the row's error, e.g. for occurrences skipped on purpose.
+func (s *Service) claim(ctx context.Context, r Rule, entityType, entityKey string, target time.Time, note string) (uint, error) {
+    f := models.RuleFiring{
+        RuleID:     ruleKey(r),
+        EntityType: entityType,
+        EntityKey:  entityKey,
+        TargetDate: target,
+        FiredAt:    time.Now().UTC(),
+        Error:      note,
+    }
+    res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&f)
+    if res.Error != nil {
+        return 0, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return 0, nil
+    }
+    return f.ID, nil
+}
+
+// fireOnce claims the occurrence and evaluates the rule only if the claim
+// succeeded. A failed evaluation stays claimed (actions may have run) and the
+// error is kept on the ledger row. A rule held back for now (ErrHeldBack)
+// gives the claim up again, so the reminder fires once the rule may fire.
+// Under a skip misfire reconcile the occurrence is claimed without
+// evaluating.
+func (s *Service) fireOnce(ctx context.Context, r Rule, entityType, entityKey string, target time.Time, ev EvalContext) {
+    if skippingMisfires(ctx) {
+        if _, err := s.claim(ctx, r, entityType, entityKey, target, skippedNote); err != nil {
+            log.Printf("relative_time rule %q: ledger claim for %s %s failed: %v", r.Name, entityType, entityKey, err)
+        }
+        s.debugf("Skip missed relative_time rule %q %s=%s target=%s", r.Name, entityType, entityKey, target.Format(time.RFC3339))
+        return
+    }
+    id, err := s.claim(ctx, r, entityType, entityKey, target, "")
+    if err != nil {
+        log.Printf("relative_time rule %q: ledger claim for %s %s failed: %v", r.Name, entityType, entityKey, err)
+        return
+    }
+    if id == 0 {
+        s.debugf("Skip relative_time rule %q %s=%s target=%s: already fired", r.Name, entityType, entityKey, target.Format(time.RFC3339))
+        return
+    }
+    s.debugf("FIRE relative_time rule %q %s=%s target=%s at %s", r.Name, entityType, entityKey, target.Format(time.RFC3339), ev.Now.Format(time.RFC3339))
+    err = s.eval(ev, r.Obj)
+    if errors.Is(err, ErrHeldBack) {
+        s.debugf("Release relative_time rule %q %s=%s target=%s: %v", r.Name, entityType, entityKey, target.Format(time.RFC3339), err)
+        if derr := s.db.WithContext(ctx).Delete(&models.RuleFiring{}, id).Error; derr != nil {
+            log.Printf("relative_time rule %q: failed to release ledger claim: %v", r.Name, derr)
+        }
+        return
+    }
+    if err != nil {
+        log.Printf("relative_time rule %q failed (%s %s): %v", r.Name, entityType, entityKey, err)
+        if uerr := s.db.WithContext(ctx).Model(&models.RuleFiring{}).Where("id = ?", id).Update("error", err.Error()).Error; uerr != nil {
+            log.Printf("relative_time rule %q: failed to save error on ledger: %v", r.Name, uerr)
+        }
+    }
+}
+
+func ruleKey(r Rule) string { return fmt.Sprint(r.ID) }
+
+// timeFromAny reads a date column from a map scan; drivers return either
+// time.Time or text.
+func timeFromAny(v any) (time.Time, bool) {
+    switch t := v.(type) {
+    case time.Time:
+        return t, true
+    case *time.Time:
+        if t == nil {
+            return time.Time{}, false
+        }
+        return *t, true
+    case string:
+        for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05", "2006-01-02"} {
+            if ts, err := time.Parse(layout, t); err == nil {
+                return ts, true
+            }
+        }
+    }
+    return time.Time{}, false
+}
@@ -0,0 +1,130 @@
+//go:build unit
+
+package scheduler
+
+import (
+    "context"
+    "fmt"
+    "testing"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+)
+
+func newLedgerDB(t *testing.T) *gorm.DB {
+    t.Helper()
+    db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+    require.NoError(t, err)
+    require.NoError(t, db.AutoMigrate(&models.CustomEventSchedule{}, &models.EmploymentHistory{}, &models.RuleFiring{}))
+    return db
+}
+
+func relativeRule(entityType, dateField, dir string, value int, unit string) Rule {
+    return Rule{ID: "9", Name: "reminder", Trigger: TriggerSpec{Type: "relative_time", Parameters: map[string]any{
+        "entity_type":      entityType,
+        "date_field":       dateField,
+        "offset_direction": dir,
+        "offset_value":     value,
+        "offset_unit":      unit,
+    }}}
+}
+
+func TestTickRelative_FiresEachOccurrenceOnce(t *testing.T) {
+    db := newLedgerDB(t)
+    now := time.Now().UTC().Truncate(time.Second)
+
+    due := models.CustomEventSchedule{CustomEventID: 1, Title: "due", EventStartDate: now.Add(90 * time.Minute), EventEndDate: now.Add(3 * time.Hour)}
+    later := models.CustomEventSchedule{CustomEventID: 1, Title: "later", EventStartDate: now.Add(5 * time.Hour), EventEndDate: now.Add(6 * time.Hour)}
+    stale := models.CustomEventSchedule{CustomEventID: 1, Title: "stale", EventStartDate: now.Add(-48 * time.Hour), EventEndDate: now.Add(-47 * time.Hour)}
+    require.NoError(t, db.Create(&due).Error)
+    require.NoError(t, db.Create(&later).Error)
+    require.NoError(t, db.Create(&stale).Error)
+
+    var fired []string
+    eval := func(ev EvalContext, _ any) error {
+        fired = append(fired, ev.Data["scheduledEvent"].(models.CustomEventSchedule).Title)
+        return nil
+    }
+    rule := relativeRule("scheduled_event", "event_start_date", "before", 2, "hours")
+    store := &fakeStore{rules: []Rule{rule}}
+    a := New(db, store, eval)
+    b := New(db, store, eval) // second replica sharing the ledger
+
+    // Fire time of "due" (start-2h) already passed 30 minutes ago; repeated
+    // and concurrent ticks must not fire it again.
+    a.tickRelative(context.Background(), now, a.lookback)
+    b.tickRelative(context.Background(), now, b.lookback)
+    a.tickRelative(context.Background(), now.Add(time.Minute), a.lookback)
+    assert.Equal(t, []string{"due"}, fired)
+
+    // "later" becomes due once its fire time is reached, even with a coarse tick.
+    a.tickRelative(context.Background(), now.Add(4*time.Hour), a.lookback)
+    assert.Equal(t, []string{"due", "later"}, fired)
+
+    // Rescheduling is a new occurrence.
+    require.NoError(t, db.Model(&due).Update("event_start_date", now.Add(4*time.Hour+30*time.Minute)).Error)
+    a.tickRelative(context.Background(), now.Add(4*time.Hour), a.lookback)
+    assert.Equal(t, []string{"due", "later", "due"}, fired)
+
+    var n int64
+    require.NoError(t, db.Model(&models.RuleFiring{}).Count(&n).Error)
+    assert.EqualValues(t, 3, n)
+}
+
+func TestTickRelative_MapRowsUseLedger(t *testing.T) {
+    db := newLedgerDB(t)
+    now := time.Now().UTC().Truncate(time.Second)
+    require.NoError(t, db.Create(&models.EmploymentHistory{
+        EmploymentID: 4, EmployeeNumber: "E1", PositionMatrixCode: "P", StartDate: now.Add(-25 * time.Hour),
+    }).Error)
+
+    calls := 0
+    s := New(db, &fakeStore{rules: []Rule{relativeRule("employment_history", "start_date", "after", 1, "days")}},
+        func(EvalContext, any) error { calls++; return nil })
+    s.tickRelative(context.Background(), now, s.lookback)
+    s.tickRelative(context.Background(), now.Add(time.Hour), s.lookback)
+    assert.Equal(t, 1, calls)
+
+    var f models.RuleFiring
+    require.NoError(t, db.First(&f).Error)
+    assert.Equal(t, "9", f.RuleID)
+    assert.Equal(t, "employment_history", f.EntityType)
+    assert.Equal(t, "4", f.EntityKey)
+}
+
+func TestFireOnce_HeldBackReleasesClaim(t *testing.T) {
+    db := newLedgerDB(t)
+    now := time.Now().UTC().Truncate(time.Second)
+    require.NoError(t, db.Create(&models.CustomEventSchedule{CustomEventID: 1, Title: "due", EventStartDate: now.Add(time.Hour), EventEndDate: now.Add(2 * time.Hour)}).Error)
+
+    held := true
+    calls := 0
+    eval := func(EvalContext, any) error {
+        calls++
+        if held {
+            return fmt.Errorf("outside the rule's active windows: %w", ErrHeldBack)
+        }
+        return nil
+    }
+    s := New(db, &fakeStore{rules: []Rule{relativeRule("scheduled_event", "event_start_date", "before", 2, "hours")}}, eval)
+
+    // Outside the active period: not fired, and nothing left in the ledger.
+    s.tickRelative(context.Background(), now, s.lookback)
+    assert.Equal(t, 1, calls)
+    var n int64
+    require.NoError(t, db.Model(&models.RuleFiring{}).Count(&n).Error)
+    assert.Zero(t, n)
+
+    // Once the rule may fire, the reminder still goes out, once.
+    held = false
+    s.tickRelative(context.Background(), now.Add(time.Minute), s.lookback)
+    s.tickRelative(context.Background(), now.Add(2*time.Minute), s.lookback)
+    assert.Equal(t, 2, calls)
+    require.NoError(t, db.Model(&models.RuleFiring{}).Count(&n).Error)
+    assert.EqualValues(t, 1, n)
+}
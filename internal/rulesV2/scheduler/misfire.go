@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Misfire policies, set per rule with the misfire_policy trigger parameter.
+// They decide what Start does with fires that fell into downtime:
+//   - skip: drop them.
+//   - fire_once: run the rule once for the whole gap (the default).
+//   - fire_all: run the rule for every missed fire, up to maxCatchUpFires.
+//
+// relative_time fires at most once per occurrence anyway, so fire_once and
+// fire_all both fire each missed occurrence once there.
+const (
+    MisfireSkip     = "skip"
+    MisfireFireOnce = "fire_once"
+    MisfireFireAll  = "fire_all"
+)
+
+// maxCatchUpFires bounds fire_all after a long outage.
+const maxCatchUpFires = 100
+
+// skippedNote marks ledger rows claimed by a skip reconcile.
+const skippedNote = "skipped: missed while scheduler was down"
+
+// misfirePolicy reads misfire_policy from trigger parameters.
+func misfirePolicy(params map[string]any) (string, error) {
+    p := strings.ToLower(strFromParams(params, "misfire_policy"))
+    switch p {
+    case "":
+        return MisfireFireOnce, nil
+    case MisfireSkip, MisfireFireOnce, MisfireFireAll:
+        return p, nil
+    default:
+        return "", fmt.Errorf("unknown misfire_policy %q", p)
+    }
+}
+
+type misfireSkipKey struct{}
+
+// skippingMisfires reports whether ctx belongs to a skip reconcile, in which
+// case due relative_time occurrences are claimed but not fired.
+func skippingMisfires(ctx context.Context) bool {
+    v, _ := ctx.Value(misfireSkipKey{}).(bool)
+    return v
+}
+
+// lastFired returns the recorded last fire of a rule, if any.
+func (s *Service) lastFired(ctx context.Context, ruleID, triggerType string) (time.Time, bool, error) {
+    var st models.RuleTriggerState
+    err := s.db.WithContext(ctx).
+        Where("rule_id = ? AND trigger_type = ?", ruleID, triggerType).
+        First(&st).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return time.Time{}, false, nil
+    }
+    if err != nil {
+        return time.Time{}, false, err
+    }
+    return st.LastFiredAt, true, nil
+}
+
+// markFired records at as the rule's last fire. It never moves the last fire
+// back, since a catch-up may finish after newer cron fires.
+func (s *Service) markFired(ctx context.Context, ruleID, triggerType string, at time.Time) {
+    if s.db == nil {
+        return
+    }
+    st := models.RuleTriggerState{RuleID: ruleID, TriggerType: triggerType, LastFiredAt: at}
+    err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "rule_id"}, {Name: "trigger_type"}},
+        DoUpdates: clause.AssignmentColumns([]string{"last_fired_at", "updated_at"}),
+        Where: clause.Where{Exprs: []clause.Expression{
+            clause.Expr{SQL: "rule_trigger_states.last_fired_at < excluded.last_fired_at"},
+        }},
+    }).Create(&st).Error
+    if err != nil {
+        log.Printf("%s rule %s: failed to record last fire: %v", triggerType, ruleID, err)
+    }
+}
+
+// seedFired records at only when the rule has no state yet, so a newly
+// scheduled rule does not catch up on fires from before it existed.
+func (s *Service) seedFired(ruleID, triggerType string, at time.Time) {
+    if s.db == nil {
+        return
+    }
+    st := models.RuleTriggerState{RuleID: ruleID, TriggerType: triggerType, LastFiredAt: at}
+    if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&st).Error; err != nil {
+        log.Printf("%s rule %s: failed to seed fire state: %v", triggerType, ruleID, err)
+    }
+}
+
+// ResetFireState forgets a rule's last fire, e.g. when it is disabled, so
+// re-enabling it later does not catch up on the time it was off.
+func (s *Service) ResetFireState(ctx context.Context, ruleID string) error {
+    if s.db == nil {
+        return nil
+    }
+    return s.db.WithContext(ctx).Where("rule_id = ?", ruleID).Delete(&models.RuleTriggerState{}).Error
+}
+
+type catchUpKey struct{}
+
+// errLeaseLost stops a background catch-up once another instance leads.
+var errLeaseLost = errors.New("scheduler lease lost")
+
+// startCatchUp reconciles in the background, so a long fire_all catch-up
+// does not hold up lease renewal. The catch-up stops between fires once the
+// lease is lost; the next leader picks up from the recorded last fires.
+func (s *Service) startCatchUp(ctx context.Context, now time.Time) {
+    if !s.catchingUp.CompareAndSwap(false, true) {
+        return
+    }
+    s.catchUps.Add(1)
+    go func() {
+        defer s.catchUps.Done()
+        defer s.catchingUp.Store(false)
+        s.reconcile(context.WithValue(ctx, catchUpKey{}, true), now)
+    }()
+}
+
+// lostLease reports whether ctx belongs to a background catch-up whose
+// instance no longer leads.
+func (s *Service) lostLease(ctx context.Context) bool {
+    v, _ := ctx.Value(catchUpKey{}).(bool)
+    return v && !s.IsLeader()
+}
+
+// reconcile applies each time-based rule's misfire policy to what should have
+// fired between its last recorded fire and now. Rules without state are new
+// and have nothing to catch up on.
+func (s *Service) reconcile(ctx context.Context, now time.Time) {
+    if s.db == nil {
+        return
+    }
+    fixed, err := s.store.ListByTrigger(ctx, "scheduled_time")
+    if err != nil {
+        log.Printf("misfire reconcile: scheduled_time list error: %v", err)
+    }
+    for _, r := range fixed {
+        if s.lostLease(ctx) {
+            return
+        }
+        if err := s.reconcileFixed(ctx, r, now); err != nil {
+            log.Printf("misfire reconcile: scheduled_time rule %q: %v", r.Name, err)
+        }
+    }
+    relative, err := s.store.ListByTrigger(ctx, "relative_time")
+    if err != nil {
+        log.Printf("misfire reconcile: relative_time list error: %v", err)
+    }
+    for _, r := range relative {
+        if s.lostLease(ctx) {
+            return
+        }
+        if err := s.reconcileRelative(ctx, r, now); err != nil {
+            log.Printf("misfire reconcile: relative_time rule %q: %v", r.Name, err)
+        }
+    }
+}
+
+// missedFires lists the cron fire times in (after, now], at most limit. A
+// once-off rule's yearly cron entry only counts up to its one fire.
+func (s *Service) missedFires(params map[string]any, after, now time.Time, limit int) ([]time.Time, error) {
+    spec, tzSpec, err := cronSpecFromParams(params)
+    if err != nil {
+        return nil, err
+    }
+    if at, ok := onceAt(params); ok && at.Before(now) {
+        now = at
+    }
+    sched, err := s.parser.Parse(strings.TrimSpace(tzSpec + " " + spec))
+    if err != nil {
+        return nil, err
+    }
+    var out []time.Time
+    for t := sched.Next(after); !t.IsZero() && !t.After(now) && len(out) < limit; t = sched.Next(t) {
+        out = append(out, t.UTC())
+    }
+    return out, nil
+}
+
+func (s *Service) reconcileFixed(ctx context.Context, r Rule, now time.Time) error {
+    key := fixedKey(r)
+    policy, err := misfirePolicy(r.Trigger.Parameters)
+    if err != nil {
+        return err
+    }
+    last, ok, err := s.lastFired(ctx, key, "scheduled_time")
+    if err != nil || !ok {
+        return err
+    }
+    limit := 1
+    if policy == MisfireFireAll {
+        limit = maxCatchUpFires
+    }
+    missed, err := s.missedFires(r.Trigger.Parameters, last, now, limit)
+    if err != nil || len(missed) == 0 {
+        return err
+    }
+    s.debugf("Reconcile scheduled_time rule %q policy=%s last=%s missed>=%d", r.Name, policy, last.Format(time.RFC3339), len(missed))
+
+    payload := fixedPayload(r.Trigger.Parameters)
+    freq := strings.ToLower(fmt.Sprint(r.Trigger.Parameters["frequency"]))
+    switch policy {
+    case MisfireSkip:
+        log.Printf("scheduled_time rule %q: skipping fires missed since %s", r.Name, last.Format(time.RFC3339))
+        s.markFired(ctx, key, "scheduled_time", now)
+    case MisfireFireOnce:
+        // Only the first missed fire was listed; fire once on behalf of all.
+        s.fireFixed(key, r.Name, payload, freq, r.Obj, now, map[string]any{
+            "catch_up":      true,
+            "missed_since":  last,
+            "scheduled_for": missed[0],
+        })
+    case MisfireFireAll:
+        for _, t := range missed {
+            if s.lostLease(ctx) {
+                return errLeaseLost
+            }
+            s.fireFixed(key, r.Name, payload, freq, r.Obj, t, map[string]any{
+                "catch_up":      true,
+                "scheduled_for": t,
+            })
+        }
+        if len(missed) == maxCatchUpFires {
+            log.Printf("scheduled_time rule %q: catch-up capped at %d fires, dropping the rest", r.Name, maxCatchUpFires)
+            s.markFired(ctx, key, "scheduled_time", now)
+        }
+    }
+    return nil
+}
+
+func (s *Service) reconcileRelative(ctx context.Context, r Rule, now time.Time) error {
+    key := ruleKey(r)
+    policy, err := misfirePolicy(r.Trigger.Parameters)
+    if err != nil {
+        return err
+    }
+    last, ok, err := s.lastFired(ctx, key, "relative_time")
+    if err != nil || !ok {
+        return err
+    }
+    gap := now.Sub(last)
+    if gap <= 0 {
+        return nil
+    }
+    s.debugf("Reconcile relative_time rule %q policy=%s last=%s gap=%s", r.Name, policy, last.Format(time.RFC3339), gap)
+
+    lookback := gap
+    if policy == MisfireSkip {
+        // Claim everything that came due during the gap without firing it.
+        ctx = context.WithValue(ctx, misfireSkipKey{}, true)
+    } else if lookback < s.lookback {
+        lookback = s.lookback
+    }
+    if err := s.tickRelativeRule(ctx, r, now, lookback); err != nil {
+        return err
+    }
+    s.markFired(ctx, key, "relative_time", now)
+    return nil
+}
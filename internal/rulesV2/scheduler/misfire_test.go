@@ -0,0 +1,208 @@
+//go:build unit
+
+package scheduler
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func dailyRule(policy string) Rule {
+    params := map[string]any{"frequency": "daily", "time_of_day": "08:00"}
+    if policy != "" {
+        params["misfire_policy"] = policy
+    }
+    return Rule{ID: "5", Name: "daily", Trigger: TriggerSpec{Type: "scheduled_time", Parameters: params}}
+}
+
+func TestReconcileFixed_Policies(t *testing.T) {
+    now := time.Date(2025, 9, 10, 12, 0, 0, 0, time.UTC)
+    last := time.Date(2025, 9, 7, 8, 0, 5, 0, time.UTC) // missed 8th, 9th and 10th
+
+    tests := []struct {
+        policy string
+        fires  int
+    }{
+        {"skip", 0},
+        {"", 1}, // fire_once by default
+        {"fire_all", 3},
+    }
+    for _, tc := range tests {
+        t.Run(tc.policy, func(t *testing.T) {
+            db := newLedgerDB(t)
+            require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+            require.NoError(t, db.Create(&models.RuleTriggerState{RuleID: "5", TriggerType: "scheduled_time", LastFiredAt: last}).Error)
+
+            var triggers []map[string]any
+            s := New(db, &fakeStore{rules: []Rule{dailyRule(tc.policy)}}, func(ev EvalContext, _ any) error {
+                triggers = append(triggers, ev.Data["trigger"].(map[string]any))
+                return nil
+            })
+            s.reconcile(context.Background(), now)
+            require.Len(t, triggers, tc.fires)
+            for _, tr := range triggers {
+                assert.Equal(t, true, tr["catch_up"])
+                // The engine only matches payloads carrying every trigger parameter.
+                for k := range dailyRule(tc.policy).Trigger.Parameters {
+                    assert.Contains(t, tr, k)
+                }
+            }
+            if tc.fires == 3 {
+                assert.Equal(t, time.Date(2025, 9, 10, 8, 0, 0, 0, time.UTC), triggers[2]["scheduled_for"])
+            }
+
+            // Reconciled: a second start finds nothing missed.
+            triggers = nil
+            s.reconcile(context.Background(), now)
+            assert.Empty(t, triggers)
+        })
+    }
+}
+
+func TestReconcileFixed_NoStateNoCatchUp(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+    calls := 0
+    s := New(db, &fakeStore{rules: []Rule{dailyRule("fire_all")}}, func(EvalContext, any) error { calls++; return nil })
+    s.reconcile(context.Background(), time.Now().UTC())
+    assert.Equal(t, 0, calls)
+
+    // Scheduling seeds the state so the next start has a baseline.
+    require.NoError(t, s.ScheduleFixedRule("5", "daily", dailyRule("").Trigger.Parameters, nil))
+    _, ok, err := s.lastFired(context.Background(), "5", "scheduled_time")
+    require.NoError(t, err)
+    assert.True(t, ok)
+}
+
+func TestReconcileRelative_SkipClaimsWithoutFiring(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+    now := time.Now().UTC().Truncate(time.Second)
+    // Fire time (start-2h) was 3 hours ago, during the outage.
+    require.NoError(t, db.Create(&models.CustomEventSchedule{CustomEventID: 1, Title: "missed", EventStartDate: now.Add(-time.Hour), EventEndDate: now}).Error)
+    require.NoError(t, db.Create(&models.RuleTriggerState{RuleID: "9", TriggerType: "relative_time", LastFiredAt: now.Add(-6 * time.Hour)}).Error)
+
+    rule := relativeRule("scheduled_event", "event_start_date", "before", 2, "hours")
+    rule.Trigger.Parameters["misfire_policy"] = "skip"
+    calls := 0
+    s := New(db, &fakeStore{rules: []Rule{rule}}, func(EvalContext, any) error { calls++; return nil })
+
+    s.reconcile(context.Background(), now)
+    s.tickRelative(context.Background(), now, s.lookback)
+    assert.Equal(t, 0, calls)
+
+    var f models.RuleFiring
+    require.NoError(t, db.First(&f).Error)
+    assert.Equal(t, skippedNote, f.Error)
+
+    last, ok, err := s.lastFired(context.Background(), "9", "relative_time")
+    require.NoError(t, err)
+    assert.True(t, ok)
+    assert.WithinDuration(t, now, last, time.Second)
+}
+
+func TestReconcileRelative_CatchesUpBeyondLookback(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+    now := time.Now().UTC().Truncate(time.Second)
+    // Fire time was two days ago, outside the poller's normal lookback.
+    require.NoError(t, db.Create(&models.CustomEventSchedule{CustomEventID: 1, Title: "old", EventStartDate: now.Add(-46 * time.Hour), EventEndDate: now}).Error)
+    require.NoError(t, db.Create(&models.RuleTriggerState{RuleID: "9", TriggerType: "relative_time", LastFiredAt: now.Add(-72 * time.Hour)}).Error)
+
+    calls := 0
+    s := New(db, &fakeStore{rules: []Rule{relativeRule("scheduled_event", "event_start_date", "before", 2, "hours")}},
+        func(EvalContext, any) error { calls++; return nil })
+    s.reconcile(context.Background(), now)
+    assert.Equal(t, 1, calls)
+}
+
+func TestFireFixed_RecordsFailedFires(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+    now := time.Date(2025, 9, 10, 12, 0, 0, 0, time.UTC)
+    require.NoError(t, db.Create(&models.RuleTriggerState{RuleID: "5", TriggerType: "scheduled_time", LastFiredAt: now.Add(-48 * time.Hour)}).Error)
+
+    calls := 0
+    s := New(db, &fakeStore{rules: []Rule{dailyRule("")}}, func(EvalContext, any) error { calls++; return errors.New("smtp down") })
+    s.reconcile(context.Background(), now)
+    s.reconcile(context.Background(), now)
+    assert.Equal(t, 1, calls, "a fire whose action failed is not caught up again")
+}
+
+func TestMarkFired_NeverMovesBack(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+    s := New(db, nil, nil)
+    ctx := context.Background()
+    newer := time.Date(2025, 9, 10, 8, 0, 0, 0, time.UTC)
+
+    s.markFired(ctx, "5", "scheduled_time", newer)
+    s.markFired(ctx, "5", "scheduled_time", newer.Add(-24*time.Hour))
+    last, _, err := s.lastFired(ctx, "5", "scheduled_time")
+    require.NoError(t, err)
+    assert.True(t, last.Equal(newer), "got %s", last)
+
+    s.markFired(ctx, "5", "scheduled_time", newer.Add(time.Hour))
+    last, _, err = s.lastFired(ctx, "5", "scheduled_time")
+    require.NoError(t, err)
+    assert.True(t, last.Equal(newer.Add(time.Hour)), "got %s", last)
+}
+
+func TestCatchUp_StopsWhenLeaseIsLost(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+    now := time.Date(2025, 9, 10, 12, 0, 0, 0, time.UTC)
+    require.NoError(t, db.Create(&models.RuleTriggerState{RuleID: "5", TriggerType: "scheduled_time", LastFiredAt: now.Add(-72 * time.Hour)}).Error)
+
+    var s *Service
+    calls := 0
+    s = New(db, &fakeStore{rules: []Rule{dailyRule("fire_all")}}, func(EvalContext, any) error {
+        calls++
+        s.leader.Store(false) // another instance took over mid catch-up
+        return nil
+    })
+    s.leader.Store(true)
+    s.startCatchUp(context.Background(), now)
+    s.catchUps.Wait()
+    assert.Equal(t, 1, calls, "the remaining missed fires are left to the new leader")
+    assert.False(t, s.catchingUp.Load())
+}
+
+func TestOnceOffRule_DoesNotRearm(t *testing.T) {
+    db := newLedgerDB(t)
+    require.NoError(t, db.AutoMigrate(&models.RuleTriggerState{}))
+    at := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Minute)
+    params := map[string]any{"frequency": "once", "date": at.Format("2006-01-02"), "time_of_day": at.Format("15:04"), "timezone": "UTC"}
+    rule := Rule{ID: "7", Name: "once", Trigger: TriggerSpec{Type: "scheduled_time", Parameters: params}}
+    calls := 0
+    s := New(db, &fakeStore{rules: []Rule{rule}}, func(EvalContext, any) error { calls++; return nil })
+    ctx := context.Background()
+
+    require.NoError(t, s.scheduleFixedTimeRules(ctx))
+    require.Contains(t, s.fixedIDs, "7")
+
+    s.fireFixed("7", rule.Name, fixedPayload(params), "once", nil, at, nil)
+    assert.NotContains(t, s.fixedIDs, "7")
+
+    // The next lease round must not add the yearly cron entry back.
+    require.NoError(t, s.scheduleFixedTimeRules(ctx))
+    assert.NotContains(t, s.fixedIDs, "7")
+
+    // Nor does a restart a year later catch up on the next anniversary.
+    s.reconcile(ctx, at.AddDate(1, 0, 1))
+    assert.Equal(t, 1, calls)
+}
+
+func TestOnceOffRule_PastDateNotScheduled(t *testing.T) {
+    params := map[string]any{"frequency": "once_off", "date": "2020-01-01", "time_of_day": "09:00"}
+    s := New(nil, &fakeStore{rules: []Rule{{ID: "7", Name: "once", Trigger: TriggerSpec{Type: "scheduled_time", Parameters: params}}}}, func(EvalContext, any) error { return nil })
+    require.NoError(t, s.scheduleFixedTimeRules(context.Background()))
+    assert.Empty(t, s.fixedIDs)
+}
@@ -4,10 +4,13 @@ import (
     "context"
     "fmt"
     "log"
+    "reflect"
     "strings"
     "time"
 
-    "Automated-Scheduling-Project/internal/database/models"
+    "Automated-Scheduling-Project/internal/rulesV2/holidays"
+
+    "gorm.io/gorm"
 )
 
 func (s *Service) runRelativePoller(ctx context.Context) {
@@ -22,14 +25,24 @@ func (s *Service) runRelativePoller(ctx context.Context) {
             s.debugf("Relative poller stopping")
             return
         case <-t.C:
+            if !s.IsLeader() {
+                continue
+            }
             now := time.Now().UTC()
-            s.debugf("Tick relative at now=%s window=%s", now.Format(time.RFC3339), s.interval)
-            s.tickRelative(ctx, now, s.interval)
+            s.debugf("Tick relative at now=%s lookback=%s", now.Format(time.RFC3339), s.lookback)
+            s.tickRelative(ctx, now, s.lookback)
+            for _, fn := range s.afterTick {
+                fn(ctx, time.Now().UTC())
+            }
         }
     }
 }
 
-func (s *Service) tickRelative(ctx context.Context, now time.Time, window time.Duration) {
+// tickRelative fires every relative_time occurrence that is due (its fire
+// time lies within lookback of now) and not yet in the ledger. Because the
+// ledger rather than the tick decides, ticker drift, restarts and replicas
+// neither skip nor repeat reminders.
+func (s *Service) tickRelative(ctx context.Context, now time.Time, lookback time.Duration) {
     ruleset, err := s.store.ListByTrigger(ctx, "relative_time")
     if err != nil {
         log.Printf("relative_time list error: %v", err)
@@ -37,284 +50,295 @@ func (s *Service) tickRelative(ctx context.Context, now time.Time, window time.D
     }
     s.debugf("Evaluating %d relative_time rule(s)", len(ruleset))
     for _, r := range ruleset {
-        params := r.Trigger.Parameters
-        entityType, _ := params["entity_type"].(string)
-        dateField, _ := params["date_field"].(string)
-        offsetDir, _ := params["offset_direction"].(string) // "before"|"after"
-        unit, _ := params["offset_unit"].(string)
-        offVal := toInt(params["offset_value"])
-
-        if entityType == "" || dateField == "" || offsetDir == "" || unit == "" {
-            log.Printf("relative_time rule %q missing params", r.Name)
+        if err := s.tickRelativeRule(ctx, r, now, lookback); err != nil {
+            log.Printf("relative_time rule %q: %v", r.Name, err)
             continue
         }
+        s.markFired(ctx, ruleKey(r), "relative_time", now)
+    }
+}
 
-        offset := toDuration(offVal, unit)
-        s.debugf("Rule %q entity=%s field=%s dir=%s offset=%d %s", r.Name, entityType, dateField, offsetDir, offVal, unit)
+// relativeSpec is a parsed relative_time trigger.
+type relativeSpec struct {
+    entityType string
+    dateField  string
+    dir        string // "before"|"after"
+    amount     int
+    unit       string // normalized by offsetUnit
+    loc        *time.Location
+    clock      bool // time_of_day set: fire at hour:minute local
+    hour       int
+    minute     int
+    region     string             // holiday_region for business_days
+    cal        *holidays.Calendar // loaded by loadRelative; nil means weekends only
+}
 
-        switch strings.ToLower(entityType) {
-        case "scheduled_event":
-            if err := s.evalRelativeScheduledEvent(ctx, now, window, offset, offsetDir, dateField, r); err != nil {
-                log.Printf("relative_time scheduled_event error: %v", err)
-            }
-        case "employee_competency":
-            if err := s.evalRelativeEmployeeCompetency(ctx, now, window, offset, offsetDir, dateField, r); err != nil {
-                log.Printf("relative_time employee_competency error: %v", err)
-            }
-        case "employee":
-            if err := s.evalRelativeEmployee(ctx, now, window, offset, offsetDir, dateField, r); err != nil {
-                log.Printf("relative_time employee error: %v", err)
-            }
-        case "employment_history":
-            if err := s.evalRelativeEmploymentHistory(ctx, now, window, offset, offsetDir, dateField, r); err != nil {
-                log.Printf("relative_time employment_history error: %v", err)
-            }
-        default:
-            log.Printf("relative_time rule %q unsupported entity_type=%s", r.Name, entityType)
+func parseRelative(params map[string]any) (relativeSpec, error) {
+    entityType, _ := params["entity_type"].(string)
+    dateField, _ := params["date_field"].(string)
+    offsetDir, _ := params["offset_direction"].(string)
+    unit, _ := params["offset_unit"].(string)
+    if entityType == "" || dateField == "" || offsetDir == "" || unit == "" {
+        return relativeSpec{}, fmt.Errorf("missing params")
+    }
+    sp := relativeSpec{
+        entityType: strings.ToLower(entityType),
+        dateField:  dateField,
+        dir:        strings.ToLower(offsetDir),
+        amount:     toInt(params["offset_value"]),
+        region:     strFromParams(params, "holiday_region"),
+    }
+    if sp.dir != "before" && sp.dir != "after" {
+        return relativeSpec{}, fmt.Errorf("unknown offset_direction %q", offsetDir)
+    }
+    var err error
+    if sp.unit, err = offsetUnit(unit); err != nil {
+        return relativeSpec{}, err
+    }
+    if sp.loc, err = LoadTimezone(strFromParams(params, "timezone")); err != nil {
+        return relativeSpec{}, err
+    }
+    if tod := strFromParams(params, "time_of_day"); tod != "" {
+        tm, err := time.Parse("15:04", tod)
+        if err != nil {
+            return relativeSpec{}, fmt.Errorf("invalid time_of_day: %w", err)
         }
+        sp.clock, sp.hour, sp.minute = true, tm.Hour(), tm.Minute()
     }
+    return sp, nil
 }
 
-func (s *Service) evalRelativeScheduledEvent(ctx context.Context, now time.Time, window time.Duration, offset time.Duration, dir string, dateField string, r Rule) error {
-    col := ""
-    switch strings.ToLower(dateField) {
-    case "event_start_date", "eventstartdate":
-        col = "event_start_date"
-    case "event_end_date", "eventenddate":
-        col = "event_end_date"
-    default:
-        return fmt.Errorf("unknown date_field %q", dateField)
+// loadRelative parses a relative_time trigger and, for business-day offsets,
+// loads the holiday calendar of its region.
+func (s *Service) loadRelative(ctx context.Context, params map[string]any) (relativeSpec, error) {
+    sp, err := parseRelative(params)
+    if err != nil || sp.unit != "business_days" {
+        return sp, err
     }
-
-    start := now
-    end := now.Add(window)
-    switch strings.ToLower(dir) {
-    case "before":
-        start = now.Add(offset)
-        end = start.Add(window)
-    case "after":
-        start = now.Add(-offset)
-        end = start.Add(window)
-    default:
-        return fmt.Errorf("unknown offset_direction %q", dir)
+    if sp.cal, err = holidays.Load(ctx, s.db, sp.region); err != nil {
+        return relativeSpec{}, fmt.Errorf("load holidays for %q: %w", sp.region, err)
     }
+    return sp, nil
+}
 
-    s.debugf("Query scheduled_event where %s in [%s, %s)", col, start.Format(time.RFC3339), end.Format(time.RFC3339))
+// fireTime is when an occurrence whose date is target fires. The offset is
+// applied in the rule's zone; a value of a Date column is taken as that
+// calendar day in the zone rather than as an instant. With time_of_day set,
+// day-or-larger offsets fire at that local time on the resulting day.
+func (sp relativeSpec) fireTime(target time.Time, kind DateKind) time.Time {
+    t := target.In(sp.loc)
+    if kind == Date {
+        y, m, d := target.UTC().Date()
+        t = time.Date(y, m, d, 0, 0, 0, 0, sp.loc)
+    }
+    n := sp.amount
+    if sp.dir == "before" {
+        n = -n
+    }
+    t = shift(t, n, sp.unit, sp.cal)
+    if sp.clock && calendarUnit(sp.unit) {
+        y, m, d := t.Date()
+        t = time.Date(y, m, d, sp.hour, sp.minute, 0, 0, sp.loc)
+    }
+    return t.UTC()
+}
 
-    var rows []models.CustomEventSchedule
-    if err := s.db.WithContext(ctx).
-        Where(col+" >= ? AND "+col+" < ?", start, end).
-        Find(&rows).Error; err != nil {
-        return err
+// candidateRange is a (from, to] range of entity dates that contains every
+// occurrence whose fire time lies in (start, end].
+func (sp relativeSpec) candidateRange(start, end time.Time) (time.Time, time.Time) {
+    n := sp.amount
+    if sp.dir == "after" {
+        n = -n
+    }
+    slack := candidateSlack
+    if sp.unit == "business_days" {
+        slack = businessDaySlack
     }
+    from := shift(start.In(sp.loc), n, sp.unit, sp.cal).Add(-slack)
+    to := shift(end.In(sp.loc), n, sp.unit, sp.cal).Add(slack)
+    return from.UTC(), to.UTC()
+}
 
-    s.debugf("Rule %q matched %d row(s)", r.Name, len(rows))
+// String renders the offset for logs and previews, e.g. "1 months before".
+func (sp relativeSpec) String() string {
+    return fmt.Sprintf("%d %s %s", sp.amount, sp.unit, sp.dir)
+}
 
-    for _, row := range rows {
-        ev := EvalContext{
-            Now: time.Now().UTC(),
-            Data: map[string]any{
-                "trigger": map[string]any{
-                    "type":             "relative_time",
-                    "entity_type":      "scheduled_event",
-                    "date_field":       strings.ToLower(dateField),
-                    "offset_direction": strings.ToLower(dir),
-                    "offset_value":     r.Trigger.Parameters["offset_value"],
-                    "offset_unit":      r.Trigger.Parameters["offset_unit"],
-                },
-                "scheduledEvent": row,
-            },
-        }
-        s.debugf("FIRE relative_time rule %q schedule_id=%d at %s", r.Name, row.CustomEventScheduleID, ev.Now.Format(time.RFC3339))
-        if err := s.eval(ev, r.Obj); err != nil {
-            log.Printf("relative_time rule %q failed on schedule %d: %v", r.Name, row.CustomEventScheduleID, err)
-        }
-    }
-    return nil
+// relativeHit is one entity occurrence found by a relative_time query.
+type relativeHit struct {
+    entityType string
+    entityKey  string
+    target     time.Time // entity date the offset is measured from
+    kind       DateKind  // column type of target
+    dataKey    string    // EvalContext.Data key the row is passed under
+    row        any
 }
 
-func (s *Service) evalRelativeEmployeeCompetency(ctx context.Context, now time.Time, window time.Duration, offset time.Duration, dir string, dateField string, r Rule) error {
-    // Supported field(s): expiry_date (DATE)
-    field := strings.ToLower(dateField)
-    if field != "expiry_date" {
-        return fmt.Errorf("unknown date_field %q for employee_competency", dateField)
+// dueHits lists the occurrences of rule r whose fire time lies in
+// (start, end], optionally only those it has not fired for yet.
+func (s *Service) dueHits(ctx context.Context, r Rule, sp relativeSpec, start, end time.Time, unfired bool) ([]relativeHit, error) {
+    from, to := sp.candidateRange(start, end)
+    hits, err := s.queryRelative(ctx, r, sp, from, to, unfired)
+    if err != nil {
+        return nil, err
     }
-
-    start := now
-    end := now.Add(window)
-    switch strings.ToLower(dir) {
-    case "before":
-        start = now.Add(offset)
-        end = start.Add(window)
-    case "after":
-        start = now.Add(-offset)
-        end = start.Add(window)
-    default:
-        return fmt.Errorf("unknown offset_direction %q", dir)
+    due := hits[:0]
+    for _, h := range hits {
+        if ft := sp.fireTime(h.target, h.kind); ft.After(start) && !ft.After(end) {
+            due = append(due, h)
+        }
     }
+    return due, nil
+}
 
-    s.debugf("Query employee_competencies where %s in [%s, %s)", field, start.Format(time.RFC3339), end.Format(time.RFC3339))
-
-    var rows []map[string]any
-    if err := s.db.WithContext(ctx).
-        Table("employee_competencies").
-        Where(field+" >= ? AND "+field+" < ?", start, end).
-        Find(&rows).Error; err != nil {
+// tickRelativeRule fires the occurrences of one relative_time rule that fell
+// due within lookback of now.
+func (s *Service) tickRelativeRule(ctx context.Context, r Rule, now time.Time, lookback time.Duration) error {
+    sp, err := s.loadRelative(ctx, r.Trigger.Parameters)
+    if err != nil {
         return err
     }
+    s.debugf("Rule %q entity=%s field=%s offset=%s tz=%s", r.Name, sp.entityType, sp.dateField, sp, sp.loc)
 
-    s.debugf("Rule %q matched %d row(s) in employee_competencies", r.Name, len(rows))
-
-    for _, row := range rows {
+    hits, err := s.dueHits(ctx, r, sp, now.Add(-lookback), now, true)
+    if err != nil {
+        return err
+    }
+    for _, h := range hits {
+        if s.lostLease(ctx) {
+            return errLeaseLost
+        }
         ev := EvalContext{
             Now: time.Now().UTC(),
             Data: map[string]any{
-                "trigger": map[string]any{
-                    "type":             "relative_time",
-                    "entity_type":      "employee_competency",
-                    "date_field":       field,
-                    "offset_direction": strings.ToLower(dir),
-                    "offset_value":     r.Trigger.Parameters["offset_value"],
-                    "offset_unit":      r.Trigger.Parameters["offset_unit"],
-                },
-                "employeeCompetency": row,
+                "trigger": relativeTrigger(r.Trigger.Parameters, sp),
+                h.dataKey: h.row,
             },
         }
-        s.debugf("FIRE relative_time rule %q employee_competency at %s", r.Name, ev.Now.Format(time.RFC3339))
-        if err := s.eval(ev, r.Obj); err != nil {
-            log.Printf("relative_time rule %q failed (employee_competency): %v", r.Name, err)
-        }
+        s.fireOnce(ctx, r, h.entityType, h.entityKey, h.target, ev)
     }
     return nil
 }
 
-func (s *Service) evalRelativeEmployee(ctx context.Context, now time.Time, window time.Duration, offset time.Duration, dir string, dateField string, r Rule) error {
-    // Supported field(s): termination_date (column name in DB is terminationdate)
-    field := strings.ToLower(strings.ReplaceAll(dateField, " ", ""))
-    // Normalize common variants
-    if field == "termination_date" || field == "terminationdate" {
-        field = "terminationdate"
-    } else {
-        return fmt.Errorf("unknown date_field %q for employee", dateField)
+// relativeTrigger is the trigger payload of a relative_time fire. It echoes
+// the rule's own date_field spelling so trigger matching passes.
+func relativeTrigger(params map[string]any, sp relativeSpec) map[string]any {
+    return map[string]any{
+        "type":             "relative_time",
+        "entity_type":      sp.entityType,
+        "date_field":       strings.ToLower(sp.dateField),
+        "offset_direction": sp.dir,
+        "offset_value":     params["offset_value"],
+        "offset_unit":      params["offset_unit"],
+        "misfire_policy":   params["misfire_policy"],
+        "timezone":         params["timezone"],
+        "time_of_day":      params["time_of_day"],
+        "holiday_region":   params["holiday_region"],
     }
+}
 
-    start := now
-    end := now.Add(window)
-    switch strings.ToLower(dir) {
-    case "before":
-        start = now.Add(offset)
-        end = start.Add(window)
-    case "after":
-        start = now.Add(-offset)
-        end = start.Add(window)
-    default:
-        return fmt.Errorf("unknown offset_direction %q", dir)
+// queryRelative lists the occurrences of the rule's entity whose date lies
+// in (start, end], optionally only those rule r has not fired for yet.
+func (s *Service) queryRelative(ctx context.Context, r Rule, sp relativeSpec, start, end time.Time, unfired bool) ([]relativeHit, error) {
+    e, ok := LookupEntity(sp.entityType)
+    if !ok {
+        return nil, fmt.Errorf("unsupported entity_type %q", sp.entityType)
     }
+    f, ok := e.DateField(sp.dateField)
+    if !ok {
+        return nil, fmt.Errorf("unknown date_field %q for %s", sp.dateField, e.Type)
+    }
+    col := e.Table + "." + f.Column
 
-    s.debugf("Query employee where %s in [%s, %s)", field, start.Format(time.RFC3339), end.Format(time.RFC3339))
+    s.debugf("Query %s where %s in (%s, %s] unfired=%v", e.Table, col, start.Format(time.RFC3339), end.Format(time.RFC3339), unfired)
 
-    var rows []map[string]any
-    if err := s.db.WithContext(ctx).
-        Table("employee").
-        Where(field+" IS NOT NULL").
-        Where(field+" >= ? AND "+field+" < ?", start, end).
-        Find(&rows).Error; err != nil {
-        return err
+    q := s.db.WithContext(ctx).
+        Table(e.Table).
+        Where(col+" IS NOT NULL").
+        Where(col+" > ? AND "+col+" <= ?", start, end)
+    if unfired {
+        q = s.notFired(q, r, e.Type, e.Table+"."+e.Key, col)
     }
 
-    s.debugf("Rule %q matched %d row(s) in employee", r.Name, len(rows))
+    var hits []relativeHit
+    var err error
+    if e.Model != nil {
+        hits, err = modelHits(ctx, q, r, e, f)
+    } else {
+        hits, err = mapHits(q, r, e, f)
+    }
+    if err != nil {
+        return nil, err
+    }
+    s.debugf("Rule %q matched %d row(s) in %s", r.Name, len(hits), e.Table)
+    return hits, nil
+}
 
+// mapHits scans rows into maps, including joined columns, skipping rows
+// whose date column cannot be read.
+func mapHits(q *gorm.DB, r Rule, e RelativeEntity, f EntityDateField) ([]relativeHit, error) {
+    q = q.Select(e.selects())
+    for _, j := range e.Joins {
+        q = q.Joins(j.Clause)
+    }
+    var rows []map[string]any
+    if err := q.Find(&rows).Error; err != nil {
+        return nil, err
+    }
+    hits := make([]relativeHit, 0, len(rows))
     for _, row := range rows {
-        ev := EvalContext{
-            Now: time.Now().UTC(),
-            Data: map[string]any{
-                "trigger": map[string]any{
-                    "type":             "relative_time",
-                    "entity_type":      "employee",
-                    "date_field":       "termination_date",
-                    "offset_direction": strings.ToLower(dir),
-                    "offset_value":     r.Trigger.Parameters["offset_value"],
-                    "offset_unit":      r.Trigger.Parameters["offset_unit"],
-                },
-                "employee": row,
-            },
-        }
-        s.debugf("FIRE relative_time rule %q employee at %s", r.Name, ev.Now.Format(time.RFC3339))
-        if err := s.eval(ev, r.Obj); err != nil {
-            log.Printf("relative_time rule %q failed (employee): %v", r.Name, err)
+        target, ok := timeFromAny(row[f.Column])
+        if !ok {
+            log.Printf("relative_time rule %q: %s row has unreadable %s=%v", r.Name, e.Type, f.Column, row[f.Column])
+            continue
         }
+        hits = append(hits, relativeHit{
+            entityType: e.Type,
+            entityKey:  fmt.Sprint(row[e.Key]),
+            target:     target,
+            kind:       f.Kind,
+            dataKey:    e.DataKey,
+            row:        row,
+        })
     }
-    return nil
+    return hits, nil
 }
 
-func (s *Service) evalRelativeEmploymentHistory(ctx context.Context, now time.Time, window time.Duration, offset time.Duration, dir string, dateField string, r Rule) error {
-    // Supported field(s): start_date
-    field := strings.ToLower(dateField)
-    if field != "start_date" {
-        return fmt.Errorf("unknown date_field %q for employment_history", dateField)
+// modelHits scans rows into e.Model structs, reading the key and date
+// through the model's GORM schema.
+func modelHits(ctx context.Context, q *gorm.DB, r Rule, e RelativeEntity, f EntityDateField) ([]relativeHit, error) {
+    stmt := &gorm.Statement{DB: q}
+    if err := stmt.Parse(e.Model); err != nil {
+        return nil, err
     }
-
-    start := now
-    end := now.Add(window)
-    switch strings.ToLower(dir) {
-    case "before":
-        start = now.Add(offset)
-        end = start.Add(window)
-    case "after":
-        start = now.Add(-offset)
-        end = start.Add(window)
-    default:
-        return fmt.Errorf("unknown offset_direction %q", dir)
+    keyField, dateField := stmt.Schema.LookUpField(e.Key), stmt.Schema.LookUpField(f.Column)
+    if keyField == nil || dateField == nil {
+        return nil, fmt.Errorf("%s model has no %s or %s column", e.Type, e.Key, f.Column)
     }
 
-    s.debugf("Query employment_history where %s in [%s, %s)", field, start.Format(time.RFC3339), end.Format(time.RFC3339))
-
-    var rows []map[string]any
-    if err := s.db.WithContext(ctx).
-        Table("employment_history").
-        Where(field+" >= ? AND "+field+" < ?", start, end).
-        Find(&rows).Error; err != nil {
-        return err
+    rows := reflect.New(reflect.SliceOf(reflect.TypeOf(e.Model)))
+    if err := q.Find(rows.Interface()).Error; err != nil {
+        return nil, err
     }
-
-    s.debugf("Rule %q matched %d row(s) in employment_history", r.Name, len(rows))
-
-    for _, row := range rows {
-        ev := EvalContext{
-            Now: time.Now().UTC(),
-            Data: map[string]any{
-                "trigger": map[string]any{
-                    "type":             "relative_time",
-                    "entity_type":      "employment_history",
-                    "date_field":       field,
-                    "offset_direction": strings.ToLower(dir),
-                    "offset_value":     r.Trigger.Parameters["offset_value"],
-                    "offset_unit":      r.Trigger.Parameters["offset_unit"],
-                },
-                "employmentHistory": row,
-            },
-        }
-        s.debugf("FIRE relative_time rule %q employment_history at %s", r.Name, ev.Now.Format(time.RFC3339))
-        if err := s.eval(ev, r.Obj); err != nil {
-            log.Printf("relative_time rule %q failed (employment_history): %v", r.Name, err)
+    list := rows.Elem()
+    hits := make([]relativeHit, 0, list.Len())
+    for i := 0; i < list.Len(); i++ {
+        row := list.Index(i)
+        date, _ := dateField.ValueOf(ctx, row)
+        target, ok := timeFromAny(date)
+        if !ok {
+            log.Printf("relative_time rule %q: %s row has unreadable %s=%v", r.Name, e.Type, f.Column, date)
+            continue
         }
+        key, _ := keyField.ValueOf(ctx, row)
+        hits = append(hits, relativeHit{
+            entityType: e.Type,
+            entityKey:  fmt.Sprint(key),
+            target:     target,
+            kind:       f.Kind,
+            dataKey:    e.DataKey,
+            row:        row.Interface(),
+        })
     }
-    return nil
+    return hits, nil
 }
-
-func toDuration(n int, unit string) time.Duration {
-    switch strings.ToLower(unit) {
-    case "minutes", "minute", "min":
-        return time.Duration(n) * time.Minute
-    case "hours", "hour", "hr":
-        return time.Duration(n) * time.Hour
-    case "days", "day":
-        return time.Duration(n) * 24 * time.Hour
-    case "weeks", "week":
-        return time.Duration(n) * 7 * 24 * time.Hour
-    case "months", "month":
-        return time.Duration(n) * 30 * 24 * time.Hour
-    default:
-        return 0
-    }
-}
\ No newline at end of file
@@ -20,33 +20,24 @@ func (f *fakeStore) ListByTrigger(ctx context.Context, triggerType string) ([]Ru
     return f.rules, f.err
 }
 
-func TestEvalRelativeScheduledEvent_UnknownField(t *testing.T) {
+func TestQueryRelative_UnknownField(t *testing.T) {
     s := New(nil, nil, func(EvalContext, any) error { return nil })
-    err := s.evalRelativeScheduledEvent(context.Background(), time.Now().UTC(), time.Minute, time.Minute, "before", "not_a_field", Rule{})
-    assert.Error(t, err)
-    assert.Contains(t, err.Error(), "unknown date_field")
-}
-
-func TestEvalRelativeEmployeeCompetency_UnknownField(t *testing.T) {
-    s := New(nil, nil, func(EvalContext, any) error { return nil })
-    err := s.evalRelativeEmployeeCompetency(context.Background(), time.Now().UTC(), time.Minute, time.Minute, "before", "not_a_field", Rule{})
-    assert.Error(t, err)
-    assert.Contains(t, err.Error(), "unknown date_field")
-}
-
-func TestEvalRelativeEmployee_UnknownField(t *testing.T) {
-    s := New(nil, nil, func(EvalContext, any) error { return nil })
-    // Any non-termination date field should error
-    err := s.evalRelativeEmployee(context.Background(), time.Now().UTC(), time.Minute, time.Minute, "after", "hire_date", Rule{})
-    assert.Error(t, err)
-    assert.Contains(t, err.Error(), "unknown date_field")
-}
+    for entity, field := range map[string]string{
+        "scheduled_event":     "not_a_field",
+        "employee_competency": "not_a_field",
+        "employee":            "hire_date", // any non-termination date field should error
+        "employment_history":  "finish_date",
+        "event_attendance":    "check_out_time",
+        "user":                "last_login",
+    } {
+        sp := relativeSpec{entityType: entity, dateField: field}
+        _, err := s.queryRelative(context.Background(), Rule{}, sp, time.Now().UTC(), time.Now().UTC().Add(time.Minute), true)
+        assert.Error(t, err, entity)
+        assert.Contains(t, err.Error(), "unknown date_field", entity)
+    }
 
-func TestEvalRelativeEmploymentHistory_UnknownField(t *testing.T) {
-    s := New(nil, nil, func(EvalContext, any) error { return nil })
-    err := s.evalRelativeEmploymentHistory(context.Background(), time.Now().UTC(), time.Minute, time.Minute, "after", "finish_date", Rule{})
-    assert.Error(t, err)
-    assert.Contains(t, err.Error(), "unknown date_field")
+    _, err := s.queryRelative(context.Background(), Rule{}, relativeSpec{entityType: "spaceship", dateField: "launch"}, time.Now().UTC(), time.Now().UTC(), true)
+    assert.ErrorContains(t, err, "unsupported entity_type")
 }
 
 func TestTickRelative_MissingParams_NoPanic(t *testing.T) {
@@ -4,6 +4,7 @@ import (
     "context"
     "log"
     "sync"
+    "sync/atomic"
     "time"
 
     "github.com/robfig/cron/v3"
@@ -16,16 +17,32 @@ type Service struct {
     store    RuleStore
     eval     EvaluateFunc
     cron     *cron.Cron
+    parser   cron.Parser
     interval time.Duration
+    lookback time.Duration // relative_time catch-up horizon, see defaultLookback
 
-    stop   chan struct{}
-    closed chan struct{}
+    // Leader election: only the holder of the scheduler lease fires
+    // time-based triggers, see lease.go.
+    instance string
+    leaseTTL time.Duration
+    leader   atomic.Bool
+
+    stop        chan struct{}
+    closed      chan struct{}
+    leaseClosed chan struct{}
 
     // Debug enables verbose logging.
     Debug bool
 
-    mu       sync.Mutex
-    fixedIDs map[string]cron.EntryID // key -> cron entry
+    mu          sync.Mutex
+    fixedIDs    map[string]cron.EntryID // key -> cron entry
+    fixedPrints map[string]string       // key -> fingerprint of the scheduled rule
+
+    afterTick []func(ctx context.Context, now time.Time)
+
+    // catchingUp is set while a background reconcile runs, see startCatchUp.
+    catchingUp atomic.Bool
+    catchUps   sync.WaitGroup
 }
 
 // debugf logs only when Debug is true.
@@ -35,27 +52,53 @@ func (s *Service) debugf(format string, args ...any) {
     }
 }
 
+// OnTick registers fn to run on the leader after every relative_time poll,
+// e.g. to send notifications buffered during the tick. Call before Start.
+func (s *Service) OnTick(fn func(ctx context.Context, now time.Time)) {
+    s.afterTick = append(s.afterTick, fn)
+}
+
 // EnableDebug toggles verbose logging.
 func (s *Service) EnableDebug(v bool) { s.Debug = v }
 
 func New(db *gorm.DB, store RuleStore, eval EvaluateFunc) *Service {
     parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
     return &Service{
-        db:       db,
-        store:    store,
-        eval:     eval,
-        cron:     cron.New(cron.WithParser(parser), cron.WithLocation(time.UTC)),
-        interval: time.Minute,
-        stop:     make(chan struct{}),
-        closed:   make(chan struct{}),
-        fixedIDs: map[string]cron.EntryID{},
+        db:          db,
+        store:       store,
+        eval:        eval,
+        cron:        cron.New(cron.WithParser(parser), cron.WithLocation(time.UTC)),
+        parser:      parser,
+        interval:    time.Minute,
+        lookback:    defaultLookback,
+        instance:    defaultInstanceID(),
+        leaseTTL:    defaultLeaseTTL,
+        stop:        make(chan struct{}),
+        closed:      make(chan struct{}),
+        leaseClosed: make(chan struct{}),
+        fixedIDs:    map[string]cron.EntryID{},
+        fixedPrints: map[string]string{},
     }
 }
 
+// Start joins the scheduler leader election and starts cron and the
+// relative_time poller. Every instance runs them, but only the lease holder
+// fires; on winning the lease an instance loads the scheduled_time rules and
+// reconciles fires missed since the last leader in the background (see the
+// misfire policies).
+// Without a database the instance leads on its own.
 func (s *Service) Start(ctx context.Context) error {
-    s.debugf("Start: loading scheduled_time rules and starting poller (interval=%s)", s.interval)
-    if err := s.scheduleFixedTimeRules(ctx); err != nil {
-        return err
+    s.debugf("Start: instance=%s interval=%s", s.instance, s.interval)
+    if s.db == nil {
+        if err := s.scheduleFixedTimeRules(ctx); err != nil {
+            return err
+        }
+        close(s.leaseClosed)
+    } else {
+        if err := s.campaign(ctx); err != nil {
+            return err
+        }
+        go s.runLeaseLoop(ctx)
     }
     s.cron.Start()
     go s.runRelativePoller(ctx)
@@ -63,10 +106,12 @@ func (s *Service) Start(ctx context.Context) error {
 }
 
 func (s *Service) Stop(ctx context.Context) error {
-    s.debugf("Stop: stopping cron and poller")
+    s.debugf("Stop: stopping cron, poller and lease")
     s.cron.Stop()
     close(s.stop)
     <-s.closed
+    <-s.leaseClosed
+    s.catchUps.Wait()
     s.debugf("Stop: all background workers stopped")
     return nil
 }
@@ -2,6 +2,7 @@ package scheduler
 
 import (
     "context"
+    "errors"
     "time"
 )
 
@@ -31,4 +32,10 @@ type RuleStore interface {
 }
 
 // EvaluateFunc is invoked when a rule fires.
-type EvaluateFunc func(ev EvalContext, rule any) error
\ No newline at end of file
+type EvaluateFunc func(ev EvalContext, rule any) error
+
+// ErrHeldBack is returned, wrapped, by an EvaluateFunc when the rule matched
+// but may not fire yet, e.g. outside its active period or during
+// maintenance. The relative_time poller then releases the occurrence so a
+// later tick fires it.
+var ErrHeldBack = errors.New("rule held back")
\ No newline at end of file
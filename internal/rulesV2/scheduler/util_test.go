@@ -4,7 +4,6 @@ package scheduler
 
 import (
     "testing"
-    "time"
 
     "github.com/stretchr/testify/assert"
 )
@@ -33,12 +32,3 @@ func TestToInt(t *testing.T) {
     assert.Equal(t, 0, toInt(""))
     assert.Equal(t, 0, toInt("oops"))
 }
-
-func TestToDuration(t *testing.T) {
-    assert.Equal(t, time.Minute*10, toDuration(10, "minutes"))
-    assert.Equal(t, time.Hour*3, toDuration(3, "hours"))
-    assert.Equal(t, 24*time.Hour, toDuration(1, "day"))
-    assert.Equal(t, 7*24*time.Hour, toDuration(1, "week"))
-    assert.Equal(t, 30*24*time.Hour, toDuration(1, "month"))
-    assert.Equal(t, time.Duration(0), toDuration(5, "unknown"))
-}
\ No newline at end of file
@@ -0,0 +1,114 @@
+package rulesv2
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Trace is the structured result of a dry-run evaluation. It records the
+// trigger parameter match, every evaluated condition node (with the resolved
+// fact value and operator result) and the rendered parameters of each action.
+// No action handler is executed while producing a Trace.
+type Trace struct {
+	Rule             string           `json:"rule"`
+	Now              time.Time        `json:"now"`
+	TriggerType      string           `json:"triggerType"`
+	TriggerExpected  map[string]any   `json:"triggerExpected,omitempty"`
+	TriggerActual    any              `json:"triggerActual,omitempty"`
+	TriggerMatched   bool             `json:"triggerMatched"`
+	Conditions       []ConditionTrace `json:"conditions"`
+	ConditionsPassed bool             `json:"conditionsPassed"`
+	ConditionError   string           `json:"conditionError,omitempty"`
+	Inactive         string           `json:"inactive,omitempty"` // why the rule's active period excludes Now
+	Actions          []ActionTrace    `json:"actions"`
+	WouldFire        bool             `json:"wouldFire"`
+}
+
+// ConditionTrace describes one evaluated condition node. Group nodes carry
+// their evaluated children; children skipped by short-circuiting are absent.
+type ConditionTrace struct {
+	Path      string           `json:"path"`
+	Kind      string           `json:"kind"`
+	Fact      string           `json:"fact,omitempty"`
+	Operator  string           `json:"operator,omitempty"`
+	Value     any              `json:"value,omitempty"`
+	Resolver  string           `json:"resolver,omitempty"`
+	FactFound bool             `json:"factFound,omitempty"`
+	FactValue any              `json:"factValue,omitempty"`
+	Result    bool             `json:"result"`
+	Error     string           `json:"error,omitempty"`
+	Children  []ConditionTrace `json:"children,omitempty"`
+}
+
+// ActionTrace shows what an action would have been called with.
+type ActionTrace struct {
+	Index      int            `json:"index"`
+	Type       string         `json:"type"`
+	Known      bool           `json:"known"`
+	Parameters map[string]any `json:"parameters,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Simulate evaluates a rule against a sample context and returns a full trace.
+// Unlike EvaluateOnce it keeps going after a trigger mismatch or failed
+// conditions so the caller can see every step, and it only renders action
+// parameters; ActionHandler.Execute is never called.
+func (e *Engine) Simulate(evCtx EvalContext, r Rulev2) Trace {
+	if evCtx.Now.IsZero() {
+		evCtx.Now = time.Now().UTC()
+	}
+	if evCtx.Data == nil {
+		evCtx.Data = map[string]any{}
+	}
+	evCtx = evCtx.withFactCache()
+
+	tr := Trace{
+		Rule:            r.Name,
+		Now:             evCtx.Now,
+		TriggerType:     r.Trigger.Type,
+		TriggerExpected: r.Trigger.Parameters,
+		TriggerActual:   evCtx.Data["trigger"],
+		Conditions:      []ConditionTrace{},
+		Actions:         []ActionTrace{},
+	}
+
+	tr.TriggerMatched = matchTriggerParams(evCtx, r.Trigger.Parameters)
+
+	ok, err := e.evalAll(evCtx, r.Conditions, "", &tr.Conditions)
+	tr.ConditionsPassed = ok && err == nil
+	if err != nil {
+		tr.ConditionError = err.Error()
+	}
+
+	for i, a := range r.Actions {
+		at := ActionTrace{Index: i, Type: a.Type}
+		ah, ok := e.R.Actions[a.Type]
+		if ok && ah != nil {
+			at.Known = true
+		} else {
+			at.Error = fmt.Sprintf("unknown action %q", a.Type)
+		}
+		params, err := renderActionParams(evCtx, ah, a.Parameters)
+		if err != nil {
+			at.Error = fmt.Sprintf("render params: %v", err)
+		} else {
+			at.Parameters = params
+		}
+		tr.Actions = append(tr.Actions, at)
+	}
+
+	tr.Inactive = r.inactiveReason(evCtx.Now)
+	tr.WouldFire = tr.TriggerMatched && tr.ConditionsPassed && tr.Inactive == ""
+	return tr
+}
+
+// SimulateRule loads a stored rule and dry-runs it against evCtx.
+func (s *RuleBackEndService) SimulateRule(ctx context.Context, ruleID string, evCtx EvalContext) (*Trace, error) {
+	rule, err := s.Store.GetRuleByID(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	tr := s.Engine.Simulate(evCtx, *rule)
+	return &tr, nil
+}
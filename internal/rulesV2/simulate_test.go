@@ -0,0 +1,78 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"testing"
+)
+
+func TestSimulate_TracesWithoutExecuting(t *testing.T) {
+	stub := &capturingAction{}
+	eng := newTestEngine(map[string]ActionHandler{"STUB": stub})
+
+	rule := Rulev2{
+		Name:    "sim",
+		Trigger: TriggerSpec{Type: "scheduled_event", Parameters: map[string]any{"operation": "create"}},
+		Conditions: []Condition{
+			{Fact: "employee.EmployeeStatus", Operator: "equals", Value: "Active"},
+			{Any: []Condition{
+				{Fact: "employee.Department", Operator: "equals", Value: "HR"},
+				{Fact: "employee.Department", Operator: "equals", Value: "Ops"},
+			}},
+		},
+		Actions: []ActionSpec{
+			{Type: "STUB", Parameters: map[string]any{"msg": "Hi {{.employee.Name}}"}},
+			{Type: "MISSING"},
+		},
+	}
+	data := map[string]any{
+		"trigger":  map[string]any{"operation": "create"},
+		"employee": map[string]any{"EmployeeStatus": "Active", "Department": "Ops", "Name": "Ann"},
+	}
+
+	tr := eng.Simulate(EvalContext{Now: fixedNow(), Data: data}, rule)
+
+	if len(stub.Calls) != 0 {
+		t.Fatalf("simulate must not execute actions, got %d calls", len(stub.Calls))
+	}
+	if !tr.TriggerMatched || !tr.ConditionsPassed || !tr.WouldFire {
+		t.Fatalf("expected matched/passed/wouldFire, got %+v", tr)
+	}
+	if len(tr.Conditions) != 2 {
+		t.Fatalf("expected 2 top-level condition traces, got %d", len(tr.Conditions))
+	}
+	leaf := tr.Conditions[0]
+	if leaf.Kind != condLeaf || !leaf.FactFound || leaf.FactValue != "Active" || !leaf.Result || leaf.Resolver == "" {
+		t.Fatalf("unexpected leaf trace: %+v", leaf)
+	}
+	group := tr.Conditions[1]
+	if group.Kind != condAny || len(group.Children) != 2 || group.Children[1].Path != "1.any[1]" {
+		t.Fatalf("unexpected group trace: %+v", group)
+	}
+	if got := tr.Actions[0].Parameters["msg"]; got != "Hi Ann" {
+		t.Fatalf("expected rendered msg, got %v", got)
+	}
+	if tr.Actions[1].Known || tr.Actions[1].Error == "" {
+		t.Fatalf("expected unknown action to be flagged, got %+v", tr.Actions[1])
+	}
+}
+
+func TestSimulate_ReportsTriggerMismatchAndContinues(t *testing.T) {
+	eng := newTestEngine(map[string]ActionHandler{"STUB": &capturingAction{}})
+	rule := Rulev2{
+		Name:       "mismatch",
+		Trigger:    TriggerSpec{Type: "T", Parameters: map[string]any{"operation": "delete"}},
+		Conditions: []Condition{{Fact: "employee.Missing", Operator: "isNotNull"}},
+		Actions:    []ActionSpec{{Type: "STUB"}},
+	}
+	tr := eng.Simulate(EvalContext{Now: fixedNow(), Data: map[string]any{
+		"trigger": map[string]any{"operation": "create"},
+	}}, rule)
+
+	if tr.TriggerMatched || tr.WouldFire {
+		t.Fatalf("expected trigger mismatch, got %+v", tr)
+	}
+	if len(tr.Conditions) != 1 || tr.Conditions[0].FactFound {
+		t.Fatalf("expected conditions still traced with missing fact, got %+v", tr.Conditions)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	"Automated-Scheduling-Project/internal/database/gen_models"
 	"Automated-Scheduling-Project/internal/database/models"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 /*
@@ -22,10 +25,79 @@ type DBRuleStore struct {
 
 /* ----------------------------- Migrations -------------------------------- */
 
-// EnsureRulesTable runs migration for the rules table.
-// Call once at startup after connecting to DB.
+// EnsureRulesTable runs migration for the rules table, its revisions, run
+// history, action queue, relative_time ledger, time-trigger state, the
+// scheduler lease, the holiday calendar, throttle hits and locks, engine
+// settings and buffered digest notifications, adds users.created_at for the
+// relative_time "user" entity and grants the rule permissions split out of
+// "rules". Call once at startup after connecting to DB.
 func EnsureRulesTable(db *gorm.DB) error {
-	return db.AutoMigrate(&models.Rule{})
+	if err := ensureUserCreatedAt(db); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Rule{}, &models.RuleRevision{}, &models.RuleRun{}, &models.RuleJob{}, &models.RuleFiring{}, &models.RuleTriggerState{}, &models.SchedulerLease{}, &models.PublicHoliday{}, &models.RuleThrottleHit{}, &models.RuleThrottleLock{}, &models.RuleSetting{}, &models.NotificationDigestItem{}, &models.RuleAPIKey{}, &models.RuleMaintenance{}); err != nil {
+		return err
+	}
+	return grantSplitRulePermissions(db)
+}
+
+// settingPermissionsSplit is the RuleSetting recording that
+// grantSplitRulePermissions has run.
+const settingPermissionsSplit = "rules_permissions_split"
+
+// grantSplitRulePermissions gives every role that holds "rules" the
+// "rules-edit" and "rules-admin" permissions, which used to be part of it, so
+// existing rule editors keep their access. It runs once per database, so
+// later revocations of the new permissions stick.
+func grantSplitRulePermissions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RuleSetting{Key: settingPermissionsSplit, Value: "done", UpdatedBy: "migration"})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if !tx.Migrator().HasTable(&models.RolePermission{}) {
+			return nil // no roles yet; the seed grants them
+		}
+		for _, page := range []string{PermissionRulesEdit, PermissionRulesAdmin} {
+			err := tx.Exec(`INSERT INTO role_permissions (role_id, page)
+				SELECT rp.role_id, ? FROM role_permissions rp
+				WHERE rp.page = ? AND NOT EXISTS (
+					SELECT 1 FROM role_permissions x WHERE x.role_id = rp.role_id AND x.page = ?)`,
+				page, PermissionRulesView, page).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// userCreatedAt is the users.created_at column as ensureUserCreatedAt adds
+// it: nullable and, at first, without a default.
+type userCreatedAt struct {
+	CreatedAt *time.Time `gorm:"column:created_at"`
+}
+
+func (userCreatedAt) TableName() string { return gen_models.TableNameUser }
+
+// ensureUserCreatedAt adds users.created_at to databases created before the
+// column existed. Existing users are left NULL rather than stamped with the
+// migration time, so "days after a user was created" rules do not fire for
+// all of them at once. On Postgres the column then gets the seed schema's
+// CURRENT_TIMESTAMP default, so gen_models.User regenerates the same.
+func ensureUserCreatedAt(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&userCreatedAt{}) || m.HasColumn(&userCreatedAt{}, "created_at") {
+		return nil
+	}
+	if err := m.AddColumn(&userCreatedAt{}, "CreatedAt"); err != nil {
+		return err
+	}
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return db.Exec("ALTER TABLE users ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP").Error
 }
 
 /* --------------------------- JSON <-> Spec -------------------------------- */
@@ -54,7 +126,7 @@ func (s DBRuleStore) ListByTrigger(ctx context.Context, triggerType string) ([]R
 	var rows []models.Rule
 	if err := s.DB.WithContext(ctx).
 		Where("enabled = ? AND trigger_type = ?", true, triggerType).
-		Order("id ASC").
+		Order(ruleExecutionOrder).
 		Find(&rows).Error; err != nil {
 		return nil, err
 	}
@@ -65,6 +137,7 @@ func (s DBRuleStore) ListByTrigger(ctx context.Context, triggerType string) ([]R
 		if err != nil {
 			return nil, fmt.Errorf("rule id=%d json decode: %w", r.ID, err)
 		}
+		spec.ID = fmt.Sprint(r.ID)
 		out = append(out, spec)
 	}
 	return out, nil
@@ -86,6 +159,7 @@ func (s DBRuleStore) Create(ctx context.Context, reg *Registry, spec Rulev2, ena
 		TriggerType: spec.Trigger.Type,
 		Spec:        js,
 		Enabled:     enabled,
+		Priority:    spec.Priority,
 	}
 	if err := s.DB.WithContext(ctx).Create(&row).Error; err != nil {
 		return models.Rule{}, err
@@ -110,6 +184,7 @@ func (s DBRuleStore) Update(ctx context.Context, reg *Registry, id uint, spec Ru
 	row.Name = spec.Name
 	row.TriggerType = spec.Trigger.Type
 	row.Spec = js
+	row.Priority = spec.Priority
 	if enabled != nil {
 		row.Enabled = *enabled
 	}
@@ -170,6 +245,7 @@ func (s DBRuleStore) Seed(ctx context.Context, reg *Registry, spec Rulev2, enabl
 	if tx.Error == nil {
 		row.TriggerType = spec.Trigger.Type
 		row.Spec = js
+		row.Priority = spec.Priority
 		row.Enabled = enabled
 		if err := s.DB.WithContext(ctx).Save(&row).Error; err != nil {
 			return models.Rule{}, err
@@ -185,6 +261,7 @@ func (s DBRuleStore) Seed(ctx context.Context, reg *Registry, spec Rulev2, enabl
 		TriggerType: spec.Trigger.Type,
 		Spec:        js,
 		Enabled:     enabled,
+		Priority:    spec.Priority,
 	}
 	if err := s.DB.WithContext(ctx).Create(&row).Error; err != nil {
 		return models.Rule{}, err
@@ -0,0 +1,65 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"testing"
+
+	"Automated-Scheduling-Project/internal/database/gen_models"
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestEnsureRulesTable_AddsUserCreatedAt(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, username TEXT NOT NULL,
+		password TEXT NOT NULL, forgot_password_link TEXT, role TEXT DEFAULT 'User', employee_number TEXT NOT NULL)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (username, password, employee_number) VALUES ('old', 'x', 'E1')`).Error)
+
+	require.NoError(t, EnsureRulesTable(db))
+	require.NoError(t, EnsureRulesTable(db), "running it again is a no-op")
+	assert.True(t, db.Migrator().HasColumn(&gen_models.User{}, "created_at"))
+
+	// New users get the column's CURRENT_TIMESTAMP default, which only
+	// Postgres can add to an existing table; here the column stays nullable.
+	var created []userCreatedAt
+	require.NoError(t, db.Find(&created).Error)
+	require.Len(t, created, 1)
+	assert.Nil(t, created[0].CreatedAt, "existing users are not stamped with the migration time")
+
+	var users []gen_models.User
+	require.NoError(t, db.Find(&users).Error, "a NULL created_at still loads")
+	assert.True(t, users[0].CreatedAt.IsZero())
+}
+
+func TestEnsureRulesTable_GrantsSplitRulePermissions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.RolePermission{}))
+	require.NoError(t, db.Create([]models.RolePermission{
+		{RoleID: 1, Page: "rules"},
+		{RoleID: 2, Page: "rules"}, {RoleID: 2, Page: "rules-edit"},
+		{RoleID: 3, Page: "users"},
+	}).Error)
+
+	pages := func(roleID int) []string {
+		var out []string
+		require.NoError(t, db.Model(&models.RolePermission{}).Where("role_id = ?", roleID).Order("page").Pluck("page", &out).Error)
+		return out
+	}
+
+	require.NoError(t, EnsureRulesTable(db))
+	assert.Equal(t, []string{"rules", "rules-admin", "rules-edit"}, pages(1))
+	assert.Equal(t, []string{"rules", "rules-admin", "rules-edit"}, pages(2))
+	assert.Equal(t, []string{"users"}, pages(3), "roles without rules get nothing")
+
+	// A later revocation is not undone by the next startup.
+	require.NoError(t, db.Where("role_id = ? AND page = ?", 1, "rules-admin").Delete(&models.RolePermission{}).Error)
+	require.NoError(t, EnsureRulesTable(db))
+	assert.Equal(t, []string{"rules", "rules-edit"}, pages(1))
+}
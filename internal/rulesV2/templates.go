@@ -0,0 +1,299 @@
+package rulesv2
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode"
+)
+
+// namedLayouts are shorthand layouts accepted by formatDate in addition to
+// any Go reference layout (e.g. "Mon 02/01").
+var namedLayouts = map[string]string{
+	"iso":      "2006-01-02",
+	"long":     "2 January 2006",
+	"short":    "02 Jan 2006",
+	"datetime": "2006-01-02 15:04",
+	"time":     "15:04",
+	"rfc3339":  time.RFC3339,
+}
+
+// templateFuncs returns the function set available to action parameter
+// templates. Every function is pure: date arithmetic is relative to now
+// (EvalContext.Now), never the wall clock, so simulations are repeatable.
+// The list is documented in metadata.GetFunctionMetadata; keep both in sync.
+func templateFuncs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		// dates
+		"now":        func() time.Time { return now },
+		"formatDate": tplFormatDate,
+		"addDays": func(n int, v any) (time.Time, error) {
+			t, err := tplTime(v)
+			return t.AddDate(0, 0, n), err
+		},
+		"addMonths": func(n int, v any) (time.Time, error) {
+			t, err := tplTime(v)
+			return t.AddDate(0, n, 0), err
+		},
+		"daysUntil": func(v any) (int, error) { return tplDaysBetween(now, v) },
+		"daysSince": func(v any) (int, error) {
+			d, err := tplDaysBetween(now, v)
+			return -d, err
+		},
+
+		// strings
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"title": tplTitle,
+		"trim":  strings.TrimSpace,
+
+		// values
+		"default":      tplDefault,
+		"pluralize":    tplPluralize,
+		"formatNumber": tplFormatNumber,
+		"join":         tplJoin,
+	}
+}
+
+// checkTemplate parses s with the template function set so unknown
+// functions and syntax errors are reported before a rule is saved.
+func checkTemplate(s string) error {
+	if !strings.Contains(s, "{{") {
+		return nil
+	}
+	_, err := template.New("param").Funcs(templateFuncs(time.Time{})).Parse(s)
+	return err
+}
+
+// collectTemplateErrors walks a parameter value (strings, maps, slices) and
+// reports template problems keyed by dotted path.
+func collectTemplateErrors(path string, v any, fn func(path string, err error)) {
+	eachParamString(path, v, func(path, s string) {
+		if err := checkTemplate(s); err != nil {
+			fn(path, err)
+		}
+	})
+}
+
+// eachParamString calls fn for every string in a parameter value, keyed by
+// dotted path, visiting map keys in sorted order.
+func eachParamString(path string, v any, fn func(path, s string)) {
+	switch t := v.(type) {
+	case string:
+		fn(path, t)
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			eachParamString(path+"."+k, t[k], fn)
+		}
+	case []any:
+		for i, val := range t {
+			eachParamString(fmt.Sprintf("%s[%d]", path, i), val, fn)
+		}
+	}
+}
+
+// templateRoots returns the top-level data keys a template reads, e.g.
+// "employee" for {{.employee.FirstName}} or {{$.employee.FirstName}}. Fields
+// inside range and with blocks are relative to the element, so only their
+// pipelines and else branches are inspected.
+func templateRoots(s string) ([]string, error) {
+	if !strings.Contains(s, "{{") {
+		return nil, nil
+	}
+	t, err := template.New("param").Funcs(templateFuncs(time.Time{})).Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var out []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.ElseList)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c)
+			}
+		case *parse.CommandNode:
+			for _, a := range n.Args {
+				walk(a)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			add(n.Ident[0])
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				add(n.Ident[1])
+			}
+		}
+	}
+	walk(t.Tree.Root)
+	return out, nil
+}
+
+func tplTime(v any) (time.Time, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return time.Time{}, fmt.Errorf("date value is nil")
+		}
+		v = rv.Elem().Interface()
+	}
+	if t, ok := asTime(v); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot use %v (%T) as a date", v, v)
+}
+
+// tplFormatDate formats v with a named or Go layout. Empty values render as "".
+func tplFormatDate(layout string, v any) (string, error) {
+	if isNilish(v) || v == "" {
+		return "", nil
+	}
+	t, err := tplTime(v)
+	if err != nil {
+		return "", err
+	}
+	if l, ok := namedLayouts[strings.ToLower(layout)]; ok {
+		layout = l
+	}
+	return t.Format(layout), nil
+}
+
+// tplDaysBetween counts calendar days from now's date to v's date.
+func tplDaysBetween(now time.Time, v any) (int, error) {
+	t, err := tplTime(v)
+	if err != nil {
+		return 0, err
+	}
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(math.Round(to.Sub(from).Hours() / 24)), nil
+}
+
+func tplTitle(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		out := r
+		if unicode.IsSpace(prev) || prev == '-' {
+			out = unicode.ToUpper(r)
+		} else {
+			out = unicode.ToLower(r)
+		}
+		prev = r
+		return out
+	}, s)
+}
+
+// tplDefault returns def when v is nil, empty or zero; usage: {{ .x | default "n/a" }}
+func tplDefault(def any, v any) any {
+	if isNilish(v) {
+		return def
+	}
+	rv := reflect.ValueOf(v)
+	if rv.IsZero() {
+		return def
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		if rv.Len() == 0 {
+			return def
+		}
+	}
+	return v
+}
+
+// tplPluralize picks the singular or plural word for n; usage: {{ pluralize .n "day" "days" }}
+func tplPluralize(n any, singular, plural string) (string, error) {
+	f, ok := asFloat(n)
+	if !ok {
+		return "", fmt.Errorf("pluralize: %v (%T) is not a number", n, n)
+	}
+	if f == 1 || f == -1 {
+		return singular, nil
+	}
+	return plural, nil
+}
+
+// tplFormatNumber renders v with the given decimals and thousands separators.
+func tplFormatNumber(decimals int, v any) (string, error) {
+	f, ok := asFloat(v)
+	if !ok {
+		return "", fmt.Errorf("formatNumber: %v (%T) is not a number", v, v)
+	}
+	if decimals < 0 {
+		decimals = 0
+	}
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+	var b strings.Builder
+	if f < 0 {
+		b.WriteByte('-')
+	}
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(frac)
+	return b.String(), nil
+}
+
+// tplJoin joins any slice with sep; usage: {{ join ", " .names }}
+func tplJoin(sep string, v any) (string, error) {
+	if isNilish(v) {
+		return "", nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: %T is not a list", v)
+	}
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"testing"
+	"time"
+
+	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateFuncs_Render(t *testing.T) {
+	expiry := time.Date(2025, 1, 11, 0, 0, 0, 0, time.UTC)
+	ev := EvalContext{Now: fixedNow(), Data: map[string]any{
+		"employeeCompetency": map[string]any{"expiry_date": expiry, "started": "2024-12-25"},
+		"employee":           map[string]any{"Firstname": "  ann-marie  ", "Phone": ""},
+		"names":              []string{"Ann", "Bob"},
+		"count":              1,
+		"amount":             1234567.891,
+	}}
+
+	cases := map[string]string{
+		`{{ .employeeCompetency.expiry_date | formatDate "long" }}`:                          "11 January 2025",
+		`{{ .employeeCompetency.expiry_date | formatDate "02/01/2006" }}`:                    "11/01/2025",
+		`{{ daysUntil .employeeCompetency.expiry_date }}`:                                    "10",
+		`{{ daysSince .employeeCompetency.started }}`:                                        "7",
+		`{{ now | addDays 30 | formatDate "iso" }}`:                                          "2025-01-31",
+		`{{ .employeeCompetency.expiry_date | addMonths 2 | formatDate "short" }}`:           "11 Mar 2025",
+		`{{ .employee.Firstname | trim | title }}`:                                           "Ann-Marie",
+		`{{ upper "abc" }}{{ lower "DEF" }}`:                                                 "ABCdef",
+		`{{ .employee.Phone | default "n/a" }}|{{ .employee.Missing | default "none" }}`:     "n/a|none",
+		`{{ .count }} {{ pluralize .count "day" "days" }}, 3 {{ pluralize 3 "day" "days" }}`: "1 day, 3 days",
+		`{{ .amount | formatNumber 2 }}|{{ -1000 | formatNumber 0 }}`:                        "1,234,567.89|-1,000",
+		`{{ join ", " .names }}`:                                                             "Ann, Bob",
+		`{{ .missing | formatDate "long" }}`:                                                 "",
+	}
+	for tmpl, want := range cases {
+		got, err := renderStringTemplate(tmpl, ev)
+		if assert.NoError(t, err, tmpl) {
+			assert.Equal(t, want, got, tmpl)
+		}
+	}
+
+	_, err := renderStringTemplate(`{{ formatDate "long" "not a date" }}`, ev)
+	assert.Error(t, err)
+}
+
+func TestTemplateFuncs_MatchMetadata(t *testing.T) {
+	funcs := templateFuncs(time.Time{})
+	documented := map[string]bool{}
+	for _, f := range meta.GetFunctionMetadata() {
+		documented[f.Name] = true
+		assert.Contains(t, funcs, f.Name, "documented function missing from FuncMap")
+		assert.NoError(t, checkTemplate(f.Example), "example for %s must parse", f.Name)
+	}
+	for name := range funcs {
+		assert.True(t, documented[name], "function %q is not documented in metadata", name)
+	}
+}
+
+func TestValidateRuleParameters_RejectsUnknownTemplateFunctions(t *testing.T) {
+	rule := Rulev2{
+		Name:    "bad template",
+		Trigger: TriggerSpec{Type: "scheduled_event"},
+		Actions: []ActionSpec{{
+			Type: "notification",
+			Parameters: map[string]any{
+				"recipients": "a@b.c",
+				"subject":    "ok {{ upper .x }}",
+				"message":    "{{ exec .x }}",
+				"type":       "email",
+			},
+		}},
+	}
+	res := ValidateRuleParameters(rule)
+	require.False(t, res.Valid)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "actions[0].message", res.Errors[0].Parameter)
+	assert.Contains(t, res.Errors[0].Message, `function "exec" not defined`)
+}
+
+func TestTemplateRoots(t *testing.T) {
+	roots, err := templateRoots(`{{.employee.FirstName}} {{ if .trigger.operation }}{{ upper $.competency.CompetencyName }}{{end}}` +
+		`{{range .names}}{{.ignored}}{{else}}{{.fallback}}{{end}}{{with .employee}}{{.alsoIgnored}}{{end}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"employee", "trigger", "competency", "names", "fallback"}, roots)
+
+	roots, err = templateRoots("no template")
+	require.NoError(t, err)
+	assert.Empty(t, roots)
+}
@@ -0,0 +1,311 @@
+package rulesv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RuleLimits caps how often a rule may fire. Durations use Go syntax plus a
+// "d" suffix for days, e.g. "90m", "24h", "7d".
+type RuleLimits struct {
+	// MaxFires is how many times the rule may fire within Window.
+	MaxFires int    `json:"maxFires,omitempty"`
+	Window   string `json:"window,omitempty"`
+	// Cooldown is the minimum time between two fires for the same key.
+	// CooldownKey is a template such as
+	// "{{.employee.EmployeeNumber}}/{{.competency.CompetencyID}}"; it
+	// defaults to the evaluated entity (e.g. "employee_competency:42").
+	Cooldown    string `json:"cooldown,omitempty"`
+	CooldownKey string `json:"cooldownKey,omitempty"`
+}
+
+// validate checks the limits of a rule; nil limits are valid.
+func (l *RuleLimits) validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.MaxFires < 0 {
+		return fmt.Errorf("maxFires must not be negative")
+	}
+	if l.MaxFires > 0 {
+		if l.Window == "" {
+			return fmt.Errorf("maxFires needs a window")
+		}
+		if _, err := parseLimitDuration(l.Window); err != nil {
+			return fmt.Errorf("window: %w", err)
+		}
+	}
+	if l.Cooldown != "" {
+		if _, err := parseLimitDuration(l.Cooldown); err != nil {
+			return fmt.Errorf("cooldown: %w", err)
+		}
+	}
+	if l.CooldownKey != "" {
+		if err := checkTemplate(l.CooldownKey); err != nil {
+			return fmt.Errorf("cooldownKey: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseLimitDuration parses a positive duration, accepting "Nd" for days.
+func parseLimitDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, nil
+}
+
+// Limiter decides whether a matched rule may fire. A non-empty reason
+// suppresses the firing; an allowed firing is counted against the rule's
+// limits. later reports a hold that passes with time, such as maintenance,
+// rather than one that uses the firing up.
+type Limiter interface {
+	Allow(ctx context.Context, evCtx EvalContext, r Rulev2) (reason string, later bool, err error)
+}
+
+// allow asks the engine's limiter, if any. Limiter errors are logged and the
+// rule fires: a broken limiter must not silence every rule.
+func (e *Engine) allow(evCtx EvalContext, r Rulev2) (string, bool) {
+	if e.Limiter == nil {
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reason, later, err := e.Limiter.Allow(ctx, evCtx, r)
+	if err != nil {
+		log.Printf("rules: limiter error for rule %q, firing anyway: %v", r.Name, err)
+		return "", false
+	}
+	return reason, later
+}
+
+// holdBack reports why a matched rule must not fire, or "" if it may: it is
+// outside its active period, or the limiter suppresses it. later is true
+// when the hold passes with time (the active period or maintenance), so a
+// relative_time occurrence can still fire afterwards. The period is checked
+// first so it does not use up the rule's limits.
+func (e *Engine) holdBack(evCtx EvalContext, r Rulev2) (reason string, later bool) {
+	if reason := r.inactiveReason(evCtx.Now); reason != "" {
+		return reason, true
+	}
+	return e.allow(evCtx, r)
+}
+
+// settingKillSwitch is the RuleSetting that suppresses every rule while "on".
+const settingKillSwitch = "kill_switch"
+
+// killSwitchTTL is how long DbLimiter trusts its cached kill switch and
+// maintenance state.
+const killSwitchTTL = 5 * time.Second
+
+// KillSwitchState is the engine-wide kill switch.
+type KillSwitchState struct {
+	Engaged   bool      `json:"engaged"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// DbLimiter enforces the kill switch, maintenance windows and rule limits,
+// keeping hits in the rule_throttle_hits table so all API instances share
+// them.
+type DbLimiter struct {
+	DB *gorm.DB
+
+	mu       sync.Mutex
+	killed   bool
+	silences []silence
+	checked  time.Time
+}
+
+// KillSwitch reads the kill switch from the database.
+func (l *DbLimiter) KillSwitch(ctx context.Context) (KillSwitchState, error) {
+	var row models.RuleSetting
+	err := l.DB.WithContext(ctx).Where("key = ?", settingKillSwitch).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return KillSwitchState{}, nil
+	}
+	if err != nil {
+		return KillSwitchState{}, err
+	}
+	return KillSwitchState{Engaged: row.Value == "on", UpdatedBy: row.UpdatedBy, UpdatedAt: row.UpdatedAt}, nil
+}
+
+// SetKillSwitch engages or releases the kill switch for all instances.
+func (l *DbLimiter) SetKillSwitch(ctx context.Context, engaged bool, by string) error {
+	value := "off"
+	if engaged {
+		value = "on"
+	}
+	row := models.RuleSetting{Key: settingKillSwitch, Value: value, UpdatedBy: by}
+	err := l.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.killed, l.checked = engaged, time.Now()
+	l.mu.Unlock()
+	return nil
+}
+
+// engaged reports the kill switch and the maintenance windows in force,
+// re-reading them at most every killSwitchTTL.
+func (l *DbLimiter) engaged(ctx context.Context) (bool, []silence, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.checked.IsZero() && time.Since(l.checked) < killSwitchTTL {
+		return l.killed, l.silences, nil
+	}
+	st, err := l.KillSwitch(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	silences, err := l.activeSilences(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	l.killed, l.silences, l.checked = st.Engaged, silences, time.Now()
+	return l.killed, l.silences, nil
+}
+
+// invalidate makes the next Allow re-read the kill switch and maintenance.
+func (l *DbLimiter) invalidate() {
+	l.mu.Lock()
+	l.checked = time.Time{}
+	l.mu.Unlock()
+}
+
+func (l *DbLimiter) Allow(ctx context.Context, evCtx EvalContext, r Rulev2) (string, bool, error) {
+	killed, silences, err := l.engaged(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	if killed {
+		return "kill switch engaged", false, nil
+	}
+	// Maintenance silences wall-clock time, whatever time is being evaluated.
+	wall := time.Now()
+	for _, s := range silences {
+		if s.covers(r.Trigger.Type, wall) {
+			return "maintenance mode until " + s.endsAt.UTC().Format(time.RFC3339), true, nil
+		}
+	}
+	lim := r.Limits
+	if lim == nil || (lim.MaxFires == 0 && lim.Cooldown == "") {
+		return "", false, nil
+	}
+
+	now := evCtx.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	ruleID := r.ID
+	if ruleID == "" {
+		ruleID = r.Name
+	}
+	key, err := cooldownKey(evCtx, lim)
+	if err != nil {
+		return "", false, err
+	}
+
+	var reason string
+	err = l.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the rule's row so concurrent firings cannot both count the
+		// same hits and both fire.
+		lock := models.RuleThrottleLock{RuleID: ruleID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("rule_id = ?", ruleID).Take(&lock).Error; err != nil {
+			return err
+		}
+		var keep time.Duration
+		if lim.MaxFires > 0 {
+			window, err := parseLimitDuration(lim.Window)
+			if err != nil {
+				return err
+			}
+			keep = window
+			var n int64
+			if err := tx.Model(&models.RuleThrottleHit{}).
+				Where("rule_id = ? AND fired_at > ?", ruleID, now.Add(-window)).
+				Count(&n).Error; err != nil {
+				return err
+			}
+			if n >= int64(lim.MaxFires) {
+				reason = fmt.Sprintf("quota of %d fires per %s reached", lim.MaxFires, lim.Window)
+				return nil
+			}
+		}
+		if lim.Cooldown != "" {
+			cooldown, err := parseLimitDuration(lim.Cooldown)
+			if err != nil {
+				return err
+			}
+			keep = max(keep, cooldown)
+			var n int64
+			if err := tx.Model(&models.RuleThrottleHit{}).
+				Where("rule_id = ? AND key = ? AND fired_at > ?", ruleID, key, now.Add(-cooldown)).
+				Count(&n).Error; err != nil {
+				return err
+			}
+			if n > 0 {
+				reason = fmt.Sprintf("cooldown of %s for %q", lim.Cooldown, key)
+				return nil
+			}
+		}
+		// Hits older than both limits no longer matter.
+		if err := tx.Where("rule_id = ? AND fired_at <= ?", ruleID, now.Add(-keep)).
+			Delete(&models.RuleThrottleHit{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.RuleThrottleHit{RuleID: ruleID, Key: key, FiredAt: now}).Error
+	})
+	return reason, false, err
+}
+
+// cooldownKey renders the rule's cooldown key, defaulting to the evaluated
+// entity.
+func cooldownKey(evCtx EvalContext, lim *RuleLimits) (string, error) {
+	if lim.CooldownKey != "" {
+		key, err := renderStringTemplate(lim.CooldownKey, evCtx)
+		if err != nil {
+			return "", fmt.Errorf("render cooldownKey: %w", err)
+		}
+		return key, nil
+	}
+	entityType, entityID := entityRef(evCtx.Data)
+	if entityType == "" {
+		return "", nil
+	}
+	return entityType + ":" + entityID, nil
+}
@@ -0,0 +1,167 @@
+//go:build unit
+
+package rulesv2
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"Automated-Scheduling-Project/internal/database/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newThrottledEngine(t *testing.T) (*Engine, *DbLimiter, *capturingAction, *memRecorder) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.RuleThrottleHit{}, &models.RuleThrottleLock{}, &models.RuleSetting{}, &models.RuleMaintenance{}))
+	act, rec := &capturingAction{}, &memRecorder{}
+	eng := newTestEngine(map[string]ActionHandler{"notify": act})
+	eng.Recorder = rec
+	lim := &DbLimiter{DB: db}
+	eng.Limiter = lim
+	return eng, lim, act, rec
+}
+
+func competencyEvent(now time.Time, id int) EvalContext {
+	return EvalContext{Now: now, Data: map[string]any{
+		"trigger":            map[string]any{"type": "relative_time"},
+		"employeeCompetency": map[string]any{"employee_competency_id": id},
+	}}
+}
+
+func TestLimiter_Cooldown(t *testing.T) {
+	eng, _, act, rec := newThrottledEngine(t)
+	rule := Rulev2{ID: "1", Name: "expiry reminder", Trigger: TriggerSpec{Type: "relative_time"},
+		Actions: []ActionSpec{{Type: "notify"}},
+		Limits:  &RuleLimits{Cooldown: "7d"}}
+	now := fixedNow()
+
+	require.NoError(t, eng.EvaluateOnce(competencyEvent(now, 42), rule))
+	require.NoError(t, eng.EvaluateOnce(competencyEvent(now.Add(24*time.Hour), 42), rule))
+	require.NoError(t, eng.EvaluateOnce(competencyEvent(now.Add(24*time.Hour), 43), rule))
+	require.NoError(t, eng.EvaluateOnce(competencyEvent(now.Add(8*24*time.Hour), 42), rule))
+
+	assert.Len(t, act.Calls, 3)
+	require.Len(t, rec.Runs, 4)
+	assert.Equal(t, RunStatusSuppressed, rec.Runs[1].Status)
+	assert.Equal(t, `cooldown of 7d for "employee_competency:42"`, rec.Runs[1].Reason)
+	assert.Equal(t, RunStatusSuccess, rec.Runs[3].Status)
+}
+
+func TestLimiter_CooldownKeyTemplate(t *testing.T) {
+	eng, _, act, _ := newThrottledEngine(t)
+	rule := Rulev2{ID: "1", Name: "per employee", Trigger: TriggerSpec{Type: "relative_time"},
+		Actions: []ActionSpec{{Type: "notify"}},
+		Limits:  &RuleLimits{Cooldown: "24h", CooldownKey: "{{.employee.EmployeeNumber}}"}}
+	ev := func(emp string) EvalContext {
+		return EvalContext{Now: fixedNow(), Data: map[string]any{"employee": map[string]any{"EmployeeNumber": emp}}}
+	}
+
+	for _, emp := range []string{"E1", "E1", "E2"} {
+		require.NoError(t, eng.EvaluateOnce(ev(emp), rule))
+	}
+	assert.Len(t, act.Calls, 2)
+}
+
+func TestLimiter_QuotaPerWindow(t *testing.T) {
+	eng, _, act, rec := newThrottledEngine(t)
+	rule := Rulev2{ID: "2", Name: "event edits", Trigger: TriggerSpec{Type: "scheduled_event"},
+		Actions: []ActionSpec{{Type: "notify"}},
+		Limits:  &RuleLimits{MaxFires: 2, Window: "1h"}}
+	now := fixedNow()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, eng.EvaluateOnce(competencyEvent(now.Add(time.Duration(i)*time.Minute), i), rule))
+	}
+	assert.Len(t, act.Calls, 2)
+	assert.Equal(t, "quota of 2 fires per 1h reached", rec.Runs[2].Reason)
+
+	// The window slides: an hour after the first fire there is room again.
+	require.NoError(t, eng.EvaluateOnce(competencyEvent(now.Add(61*time.Minute), 9), rule))
+	assert.Len(t, act.Calls, 3)
+}
+
+func TestLimiter_ConcurrentFiresAreSerialised(t *testing.T) {
+	// A file database, so each goroutine gets its own connection.
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "rules.db")+"?_busy_timeout=5000"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.RuleThrottleHit{}, &models.RuleThrottleLock{}, &models.RuleSetting{}, &models.RuleMaintenance{}))
+	lim := &DbLimiter{DB: db}
+	rule := Rulev2{ID: "3", Name: "event reminder", Trigger: TriggerSpec{Type: "scheduled_event"},
+		Limits: &RuleLimits{MaxFires: 1, Window: "1h", Cooldown: "1h"}}
+	ev := competencyEvent(fixedNow(), 42)
+
+	const n = 8
+	reasons := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reasons[i], _, errs[i] = lim.Allow(context.Background(), ev, rule)
+		}(i)
+	}
+	wg.Wait()
+
+	allowed := 0
+	for i := 0; i < n; i++ {
+		require.NoError(t, errs[i])
+		if reasons[i] == "" {
+			allowed++
+		}
+	}
+	assert.Equal(t, 1, allowed, "only one of the concurrent firings may fire")
+}
+
+func TestLimiter_KillSwitch(t *testing.T) {
+	eng, lim, act, rec := newThrottledEngine(t)
+	ctx := context.Background()
+	rule := Rulev2{ID: "3", Name: "any", Trigger: TriggerSpec{Type: "job_position"}, Actions: []ActionSpec{{Type: "notify"}}}
+
+	require.NoError(t, lim.SetKillSwitch(ctx, true, "admin@example.com"))
+	state, err := lim.KillSwitch(ctx)
+	require.NoError(t, err)
+	assert.True(t, state.Engaged)
+	assert.Equal(t, "admin@example.com", state.UpdatedBy)
+
+	require.NoError(t, eng.EvaluateOnce(EvalContext{Now: fixedNow(), Data: map[string]any{}}, rule))
+	assert.Empty(t, act.Calls)
+	require.Len(t, rec.Runs, 1)
+	assert.Equal(t, "kill switch engaged", rec.Runs[0].Reason)
+	assert.True(t, rec.Runs[0].Matched)
+
+	require.NoError(t, lim.SetKillSwitch(ctx, false, "admin@example.com"))
+	require.NoError(t, eng.EvaluateOnce(EvalContext{Now: fixedNow(), Data: map[string]any{}}, rule))
+	assert.Len(t, act.Calls, 1)
+}
+
+func TestRuleLimits_Validate(t *testing.T) {
+	reg := NewRegistryWithDefaults()
+	base := Rulev2{Name: "r", Trigger: TriggerSpec{Type: "job_position"}}
+	for _, tc := range []struct {
+		limits *RuleLimits
+		ok     bool
+	}{
+		{nil, true},
+		{&RuleLimits{MaxFires: 10, Window: "24h", Cooldown: "7d", CooldownKey: "{{.employee.EmployeeNumber}}"}, true},
+		{&RuleLimits{MaxFires: 10}, false},
+		{&RuleLimits{MaxFires: -1}, false},
+		{&RuleLimits{Cooldown: "a week"}, false},
+		{&RuleLimits{Cooldown: "0d"}, false},
+		{&RuleLimits{Cooldown: "1h", CooldownKey: "{{.x"}, false},
+	} {
+		r := base
+		r.Limits = tc.limits
+		err := ValidateRule(reg, r)
+		assert.Equal(t, tc.ok, err == nil, "%+v: %v", tc.limits, err)
+	}
+}
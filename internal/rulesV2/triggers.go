@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
+
 	"gorm.io/gorm"
 )
 
@@ -20,6 +22,12 @@ func NewTrigger(db *gorm.DB, kind string) *DBTrigger {
 	return &DBTrigger{DB: db, Kind: kind}
 }
 
+// Describe returns the metadata of the trigger's kind.
+func (t *DBTrigger) Describe() meta.TriggerMetadata {
+	m, _ := meta.Trigger(t.Kind)
+	return m
+}
+
 // Fire emits an EvalContext with a normalized trigger payload.
 // It always includes: type and operation (if provided).
 // For scheduled_event it also includes updateField (if provided).
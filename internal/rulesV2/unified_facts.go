@@ -1,11 +1,21 @@
 package rulesv2
 
-import "strings"
+import (
+    "strings"
 
-// UnifiedFacts provides minimal derived event.* helpers and generic passthrough for <top>.*
+    meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
+)
+
+// UnifiedFacts provides minimal derived event.* helpers, computed date facts
+// (see computedFacts) and generic passthrough for <top>.*
 // e.g. "employee.EmployeeStatus" resolves under Data["employee"] if present.
 type UnifiedFacts struct{}
 
+// DescribeFacts lists the payload, computed and event.* facts.
+func (UnifiedFacts) DescribeFacts() []meta.FactMetadata {
+    return meta.PayloadFactMetadata()
+}
+
 func (UnifiedFacts) Resolve(evCtx EvalContext, path string) (any, bool, error) {
     // event.* helpers from trigger payload
     if strings.EqualFold(path, "event.Operation") {
@@ -36,6 +46,11 @@ func (UnifiedFacts) Resolve(evCtx EvalContext, path string) (any, bool, error) {
         return nil, true, nil
     }
 
+    // Computed date facts, e.g. employeeCompetency.DaysUntilExpiry
+    if v, ok := resolveComputedFact(evCtx, path); ok {
+        return v, true, nil
+    }
+
     // Generic passthrough: <top>.<field>...
     seg := getPathSegments(path)
     if len(seg) < 2 {
@@ -4,6 +4,10 @@ package rulesv2
 
 import (
     "testing"
+    "time"
+
+    "Automated-Scheduling-Project/internal/database/models"
+    meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
 
     "github.com/stretchr/testify/require"
 )
@@ -60,6 +64,16 @@ func TestUnifiedFacts_Passthrough_MapResolution(t *testing.T) {
     require.Equal(t, "E-123", v)
 }
 
+func TestUnifiedFacts_Passthrough_SnakeCaseColumns(t *testing.T) {
+    ev := EvalContext{Data: map[string]any{
+        "eventAttendance": map[string]any{"employee_number": "E-7", "event_title": "Induction"},
+    }}
+    v, handled, err := UnifiedFacts{}.Resolve(ev, "eventAttendance.EmployeeNumber")
+    require.NoError(t, err)
+    require.True(t, handled)
+    require.Equal(t, "E-7", v)
+}
+
 func TestUnifiedFacts_CaseInsensitiveTopKey(t *testing.T) {
     uf := UnifiedFacts{}
     ev := EvalContext{
@@ -95,4 +109,57 @@ func TestUnifiedFacts_UnknownTop_ReturnsFalse(t *testing.T) {
     require.NoError(t, err)
     require.False(t, handled)
     require.Nil(t, v)
-}
\ No newline at end of file
+}
+
+func TestUnifiedFacts_ComputedTemporalFacts(t *testing.T) {
+    uf := UnifiedFacts{}
+    now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+    expiry := time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC)
+    ev := EvalContext{
+        Now: now,
+        Data: map[string]any{
+            // row-map shape as produced by the relative_time scheduler
+            "employeeCompetency": map[string]any{"expiry_date": expiry, "achievement_date": "2024-12-01"},
+            "employmentHistory":  map[string]any{"start_date": "2024-01-01"},
+            // struct shape
+            "scheduledEvent": models.CustomEventSchedule{EventStartDate: now.Add(50 * time.Hour)},
+        },
+    }
+
+    cases := map[string]any{
+        "employeeCompetency.DaysUntilExpiry":   7,
+        "competency.DaysUntilExpiry":           7, // falls back to employeeCompetency
+        "employeeCompetency.DaysSinceAchieved": 31,
+        "employmentHistory.TenureDays":         366,
+        "scheduledEvent.HoursUntilStart":       50,
+    }
+    for path, want := range cases {
+        v, handled, err := uf.Resolve(ev, path)
+        require.NoError(t, err, path)
+        require.True(t, handled, path)
+        require.Equal(t, want, v, path)
+    }
+
+    // Entity present but no expiry date: resolves to nil rather than "not found".
+    ev.Data["employeeCompetency"] = map[string]any{"expiry_date": nil}
+    v, handled, err := uf.Resolve(ev, "employeeCompetency.DaysUntilExpiry")
+    require.NoError(t, err)
+    require.True(t, handled)
+    require.Nil(t, v)
+
+    // Entity absent.
+    _, handled, _ = uf.Resolve(EvalContext{Now: now, Data: map[string]any{}}, "scheduledEvent.HoursUntilStart")
+    require.False(t, handled)
+}
+
+// Every computed fact must be described, or semantic validation rejects rules
+// that use it.
+func TestComputedFacts_Described(t *testing.T) {
+    described := map[string]bool{}
+    for _, f := range meta.GetRulesMetadata().Facts {
+        described[factKey(f.Name)] = true
+    }
+    for key := range computedFacts {
+        require.True(t, described[key], "computed fact %s has no metadata", key)
+    }
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	meta "Automated-Scheduling-Project/internal/rulesV2/metadata"
+	rsched "Automated-Scheduling-Project/internal/rulesV2/scheduler"
 )
 
 // ValidationError represents a parameter validation error
@@ -24,10 +28,27 @@ type ValidationResult struct {
 
 // ValidateRuleParameters validates a rule's trigger and action parameters
 func ValidateRuleParameters(rule Rulev2) ValidationResult {
+	return validateRuleParameters(nil, rule)
+}
+
+// catalogFor returns what reg describes, or the metadata package's
+// descriptions when there is no registry.
+func catalogFor(reg *Registry) meta.RulesMetadata {
+	if reg != nil {
+		return reg.Metadata()
+	}
+	return meta.GetRulesMetadata()
+}
+
+// validateRuleParameters checks parameters against the catalog of reg.
+// Actions that do not describe their parameters only have their templates
+// checked.
+func validateRuleParameters(reg *Registry, rule Rulev2) ValidationResult {
 	result := ValidationResult{Valid: true, Errors: []ValidationError{}}
+	cat := catalogFor(reg)
 
 	// Validate trigger parameters
-	triggerMeta := findTriggerMetadata(rule.Trigger.Type)
+	triggerMeta := lookupTrigger(cat, rule.Trigger.Type)
 	if triggerMeta == nil {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
@@ -49,9 +70,32 @@ func ValidateRuleParameters(rule Rulev2) ValidationResult {
 		}
 	}
 
+	// Validate condition tree shape (leaf vs all/any/not groups)
+	_ = walkConditions(rule.Conditions, "conditions", func(path string, c Condition) error {
+		if msg := conditionShapeError(c); msg != "" {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Parameter: path,
+				Message:   msg,
+			})
+			return nil
+		}
+		// Operator values that can be checked up front (regex, ranges, lists)
+		if !c.IsGroup() {
+			if err := validateOperatorValue(c.Operator, c.Value); err != nil {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Parameter: path + ".value",
+					Message:   fmt.Sprintf("%s: %v", c.Operator, err),
+				})
+			}
+		}
+		return nil
+	})
+
 	// Validate action parameters
 	for i, action := range rule.Actions {
-		actionMeta := findActionMetadata(action.Type)
+		actionMeta := lookupAction(cat, action.Type)
 		if actionMeta == nil {
 			result.Valid = false
 			result.Errors = append(result.Errors, ValidationError{
@@ -61,9 +105,18 @@ func ValidateRuleParameters(rule Rulev2) ValidationResult {
 			continue
 		}
 
+		// Reject templates with syntax errors or unknown functions
+		collectTemplateErrors(fmt.Sprintf("actions[%d]", i), action.Parameters, func(path string, err error) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Parameter: path,
+				Message:   fmt.Sprintf("invalid template: %v", err),
+			})
+		})
+
 		// Validate action parameters
 		for _, param := range actionMeta.Parameters {
-			if err := validateParameter(param, action.Parameters); err != nil {
+			if err := validateActionParameter(param, action.Parameters); err != nil {
 				result.Valid = false
 				result.Errors = append(result.Errors, ValidationError{
 					Parameter: fmt.Sprintf("actions[%d].%s", i, param.Name),
@@ -78,21 +131,35 @@ func ValidateRuleParameters(rule Rulev2) ValidationResult {
 
 // findTriggerMetadata finds metadata for a specific trigger type
 func findTriggerMetadata(triggerType string) *meta.TriggerMetadata {
-	triggers := meta.GetTriggerMetadata()
-	for _, trigger := range triggers {
-		if trigger.Type == triggerType {
-			return &trigger
-		}
+	if m, ok := meta.Trigger(triggerType); ok {
+		return &m
 	}
 	return nil
 }
 
 // findActionMetadata finds metadata for a specific action type
 func findActionMetadata(actionType string) *meta.ActionMetadata {
-	actions := meta.GetActionMetadata()
-	for _, action := range actions {
-		if action.Type == actionType {
-			return &action
+	if m, ok := meta.Action(actionType); ok {
+		return &m
+	}
+	return nil
+}
+
+// lookupTrigger finds a trigger type in a catalog.
+func lookupTrigger(cat meta.RulesMetadata, triggerType string) *meta.TriggerMetadata {
+	for _, t := range cat.Triggers {
+		if t.Type == triggerType {
+			return &t
+		}
+	}
+	return nil
+}
+
+// lookupAction finds an action type in a catalog.
+func lookupAction(cat meta.RulesMetadata, actionType string) *meta.ActionMetadata {
+	for _, a := range cat.Actions {
+		if a.Type == actionType {
+			return &a
 		}
 	}
 	return nil
@@ -117,24 +184,51 @@ func validateParameter(param meta.Parameter, params map[string]any) error {
 		return err
 	}
 
-	// If options (enum) are provided, enforce membership
-	if len(param.Options) > 0 {
-		valStr := strings.ToLower(fmt.Sprint(value))
-		ok := false
-		for _, opt := range param.Options {
-			if valStr == strings.ToLower(fmt.Sprint(opt)) {
-				ok = true
-				break
-			}
-		}
-		if !ok {
-			return fmt.Errorf("parameter '%s' must be one of %v", param.Name, param.Options)
+	// If options (enum) are provided, enforce membership. Timezone options
+	// only suggest common zones; validateParameterType checks the rest.
+	if len(param.Options) > 0 && param.Type != "timezone" && !inOptions(value, param.Options) {
+		return fmt.Errorf("parameter '%s' must be one of %v", param.Name, param.Options)
+	}
+
+	// Options narrowed by another parameter, e.g. date_field by entity_type
+	if param.DependsOn != "" {
+		dep := strings.ToLower(fmt.Sprint(params[param.DependsOn]))
+		if subset, ok := param.OptionsBy[dep]; ok && !inOptions(value, subset) {
+			return fmt.Errorf("parameter '%s' must be one of %v for %s '%s'", param.Name, subset, param.DependsOn, dep)
 		}
 	}
 
 	return nil
 }
 
+// validateActionParameter is validateParameter for action parameters, which
+// may be templates rendered when the rule fires and take dates as the
+// expressions CreateEventAction understands ("in 3 business days").
+func validateActionParameter(param meta.Parameter, params map[string]any) error {
+	str, ok := params[param.Name].(string)
+	switch {
+	case ok && strings.Contains(str, "{{"):
+		return nil
+	case ok && param.Type == "date":
+		if _, err := NewRelativeDateParser(time.Now()).ParseRelativeDate(str); err != nil {
+			return fmt.Errorf("parameter '%s' must be a date (YYYY-MM-DD HH:MM) or an expression like 'in 1 month': %v", param.Name, err)
+		}
+		return nil
+	}
+	return validateParameter(param, params)
+}
+
+// inOptions reports whether value matches one of opts, case-insensitively.
+func inOptions(value any, opts []any) bool {
+	valStr := strings.ToLower(fmt.Sprint(value))
+	for _, opt := range opts {
+		if valStr == strings.ToLower(fmt.Sprint(opt)) {
+			return true
+		}
+	}
+	return false
+}
+
 // validateParameterType validates that a parameter value matches its expected type
 func validateParameterType(param meta.Parameter, value any) error {
 	if value == nil {
@@ -145,10 +239,17 @@ func validateParameterType(param meta.Parameter, value any) error {
 	}
 
 	switch param.Type {
-	case "string", "text_area", "employees", "event_type", "job_positions":
+	case "string", "text_area", "employees", "job_positions":
 		if _, ok := value.(string); !ok {
 			return fmt.Errorf("parameter '%s' must be a string, got %T", param.Name, value)
 		}
+	case "event_type":
+		// An event definition id, sent as a number or a string
+		switch value.(type) {
+		case string, int, int32, int64, float64:
+		default:
+			return fmt.Errorf("parameter '%s' must be an event definition id, got %T", param.Name, value)
+		}
 	case "number":
 		switch value.(type) {
 		case int, int32, int64, float32, float64:
@@ -194,6 +295,15 @@ func validateParameterType(param meta.Parameter, value any) error {
 		} else {
 			return fmt.Errorf("parameter '%s' must be a time string (HH:MM), got %T", param.Name, value)
 		}
+	case "timezone":
+		// IANA name (e.g. "Europe/London") or fixed offset ("UTC+2")
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("parameter '%s' must be a timezone string, got %T", param.Name, value)
+		}
+		if _, err := rsched.LoadTimezone(str); err != nil {
+			return fmt.Errorf("parameter '%s': %v", param.Name, err)
+		}
 	case "array":
 		if reflect.TypeOf(value).Kind() != reflect.Slice {
 			return fmt.Errorf("parameter '%s' must be an array, got %T", param.Name, value)
@@ -208,3 +318,293 @@ func validateParameterType(param meta.Parameter, value any) error {
 
 	return nil
 }
+
+// InvalidRuleError is returned when a rule fails ValidateRuleSpec on save.
+type InvalidRuleError struct {
+	Errors []ValidationError
+}
+
+func (e *InvalidRuleError) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("invalid rule: %s: %s", e.Errors[0].Parameter, e.Errors[0].Message)
+	}
+	return fmt.Sprintf("invalid rule: %d problems", len(e.Errors))
+}
+
+// ValidateRuleSpec runs every check a rule must pass before it is saved: the
+// parameter checks of ValidateRuleParameters, the registry checks of
+// ValidateRule, and semantic checks against what the rule's trigger supplies.
+// Each condition fact must be available for the trigger, its operator must
+// suit the fact's type and its value must type-check; action templates may
+// only read data the trigger provides.
+func ValidateRuleSpec(reg *Registry, rule Rulev2) ValidationResult {
+	// Time-based triggers cannot run without their parameters, so a missing
+	// map is checked like an empty one.
+	if rule.Trigger.Parameters == nil && (rule.Trigger.Type == "scheduled_time" || rule.Trigger.Type == "relative_time") {
+		rule.Trigger.Parameters = map[string]any{}
+	}
+	result := validateRuleParameters(reg, rule)
+	fail := func(param, msg string) {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{Parameter: param, Message: msg})
+	}
+	if strings.TrimSpace(rule.Name) == "" {
+		fail("name", "rule must have a name")
+	}
+	if err := rule.Limits.validate(); err != nil {
+		fail("limits", err.Error())
+	}
+	if _, err := parseActivePeriod(rule); err != nil {
+		fail("active", err.Error())
+	}
+	for _, e := range validateRuleSemantics(reg, rule) {
+		fail(e.Parameter, e.Message)
+	}
+	// Anything the field checks above missed
+	if result.Valid && reg != nil {
+		if err := ValidateRule(reg, rule); err != nil {
+			fail("rule", err.Error())
+		}
+	}
+	return result
+}
+
+// triggerScope is what a trigger puts in EvalContext.Data: the facts
+// conditions may use and the top-level keys templates may read.
+type triggerScope struct {
+	label string // e.g. "trigger competency", for messages
+	facts map[string]meta.FactMetadata
+	keys  map[string]bool
+}
+
+// scopeFor returns the scope of a trigger in a catalog. ok is false when the
+// trigger type or relative_time entity is unknown; parameter validation
+// reports those.
+func scopeFor(cat meta.RulesMetadata, t TriggerSpec) (triggerScope, bool) {
+	if lookupTrigger(cat, t.Type) == nil {
+		return triggerScope{}, false
+	}
+	sc := triggerScope{
+		label: "trigger " + t.Type,
+		facts: map[string]meta.FactMetadata{},
+		keys:  map[string]bool{"trigger": true},
+	}
+	// Entity events put each object a fact reads in Data; relative_time puts
+	// only its row there (other facts on it are looked up, e.g. DbFacts).
+	source, keysFromFacts := t.Type, true
+	switch t.Type {
+	case "relative_time":
+		entityType, _ := t.Parameters["entity_type"].(string)
+		e, ok := rsched.LookupEntity(entityType)
+		if !ok {
+			return triggerScope{}, false
+		}
+		source, keysFromFacts = e.Type, false
+		sc.label = fmt.Sprintf("relative_time entity_type %q", e.Type)
+		sc.keys[e.DataKey] = true
+	}
+	for _, f := range cat.Facts {
+		// event.* facts read the payload of entity events, which an entity
+		// type of the same name (scheduled_event) does not have.
+		if t.Type == "relative_time" && strings.HasPrefix(f.Name, "event.") {
+			continue
+		}
+		for _, tr := range f.Triggers {
+			if tr != source {
+				continue
+			}
+			sc.facts[factKey(f.Name)] = f
+			if top, _, ok := strings.Cut(f.Name, "."); ok && keysFromFacts {
+				sc.keys[top] = true
+			}
+		}
+	}
+	return sc, true
+}
+
+// factIndex matches the bracketed argument of facts such as
+// employee.HasCompetency[12].
+var factIndex = regexp.MustCompile(`\[[^\]]*\]`)
+
+// factKey normalises a fact path for lookup: facts resolve
+// case-insensitively and bracketed arguments are placeholders.
+func factKey(name string) string {
+	return strings.ToLower(factIndex.ReplaceAllString(strings.TrimSpace(name), "[]"))
+}
+
+// validateRuleSemantics checks conditions and action templates against the
+// scope of the rule's trigger.
+func validateRuleSemantics(reg *Registry, rule Rulev2) []ValidationError {
+	cat := catalogFor(reg)
+	sc, ok := scopeFor(cat, rule.Trigger)
+	if !ok {
+		return nil
+	}
+	var errs []ValidationError
+	add := func(param, format string, args ...any) {
+		errs = append(errs, ValidationError{Parameter: param, Message: fmt.Sprintf(format, args...)})
+	}
+
+	_ = walkConditions(rule.Conditions, "conditions", func(path string, c Condition) error {
+		if c.IsGroup() || conditionShapeError(c) != "" {
+			return nil
+		}
+		f, ok := sc.facts[factKey(c.Fact)]
+		if !ok {
+			add(path+".fact", "fact %q is not available for %s", c.Fact, sc.label)
+			return nil
+		}
+		if !knownOperator(reg, c.Operator) {
+			add(path+".operator", "unknown operator %q", c.Operator)
+			return nil
+		}
+		if !operatorFits(cat, f, c.Operator) {
+			add(path+".operator", "operator %q cannot be used with %s fact %q", c.Operator, f.Type, f.Name)
+			return nil
+		}
+		if err := checkFactValue(f, c.Operator, c.Value); err != nil {
+			add(path+".value", "%s: %v", f.Name, err)
+		}
+		return nil
+	})
+
+	for i, a := range rule.Actions {
+		raw := map[string]bool{}
+		if reg != nil {
+			if rp, ok := reg.Actions[a.Type].(RawParamsAction); ok {
+				for _, k := range rp.RawParams() {
+					raw[k] = true
+				}
+			}
+		}
+		keys := make([]string, 0, len(a.Parameters))
+		for k := range a.Parameters {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if raw[k] {
+				continue
+			}
+			eachParamString(fmt.Sprintf("actions[%d].%s", i, k), a.Parameters[k], func(path, s string) {
+				roots, err := templateRoots(s)
+				if err != nil {
+					return // reported by collectTemplateErrors
+				}
+				for _, r := range roots {
+					if !sc.keys[r] {
+						add(path, "template reads .%s, which %s does not provide (available: %s)", r, sc.label, strings.Join(sortedKeys(sc.keys), ", "))
+					}
+				}
+			})
+		}
+	}
+	return errs
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// knownOperator reports whether op is registered, or described in the
+// metadata when there is no registry.
+func knownOperator(reg *Registry, op string) bool {
+	if reg != nil {
+		return reg.hasOperator(op)
+	}
+	for _, m := range meta.GetOperatorMetadata() {
+		if m.Name == op {
+			return true
+		}
+	}
+	return false
+}
+
+// operatorFits reports whether op suits the fact: listed for the fact, or
+// described in the catalog as working on values of the fact's type.
+func operatorFits(cat meta.RulesMetadata, f meta.FactMetadata, op string) bool {
+	for _, o := range f.Operators {
+		if o == op {
+			return true
+		}
+	}
+	for _, m := range cat.Operators {
+		if m.Name != op {
+			continue
+		}
+		for _, t := range m.Types {
+			if t == f.Type {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkFactValue type-checks a condition value against the fact's type and
+// options. Operators that take no value, or whose value is not of the fact's
+// type (day counts, patterns, relative days), are left to
+// validateOperatorValue.
+func checkFactValue(f meta.FactMetadata, op string, v any) error {
+	switch op {
+	case "isTrue", "isFalse", "isNull", "isNotNull", "isEmpty", "isNotEmpty",
+		"matches", "withinNextDays", "withinLastDays", "olderThanDays", "sameDayAs":
+		return nil
+	case "contains", "notContains":
+		if f.Type == "list" {
+			return nil
+		}
+	case "in", "notIn", "between", "notBetween":
+		rv := reflect.ValueOf(v)
+		if v == nil || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) {
+			return nil
+		}
+		exact := op == "in" || op == "notIn"
+		for i := 0; i < rv.Len(); i++ {
+			if err := checkFactScalar(f, rv.Index(i).Interface(), exact); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+	return checkFactScalar(f, v, op == "equals" || op == "notEquals")
+}
+
+// checkFactScalar checks one value; exact comparisons must also match the
+// fact's options, if it has any.
+func checkFactScalar(f meta.FactMetadata, v any, exact bool) error {
+	if v == nil {
+		return nil
+	}
+	switch f.Type {
+	case "number":
+		if _, ok := asFloat(v); !ok {
+			return fmt.Errorf("%v is not a number", v)
+		}
+	case "date":
+		if _, ok := asTime(v); !ok {
+			return fmt.Errorf("%v is not a date (YYYY-MM-DD or RFC3339)", v)
+		}
+	case "boolean":
+		ok := false
+		switch b := v.(type) {
+		case bool:
+			ok = true
+		case string:
+			_, err := strconv.ParseBool(b)
+			ok = err == nil
+		}
+		if !ok {
+			return fmt.Errorf("%v is not a boolean", v)
+		}
+	}
+	if exact && len(f.Options) > 0 && !inOptions(v, f.Options) {
+		return fmt.Errorf("%v is not one of %v", v, f.Options)
+	}
+	return nil
+}
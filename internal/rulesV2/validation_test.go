@@ -49,6 +49,23 @@ func TestValidateRuleParameters(t *testing.T) {
 		assert.Contains(t, result.Errors[0].Message, "Unknown trigger type")
 	})
 
+	t.Run("InvalidConditionGroup", func(t *testing.T) {
+		rule := Rulev2{
+			Name:    "Invalid Group",
+			Trigger: TriggerSpec{Type: "scheduled_event"},
+			Conditions: []Condition{
+				{Any: []Condition{{Fact: "employee.Department"}}},
+			},
+			Actions: []ActionSpec{},
+		}
+
+		result := ValidateRuleParameters(rule)
+		assert.False(t, result.Valid)
+		assert.Len(t, result.Errors, 1)
+		assert.Equal(t, "conditions[0].any[0]", result.Errors[0].Parameter)
+		assert.Contains(t, result.Errors[0].Message, "missing operator")
+	})
+
 	t.Run("InvalidActionType", func(t *testing.T) {
 		rule := Rulev2{
 			Name: "Invalid Rule",
@@ -112,6 +129,24 @@ func TestValidateRuleParameters(t *testing.T) {
 		assert.False(t, result.Valid)
 		assert.GreaterOrEqual(t, len(result.Errors), 1) // At least 1 error for wrong type
 	})
+
+	t.Run("DateFieldNarrowedByEntity", func(t *testing.T) {
+		rule := Rulev2{
+			Name: "Relative",
+			Trigger: TriggerSpec{Type: "relative_time", Parameters: map[string]any{
+				"entity_type": "employee", "date_field": "expiry_date",
+				"offset_direction": "before", "offset_value": 1, "offset_unit": "days",
+			}},
+		}
+		result := ValidateRuleParameters(rule)
+		assert.False(t, result.Valid)
+		if assert.Len(t, result.Errors, 1) {
+			assert.Contains(t, result.Errors[0].Message, "for entity_type 'employee'")
+		}
+
+		rule.Trigger.Parameters["entity_type"] = "employee_competency"
+		assert.True(t, ValidateRuleParameters(rule).Valid)
+	})
 }
 
 func TestFindTriggerMetadata(t *testing.T) {
@@ -188,6 +223,23 @@ func TestValidateParameterType(t *testing.T) {
 		assert.Contains(t, err.Error(), "must be a number")
 	})
 
+	t.Run("Timezone", func(t *testing.T) {
+		param := meta.Parameter{
+			Name:    "timezone",
+			Type:    "timezone",
+			Options: []any{"UTC+0", "UTC+2"},
+		}
+		assert.NoError(t, validateParameterType(param, "UTC+2"))
+		assert.NoError(t, validateParameterType(param, "America/New_York"))
+		// Options only suggest zones: other tzdata names pass validateParameter.
+		assert.NoError(t, validateParameter(param, map[string]any{"timezone": "Asia/Kathmandu"}))
+
+		err := validateParameter(param, map[string]any{"timezone": "Nowhere/Special"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown timezone")
+		assert.Error(t, validateParameterType(param, 2))
+	})
+
 	t.Run("ValidBoolean", func(t *testing.T) {
 		param := meta.Parameter{
 			Name:     "test",
@@ -303,3 +355,155 @@ func TestValidateParameterType(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestValidateRuleSpec(t *testing.T) {
+	reg := NewRegistryWithDefaults().
+		UseFactResolver(DbFacts{}).
+		UseFactResolver(UnifiedFacts{}).
+		UseTrigger("competency", NewTrigger(nil, "competency")).
+		UseTrigger("scheduled_time", NewTrigger(nil, "scheduled_time")).
+		UseTrigger("relative_time", NewTrigger(nil, "relative_time")).
+		UseAction("notification", &NotificationAction{}).
+		UseAction("custom", &capturingAction{})
+	notify := ActionSpec{Type: "notification", Parameters: map[string]any{
+		"recipients": `["EMP001"]`, "subject": "Expiring", "message": "Hello {{.employeeCompetency.EmployeeNumber}}",
+	}}
+	relative := TriggerSpec{Type: "relative_time", Parameters: map[string]any{
+		"entity_type": "employee_competency", "date_field": "expiry_date",
+		"offset_direction": "before", "offset_value": 30, "offset_unit": "days",
+	}}
+	base := Rulev2{Name: "expiry", Trigger: relative,
+		Conditions: []Condition{{Fact: "employeeCompetency.DaysUntilExpiry", Operator: "lessThan", Value: 31}},
+		Actions:    []ActionSpec{notify}}
+
+	errorsOf := func(r Rulev2) map[string]string {
+		res := ValidateRuleSpec(reg, r)
+		out := map[string]string{}
+		for _, e := range res.Errors {
+			out[e.Parameter] = e.Message
+		}
+		assert.Equal(t, len(out) == 0, res.Valid)
+		return out
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		assert.Empty(t, errorsOf(base))
+	})
+
+	t.Run("ComputedFactAliases", func(t *testing.T) {
+		// The seed rules use competency.DaysUntilExpiry rather than the
+		// employeeCompetency name.
+		r := base
+		r.Conditions = []Condition{
+			{Fact: "competency.DaysUntilExpiry", Operator: "lessThanEqual", Value: 30},
+			{Fact: "competency.DaysSinceAchieved", Operator: "greaterThan", Value: 0},
+		}
+		assert.Empty(t, errorsOf(r))
+
+		r.Trigger = TriggerSpec{Type: "relative_time", Parameters: map[string]any{
+			"entity_type": "employment_history", "date_field": "start_date",
+			"offset_direction": "after", "offset_value": 90, "offset_unit": "days",
+		}}
+		r.Conditions = []Condition{{Fact: "employee.TenureDays", Operator: "greaterThanEqual", Value: 90}}
+		r.Actions = nil
+		assert.Empty(t, errorsOf(r))
+	})
+
+	t.Run("FactNotAvailableForTrigger", func(t *testing.T) {
+		r := base
+		r.Conditions = []Condition{{Fact: "scheduledEvent.Title", Operator: "equals", Value: "x"}}
+		errs := errorsOf(r)
+		assert.Contains(t, errs["conditions[0].fact"], `fact "scheduledEvent.Title" is not available for relative_time entity_type "employee_competency"`)
+	})
+
+	t.Run("OperatorDoesNotSuitFactType", func(t *testing.T) {
+		r := base
+		r.Conditions = []Condition{{Any: []Condition{{Fact: "employeeCompetency.ExpiryDate", Operator: "startsWith", Value: "2025"}}}}
+		assert.Contains(t, errorsOf(r)["conditions[0].any[0].operator"], `operator "startsWith" cannot be used with date fact`)
+	})
+
+	t.Run("ValuesTypeCheck", func(t *testing.T) {
+		r := base
+		r.Conditions = []Condition{
+			{Fact: "employeeCompetency.DaysUntilExpiry", Operator: "lessThan", Value: "soon"},
+			{Fact: "employeeCompetency.ExpiryDate", Operator: "before", Value: "next tuesday"},
+			{Fact: "employeeCompetency.CompetencyID", Operator: "in", Value: []any{1, "two"}},
+			{Fact: "employeeCompetency.ExpiryDate", Operator: "withinNextDays", Value: 30},
+		}
+		errs := errorsOf(r)
+		assert.Contains(t, errs["conditions[0].value"], "soon is not a number")
+		assert.Contains(t, errs["conditions[1].value"], "is not a date")
+		assert.Contains(t, errs["conditions[2].value"], "item 1: two is not a number")
+		assert.NotContains(t, errs, "conditions[3].value")
+	})
+
+	t.Run("EnumeratedOptions", func(t *testing.T) {
+		r := base
+		r.Trigger = TriggerSpec{Type: "relative_time", Parameters: map[string]any{
+			"entity_type": "job_matrix", "date_field": "creation_date",
+			"offset_direction": "after", "offset_value": 1, "offset_unit": "days",
+		}}
+		r.Actions = nil
+		r.Conditions = []Condition{{Fact: "jobMatrix.RequirementStatus", Operator: "equals", Value: "Mandatory"}}
+		assert.Contains(t, errorsOf(r)["conditions[0].value"], "Mandatory is not one of [Required Optional]")
+
+		r.Conditions[0].Value = "required"
+		assert.Empty(t, errorsOf(r))
+	})
+
+	t.Run("TemplateReadsMissingData", func(t *testing.T) {
+		r := base
+		r.Actions = []ActionSpec{{Type: "notification", Parameters: map[string]any{
+			"recipients": `["EMP001"]`, "subject": "{{.scheduledEvent.Title}}", "message": "{{range .trigger}}{{.x}}{{end}}",
+		}}}
+		errs := errorsOf(r)
+		assert.Contains(t, errs["actions[0].subject"], "template reads .scheduledEvent")
+		assert.Contains(t, errs["actions[0].subject"], "available: employeeCompetency, trigger")
+		assert.NotContains(t, errs, "actions[0].message")
+	})
+
+	t.Run("DigestTemplatesAreNotCheckedAgainstTrigger", func(t *testing.T) {
+		r := base
+		r.Actions = []ActionSpec{{Type: "notification", Parameters: map[string]any{
+			"recipients": `["EMP001"]`, "subject": "s", "message": "m",
+			"digest": "tick", "digestSubject": "{{.Count}} expiring",
+		}}}
+		assert.Empty(t, errorsOf(r))
+	})
+
+	t.Run("EventHelpersAndUndescribedActions", func(t *testing.T) {
+		r := Rulev2{Name: "on update", Trigger: TriggerSpec{Type: "competency"},
+			Conditions: []Condition{{Fact: "event.Operation", Operator: "equals", Value: "update"}},
+			Actions:    []ActionSpec{{Type: "custom", Parameters: map[string]any{"url": "https://example.com"}}}}
+		assert.Empty(t, errorsOf(r))
+
+		assert.False(t, ValidateRuleParameters(r).Valid, "metadata alone does not describe custom")
+	})
+
+	t.Run("TimeTriggersNeedParameters", func(t *testing.T) {
+		r := Rulev2{Name: "daily", Trigger: TriggerSpec{Type: "scheduled_time"}}
+		assert.Contains(t, errorsOf(r)["trigger.frequency"], "required parameter 'frequency' is missing")
+	})
+
+	t.Run("NameAndLimits", func(t *testing.T) {
+		r := base
+		r.Name = ""
+		r.Limits = &RuleLimits{MaxFires: 1}
+		errs := errorsOf(r)
+		assert.Contains(t, errs["name"], "rule must have a name")
+		assert.Contains(t, errs["limits"], "maxFires needs a window")
+	})
+
+	t.Run("ActionDateExpressions", func(t *testing.T) {
+		r := Rulev2{Name: "book", Trigger: TriggerSpec{Type: "competency"},
+			Actions: []ActionSpec{{Type: "create_event", Parameters: map[string]any{
+				"title": "Refresher", "customEventID": 3, "startTime": "in 5 business days",
+				"endTime": "{{ formatDate \"iso\" (addDays 6 now) }}",
+			}}}}
+		reg.UseAction("create_event", &CreateEventAction{})
+		assert.Empty(t, errorsOf(r))
+
+		r.Actions[0].Parameters["startTime"] = "someday"
+		assert.Contains(t, errorsOf(r)["actions[0].startTime"], "must be a date")
+	})
+}
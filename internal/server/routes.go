@@ -70,7 +70,11 @@ func (s *Server) RegisterRoutes() http.Handler {
 	profile.RegisterProfileRoutes(r)
 	employee_competencies.RegisterEmployeeCompetencyRoutes(r)
 	employment_history.RegisterEmploymentHistoryRoutes(r)
-	rulesv2.RegisterRulesRoutes(r, s.rulesService)
+	rulesv2.RegisterRulesRoutes(r, s.rulesService, rulesv2.RouteAccess{
+		Authenticate:      auth.AuthMiddleware(),
+		RequirePermission: role.RequirePage,
+		HasPermission:     role.HasPage,
+	})
 
 	return r
 }
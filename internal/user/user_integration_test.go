@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
@@ -27,6 +28,7 @@ type User struct {
 	Password           string
 	ForgotPasswordLink *string
 	Role               string
+	CreatedAt          *time.Time
 }
 
 const (
@@ -65,7 +65,7 @@ func TestGetAllUsersHandler_Unit(t *testing.T) {
 	db, mock := newMockDB(t)
 
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT "users"."id","users"."username","users"."password","users"."forgot_password_link","users"."role","users"."employee_number" FROM "users"`)).
+		`SELECT "users"."id","users"."username","users"."password","users"."forgot_password_link","users"."role","users"."employee_number","users"."created_at" FROM "users"`)).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "employee_number", "role"}).
 			AddRow(1, "user1", "E001", "Admin").
 			AddRow(2, "user2", "E002", "User"))
@@ -116,7 +116,7 @@ func TestAddUserHandler_Success_Unit(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`INSERT INTO "users" ("username","password","forgot_password_link","role","employee_number") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		`INSERT INTO "users" ("username","password","forgot_password_link","role","employee_number") VALUES ($1,$2,$3,$4,$5) RETURNING "id","created_at"`)).
 		WithArgs(addUserReq.Username, sqlmock.AnyArg(), "", addUserReq.Role, testNewUserEmpNum).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
@@ -168,7 +168,7 @@ func TestUpdateUserHandler_Success_Unit(t *testing.T) {
 	userID := 1
 
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT "users"."id","users"."username","users"."password","users"."forgot_password_link","users"."role","users"."employee_number" FROM "users" WHERE "users"."id" = $1 ORDER BY "users"."id" LIMIT $2`)).
+		`SELECT "users"."id","users"."username","users"."password","users"."forgot_password_link","users"."role","users"."employee_number","users"."created_at" FROM "users" WHERE "users"."id" = $1 ORDER BY "users"."id" LIMIT $2`)).
 		WithArgs(userID, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "employee_number", "role"}).
 			AddRow(userID, testUserUsername, testUserEmpNum, "User"))
@@ -228,7 +228,7 @@ func TestUpdateUserHandler_UserNotFound_Unit(t *testing.T) {
 	userID := 999
 
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT "users"."id","users"."username","users"."password","users"."forgot_password_link","users"."role","users"."employee_number" FROM "users" WHERE "users"."id" = $1 ORDER BY "users"."id" LIMIT $2`)).
+		`SELECT "users"."id","users"."username","users"."password","users"."forgot_password_link","users"."role","users"."employee_number","users"."created_at" FROM "users" WHERE "users"."id" = $1 ORDER BY "users"."id" LIMIT $2`)).
 		WithArgs(userID, 1).
 		WillReturnError(gorm.ErrRecordNotFound)
 
@@ -6,6 +6,8 @@ export type AllowedPage =
   | 'event-definitions'
   | 'events'
   | 'rules'
+  | 'rules-edit'
+  | 'rules-admin'
   | 'competencies'
   | 'main-help';
 